@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+)
+
+// redactHandler is an slog.Handler that masks secret values in log output
+// before passing records to the underlying handler.
+type redactHandler struct {
+	underlying slog.Handler
+	redact     func(string) string
+}
+
+// newRedactLogger wraps a logger so secrets are masked in messages and attributes.
+func newRedactLogger(logger *slog.Logger, redact func(string) string) *slog.Logger {
+	return slog.New(&redactHandler{underlying: logger.Handler(), redact: redact})
+}
+
+// Enabled reports whether the underlying handler handles records at the given level.
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.underlying.Enabled(ctx, level)
+}
+
+// Handle masks secrets in the record and forwards it.
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.underlying.Handle(ctx, redacted)
+}
+
+// WithAttrs returns a new handler with the given attributes masked.
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+	return &redactHandler{underlying: h.underlying.WithAttrs(redacted), redact: h.redact}
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{underlying: h.underlying.WithGroup(name), redact: h.redact}
+}
+
+// redactAttr masks secrets in string, error and group attribute values.
+func (h *redactHandler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redactAttr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		switch val := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, h.redact(val.Error()))
+		case []string:
+			redacted := make([]string, len(val))
+			for i, s := range val {
+				redacted[i] = h.redact(s)
+			}
+			return slog.Any(a.Key, redacted)
+		}
+	}
+	return a
+}
+
+// Ensure redactHandler implements slog.Handler at compile time.
+var _ slog.Handler = (*redactHandler)(nil)
@@ -46,7 +46,8 @@ Example:
   athyr-agent run agent.yaml --server localhost:9090
   athyr-agent run agent.yaml --tui
   athyr-agent run agent.yaml --verbose
-  athyr-agent run agent.yaml --quiet --log-format=json`,
+  athyr-agent run agent.yaml --quiet --log-format=json
+  athyr-agent run agent.yaml --lenient`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filepath := args[0]
@@ -65,7 +66,7 @@ Example:
 		}
 
 		// Load and validate config
-		cfg, err := config.LoadFile(filepath)
+		cfg, err := loadConfig(filepath)
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
@@ -73,6 +74,12 @@ Example:
 			return fmt.Errorf("invalid config: %w", err)
 		}
 
+		// Without the TUI, approval requests can only be answered over the topic
+		tools := cfg.Agent.Tools
+		if !useTUI && len(tools.RequireApproval) > 0 && tools.Approval.Topic == "" {
+			return fmt.Errorf("agent.tools.require_approval needs agent.tools.approval.topic when running without --tui")
+		}
+
 		if useTUI {
 			return runWithTUI(cfg, logLevel)
 		}
@@ -85,6 +92,7 @@ func init() {
 	runCmd.Flags().BoolVar(&useTUI, "tui", false, "run with interactive terminal UI")
 	runCmd.Flags().BoolVar(&quiet, "quiet", false, "only show errors (mutually exclusive with --verbose)")
 	runCmd.Flags().StringVar(&logFormat, "log-format", "text", "log output format: text or json")
+	runCmd.Flags().BoolVar(&lenient, "lenient", false, "ignore unknown fields in the agent file instead of failing")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -98,7 +106,7 @@ func runHeadless(cfg *config.Config, logLevel slog.Level) error {
 	} else {
 		handler = slog.NewTextHandler(os.Stderr, opts)
 	}
-	logger := slog.New(handler)
+	logger := newRedactLogger(slog.New(handler), cfg.Redact)
 
 	// Collect MCP server names for logging
 	var mcpServers []string
@@ -133,6 +141,11 @@ func runHeadless(cfg *config.Config, logLevel slog.Level) error {
 		sig := <-sigCh
 		logger.Info("received signal, shutting down", "signal", sig)
 		cancel()
+
+		// A second signal skips the drain
+		sig = <-sigCh
+		logger.Warn("received second signal, exiting immediately", "signal", sig)
+		os.Exit(1)
 	}()
 
 	// Run the agent
@@ -145,8 +158,8 @@ func runWithTUI(cfg *config.Config, logLevel slog.Level) error {
 	eventBus := runner.NewEventBus(100)
 	defer eventBus.Close()
 
-	// Create TUI logger that emits to event bus
-	logger := tui.NewTUILogger(eventBus, logLevel)
+	// Create TUI logger that emits to event bus, masking secrets
+	logger := newRedactLogger(tui.NewTUILogger(eventBus, logLevel), cfg.Redact)
 
 	logger.Info("loaded agent config",
 		"name", cfg.Agent.Name,
@@ -206,6 +219,7 @@ func runWithTUI(cfg *config.Config, logLevel slog.Level) error {
 						handler: r.Handler(),
 						tuiSend: tuiApp.Send,
 					})
+					tuiApp.SetApprovalHandler(&approvalHandlerAdapter{handler: r.Handler()})
 					return
 				}
 			}
@@ -230,14 +244,15 @@ func runWithTUI(cfg *config.Config, logLevel slog.Level) error {
 	// TUI exited normally, cancel runner
 	cancel()
 
-	// Wait for runner to finish (with timeout)
+	// Wait for the runner to drain in-flight messages (with timeout)
+	gracePeriod, _ := cfg.Agent.Shutdown.GetGracePeriod()
 	select {
 	case err := <-errCh:
 		if err != nil && err.Error() != "runner error: context canceled" {
 			return err
 		}
-	default:
-		// Runner might not have finished yet, that's ok
+	case <-time.After(gracePeriod + 5*time.Second):
+		// Runner didn't finish in time, exit anyway
 	}
 
 	return nil
@@ -248,8 +263,37 @@ type chatHandlerAdapter struct {
 	handler *runner.MessageHandler
 }
 
-func (a *chatHandlerAdapter) DirectChat(content string) (string, string, int, error) {
-	return a.handler.DirectChat(content)
+func (a *chatHandlerAdapter) DirectChat(exchange int, content string) (string, string, int, error) {
+	return a.handler.DirectChat(exchange, content)
+}
+
+func (a *chatHandlerAdapter) ResetChat() error {
+	return a.handler.ResetChat()
+}
+
+func (a *chatHandlerAdapter) SetChatSystemPrompt(prompt string) {
+	a.handler.SetChatSystemPrompt(prompt)
+}
+
+func (a *chatHandlerAdapter) SetChatModel(model string) {
+	a.handler.SetChatModel(model)
+}
+
+func (a *chatHandlerAdapter) SaveChat(path string) error {
+	return a.handler.SaveChat(path)
+}
+
+func (a *chatHandlerAdapter) PreviewPipeline(content string) (*runner.PipelinePreview, error) {
+	return a.handler.PreviewPipeline(content)
+}
+
+// approvalHandlerAdapter adapts the MessageHandler to the tui.ApprovalHandler interface.
+type approvalHandlerAdapter struct {
+	handler *runner.MessageHandler
+}
+
+func (a *approvalHandlerAdapter) DecideApproval(id string, approved bool) error {
+	return a.handler.DecideApproval(id, runner.ApprovalDecision{Approved: approved, By: "tui"})
 }
 
 // messagingHandlerAdapter adapts the MessageHandler to the tui.MessagingHandler interface.
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for agent YAML files",
+	Long: `Print a JSON Schema (draft 2020-12) describing agent YAML files.
+
+Editors with YAML language server support can use it for autocompletion
+and inline validation.
+
+Example:
+  athyr-agent schema > athyr-agent.schema.json
+
+Then reference it from an agent file:
+  # yaml-language-server: $schema=./athyr-agent.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode schema: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
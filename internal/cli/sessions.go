@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+	"github.com/athyr-tech/athyr-agent/internal/runner"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// offline skips the running agent and uses the persisted session map.
+var offline bool
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Inspect and manage an agent's memory sessions",
+	Long: `Inspect and manage the memory sessions of an agent.
+
+The commands send a session control message to the running agent on its
+memory.control_topic. With --offline they use the session map the agent
+persists to memory.store instead; stop the agent first, since a running
+agent overwrites the file.
+
+Example:
+  athyr-agent sessions list agent.yaml
+  athyr-agent sessions show agent.yaml user-123
+  athyr-agent sessions reset agent.yaml user-123
+  athyr-agent sessions delete agent.yaml user-123 --offline`,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list <file>",
+	Short: "List an agent's memory sessions",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := sessionControl(cmd.Context(), args[0], runner.SessionControl{Action: runner.SessionControlList})
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			fmt.Println("No sessions.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "USER SESSION\tSERVER SESSION\tCREATED\tLAST USED\tTURNS")
+		for _, rec := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+				rec.UserSessionID,
+				rec.ServerSessionID,
+				formatTime(rec.CreatedAt),
+				formatTime(rec.LastUsed),
+				rec.Turns,
+			)
+		}
+		return w.Flush()
+	},
+}
+
+var sessionsShowCmd = &cobra.Command{
+	Use:   "show <file> <session-id>",
+	Short: "Show one memory session",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := sessionControl(cmd.Context(), args[0], runner.SessionControl{
+			Action:    runner.SessionControlShow,
+			SessionID: args[1],
+		})
+		if err != nil {
+			return err
+		}
+
+		rec := records[0]
+		fmt.Printf("User session:   %s\n", rec.UserSessionID)
+		fmt.Printf("Server session: %s\n", rec.ServerSessionID)
+		fmt.Printf("Created:        %s\n", formatTime(rec.CreatedAt))
+		fmt.Printf("Last used:      %s (%s ago)\n", formatTime(rec.LastUsed), time.Since(rec.LastUsed).Round(time.Second))
+		fmt.Printf("Turns:          %d\n", rec.Turns)
+		return nil
+	},
+}
+
+var sessionsDeleteCmd = &cobra.Command{
+	Use:   "delete <file> <session-id>",
+	Short: "Delete a memory session and its history",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := sessionControl(cmd.Context(), args[0], runner.SessionControl{
+			Action:    runner.SessionControlDelete,
+			SessionID: args[1],
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted session %s (server session %s)\n", records[0].UserSessionID, records[0].ServerSessionID)
+		return nil
+	},
+}
+
+var sessionsResetCmd = &cobra.Command{
+	Use:   "reset <file> <session-id>",
+	Short: "Clear a memory session's history",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := sessionControl(cmd.Context(), args[0], runner.SessionControl{
+			Action:    runner.SessionControlReset,
+			SessionID: args[1],
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reset session %s; the next message starts a new conversation\n", records[0].UserSessionID)
+		return nil
+	},
+}
+
+func init() {
+	sessionsCmd.PersistentFlags().BoolVar(&offline, "offline", false, "use the persisted session map instead of the running agent")
+	sessionsCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "disable TLS (for development)")
+	sessionsCmd.AddCommand(sessionsListCmd, sessionsShowCmd, sessionsDeleteCmd, sessionsResetCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
+
+// sessionControl performs a session control action for the agent defined in
+// path, on the running agent, or on its session file with --offline.
+func sessionControl(ctx context.Context, path string, ctl runner.SessionControl) ([]runner.SessionRecord, error) {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Agent.Memory.Enabled {
+		return nil, fmt.Errorf("memory is not enabled for agent '%s'", cfg.Agent.Name)
+	}
+
+	// Editing the file behind a running agent would be undone by its next save
+	if !offline {
+		reply, err := requestSessionControl(ctx, cfg, ctl)
+		if err != nil {
+			return nil, fmt.Errorf("agent not reachable (use --offline to edit the session file of a stopped agent): %w", err)
+		}
+		if reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+		return reply.Sessions, nil
+	}
+
+	storePath, err := cfg.Agent.Memory.GetStorePath(cfg.Agent.Name)
+	if err != nil {
+		return nil, err
+	}
+	return controlSessionFile(storePath, ctl)
+}
+
+// requestSessionControl connects to the server and sends ctl to the agent's
+// memory.control_topic.
+func requestSessionControl(ctx context.Context, cfg *config.Config, ctl runner.SessionControl) (*runner.SessionControlReply, error) {
+	topic := cfg.Agent.Memory.ControlTopic
+	if topic == "" {
+		return nil, errors.New("agent.memory.control_topic is not set")
+	}
+
+	opts := []athyr.AgentOption{
+		athyr.WithAgentCard(athyr.AgentCard{
+			Name:        cfg.Agent.Name + "-sessions",
+			Description: "athyr-agent sessions command",
+			Version:     "1.0.0",
+			Metadata:    map[string]string{"runner": "athyr-agent"},
+		}),
+	}
+	if insecure {
+		opts = append(opts, athyr.WithInsecure())
+	}
+
+	agent, err := athyr.NewAgent(viper.GetString("server"), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent: %w", err)
+	}
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := agent.Connect(connectCtx); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer agent.Close()
+
+	return runner.RequestSessionControl(ctx, agent, topic, ctl)
+}
+
+// controlSessionFile performs a session control action on a persisted session
+// map. Deleting or resetting a session removes its mapping, so the next message
+// starts a new conversation; server-side history is left to expire.
+func controlSessionFile(path string, ctl runner.SessionControl) ([]runner.SessionRecord, error) {
+	records, err := runner.ReadSessionFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		records, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ctl.Action == runner.SessionControlList {
+		return records, nil
+	}
+
+	i := slices.IndexFunc(records, func(rec runner.SessionRecord) bool {
+		return rec.UserSessionID == ctl.SessionID
+	})
+	if i < 0 {
+		return nil, fmt.Errorf("unknown session %q in %s", ctl.SessionID, path)
+	}
+	rec := records[i]
+	if ctl.Action == runner.SessionControlShow {
+		return []runner.SessionRecord{rec}, nil
+	}
+
+	if err := runner.WriteSessionFile(path, slices.Delete(records, i, i+1)); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	return []runner.SessionRecord{rec}, nil
+}
+
+// formatTime formats a session timestamp in local time.
+func formatTime(t time.Time) string {
+	return t.Local().Format(time.DateTime)
+}
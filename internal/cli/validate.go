@@ -9,20 +9,26 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// lenient ignores unknown YAML fields instead of failing (shared by run and validate).
+var lenient bool
+
 var validateCmd = &cobra.Command{
 	Use:   "validate <file>",
 	Short: "Validate an agent YAML file",
 	Long: `Validate an agent YAML file without running it.
 
 Checks that the YAML is well-formed and contains all required fields.
+Unknown fields (e.g. typos like "subscibe") are rejected unless --lenient
+is set, in which case they are reported as warnings.
 
 Example:
-  athyr-agent validate agent.yaml`,
+  athyr-agent validate agent.yaml
+  athyr-agent validate agent.yaml --lenient`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filepath := args[0]
 
-		cfg, err := config.LoadFile(filepath)
+		cfg, err := loadConfig(filepath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Validation failed: %v\n", err)
 			return err
@@ -33,17 +39,33 @@ Example:
 			return err
 		}
 
-		fmt.Printf("Agent '%s' is valid.\n", cfg.Agent.Name)
+		fmt.Printf("Agent '%s' is valid.\n", cfg.Redact(cfg.Agent.Name))
 		if verbose {
-			fmt.Printf("  description: %s\n", cfg.Agent.Description)
-			fmt.Printf("  model:       %s\n", cfg.Agent.Model)
-			fmt.Printf("  subscribe:   %v\n", cfg.Agent.Topics.Subscribe)
-			fmt.Printf("  publish:     %v\n", cfg.Agent.Topics.Publish)
+			fmt.Printf("  description: %s\n", cfg.Redact(cfg.Agent.Description))
+			fmt.Printf("  model:       %s\n", cfg.Redact(cfg.Agent.Model))
+			if len(cfg.Agent.FallbackModels) > 0 {
+				fmt.Printf("  fallbacks:   %s\n", cfg.Redact(fmt.Sprint(cfg.Agent.FallbackModels)))
+			}
+			fmt.Printf("  subscribe:   %s\n", cfg.Redact(fmt.Sprint(cfg.Agent.Topics.Subscribe)))
+			fmt.Printf("  publish:     %s\n", cfg.Redact(fmt.Sprint(cfg.Agent.Topics.Publish)))
 		}
 		return nil
 	},
 }
 
 func init() {
+	validateCmd.Flags().BoolVar(&lenient, "lenient", false, "ignore unknown fields instead of failing")
 	rootCmd.AddCommand(validateCmd)
 }
+
+// loadConfig loads an agent file, honoring --lenient and printing any warnings.
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.LoadFileWithOptions(path, config.LoadOptions{Lenient: lenient})
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range cfg.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", w)
+	}
+	return cfg, nil
+}
@@ -3,7 +3,13 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,42 +18,80 @@ import (
 
 // Config represents a complete agent YAML file.
 type Config struct {
-	Agent AgentConfig `yaml:"agent"`
+	Agent AgentConfig `yaml:"agent" jsonschema:"Agent definition"`
+
+	file       string              // source file, used in error positions
+	positions  map[string]position // YAML path → source position
+	unresolved []fieldIssue        // unresolved ${...} references, reported by Validate
+	warnings   []fieldIssue        // unknown fields ignored in lenient mode
+	secrets    []string            // values loaded from ${file:...} references
+}
+
+// LoadOptions controls how agent YAML is decoded.
+type LoadOptions struct {
+	// Lenient ignores unknown fields instead of failing; they are reported by Warnings.
+	Lenient bool
 }
 
 // AgentConfig defines the agent's configuration.
 type AgentConfig struct {
-	Name         string           `yaml:"name"`
-	Description  string           `yaml:"description"`
-	Model        string           `yaml:"model"`
-	Instructions string           `yaml:"instructions"`
-	Plugins      []PluginConfig   `yaml:"plugins,omitempty"`
-	Topics       TopicsConfig     `yaml:"topics"`
-	Memory       MemoryConfig     `yaml:"memory,omitempty"`
-	MCP          MCPConfig        `yaml:"mcp,omitempty"`
-	Connection   ConnectionConfig `yaml:"connection,omitempty"`
+	Name             string                 `yaml:"name" jsonschema:"Unique agent name, used for registration with Athyr"`
+	Description      string                 `yaml:"description" jsonschema:"Human-readable description"`
+	Model            string                 `yaml:"model" jsonschema:"LLM model identifier (e.g. google/gemini-2.5-flash-lite)"`
+	FallbackModels   []string               `yaml:"fallback_models,omitempty" jsonschema:"Models to try in order when the primary model fails"`
+	Instructions     string                 `yaml:"instructions" jsonschema:"System prompt sent to the LLM with every request"`
+	InstructionsFrom InstructionsFromConfig `yaml:"instructions_from,omitempty" jsonschema:"MCP prompt used as the system prompt instead of instructions"`
+	Plugins          []PluginConfig         `yaml:"plugins,omitempty" jsonschema:"Lua plugin definitions"`
+	Topics           TopicsConfig           `yaml:"topics" jsonschema:"Pub/sub topic configuration"`
+	Completion       CompletionConfig       `yaml:"completion,omitempty" jsonschema:"LLM completion parameters"`
+	Streaming        StreamingConfig        `yaml:"streaming,omitempty" jsonschema:"Publish responses incrementally while they are generated"`
+	Memory           MemoryConfig           `yaml:"memory,omitempty" jsonschema:"Session memory settings"`
+	MCP              MCPConfig              `yaml:"mcp,omitempty" jsonschema:"MCP tool server connections"`
+	Tools            ToolsConfig            `yaml:"tools,omitempty" jsonschema:"How the LLM's MCP tool calls are executed"`
+	Connection       ConnectionConfig       `yaml:"connection,omitempty" jsonschema:"SDK connection tuning"`
+	Processing       ProcessingConfig       `yaml:"processing,omitempty" jsonschema:"Per-message processing limits"`
+	Errors           ErrorsConfig           `yaml:"errors,omitempty" jsonschema:"Retry and dead-letter handling for failed messages"`
+	Shutdown         ShutdownConfig         `yaml:"shutdown,omitempty" jsonschema:"Graceful shutdown settings"`
+}
+
+// GetModels returns the primary model followed by the fallback models, in the order they are tried.
+func (a *AgentConfig) GetModels() []string {
+	return append([]string{a.Model}, a.FallbackModels...)
+}
+
+// InstructionsFromConfig names an MCP prompt to use as the agent's instructions.
+// The prompt is fetched once, when the agent starts.
+type InstructionsFromConfig struct {
+	Server string            `yaml:"server" jsonschema:"Name of the MCP server providing the prompt"`
+	Prompt string            `yaml:"prompt" jsonschema:"Name of the prompt on the server"`
+	Args   map[string]string `yaml:"args,omitempty" jsonschema:"Arguments passed to the prompt"`
+}
+
+// IsSet reports whether the instructions come from an MCP prompt.
+func (i *InstructionsFromConfig) IsSet() bool {
+	return i.Server != "" || i.Prompt != ""
 }
 
 // PluginConfig defines a Lua plugin.
 type PluginConfig struct {
-	Name     string         `yaml:"name"`
-	File     string         `yaml:"file"`
-	Restrict []string       `yaml:"restrict,omitempty"`
-	Config   map[string]any `yaml:"config,omitempty"`
+	Name     string         `yaml:"name" jsonschema:"Unique plugin name, referenced in topics.subscribe or topics.publish"`
+	File     string         `yaml:"file" jsonschema:"Path to the Lua file"`
+	Restrict []string       `yaml:"restrict,omitempty" jsonschema:"Bridge APIs to block (e.g. http, fs.write)"`
+	Config   map[string]any `yaml:"config,omitempty" jsonschema:"Key-value pairs passed to the Lua subscribe/publish functions"`
 }
 
 // TopicsConfig defines pub/sub topics.
 type TopicsConfig struct {
-	Subscribe []string      `yaml:"subscribe"`
-	Publish   []string      `yaml:"publish"`
-	Routes    []RouteConfig `yaml:"routes,omitempty"`
+	Subscribe []string      `yaml:"subscribe" jsonschema:"Topics or plugin sources to receive messages from"`
+	Publish   []string      `yaml:"publish" jsonschema:"Topics or plugin destinations to send responses to"`
+	Routes    []RouteConfig `yaml:"routes,omitempty" jsonschema:"Dynamic routing destinations the LLM can choose from"`
 }
 
 // RouteConfig defines a dynamic routing destination.
 // The LLM can route messages to these topics based on its analysis.
 type RouteConfig struct {
-	Topic       string `yaml:"topic"`
-	Description string `yaml:"description"`
+	Topic       string `yaml:"topic" jsonschema:"Destination topic name"`
+	Description string `yaml:"description" jsonschema:"What this route is for (included in the LLM prompt)"`
 }
 
 // HasRoutes returns true if dynamic routes are configured.
@@ -95,19 +139,115 @@ func (t *TopicsConfig) IsValidRoute(topic string) bool {
 	return false
 }
 
+// Tool choice policies accepted by CompletionConfig.ToolChoice.
+const (
+	ToolChoiceAuto     = "auto"
+	ToolChoiceNone     = "none"
+	ToolChoiceRequired = "required"
+)
+
+// CompletionConfig defines LLM completion parameters.
+// Pointer fields distinguish "unset" from an explicit zero (e.g. temperature: 0).
+type CompletionConfig struct {
+	Temperature *float64 `yaml:"temperature,omitempty" jsonschema:"Sampling temperature"`                     // Sampling temperature (0-2)
+	MaxTokens   int      `yaml:"max_tokens,omitempty" jsonschema:"Maximum tokens to generate per completion"` // Max tokens to generate
+	TopP        *float64 `yaml:"top_p,omitempty" jsonschema:"Nucleus sampling probability mass"`              // Nucleus sampling (0-1]
+	Stop        []string `yaml:"stop,omitempty" jsonschema:"Sequences that stop generation"`                  // Stop sequences
+	Seed        *int     `yaml:"seed,omitempty" jsonschema:"Seed for reproducible sampling"`                  // Seed for reproducible sampling
+	ToolChoice  string   `yaml:"tool_choice,omitempty" jsonschema:"Tool policy when MCP tools are available"` // "auto", "none", "required"
+}
+
+// GetCompletion returns the completion parameters with defaults applied.
+func (a *AgentConfig) GetCompletion() CompletionConfig {
+	completion := a.Completion
+
+	// Apply defaults
+	if completion.Temperature == nil {
+		temperature := 0.7
+		completion.Temperature = &temperature
+	}
+	if completion.MaxTokens == 0 {
+		completion.MaxTokens = 2048
+	}
+	if completion.ToolChoice == "" {
+		completion.ToolChoice = ToolChoiceAuto
+	}
+
+	return completion
+}
+
+// StreamingConfig publishes responses as ordered chunks while the LLM generates them.
+type StreamingConfig struct {
+	Enabled   bool `yaml:"enabled" jsonschema:"Publish response chunks to <topic>.stream for each publish topic"`
+	ChunkSize int  `yaml:"chunk_size,omitempty" jsonschema:"Characters per chunk when streaming is simulated"` // Default 64
+}
+
+// GetChunkSize returns the simulated chunk size, defaulting to 64 characters.
+func (s *StreamingConfig) GetChunkSize() int {
+	if s.ChunkSize <= 0 {
+		return 64
+	}
+	return s.ChunkSize
+}
+
+// Memory backends accepted by MemoryConfig.Backend.
+const (
+	MemoryBackendAthyr = "athyr" // Server-side sessions
+	MemoryBackendLocal = "local" // History kept inside the agent
+)
+
 // MemoryConfig defines optional memory/session settings.
 type MemoryConfig struct {
-	Enabled       bool                 `yaml:"enabled"`
-	SessionPrefix string               `yaml:"session_prefix,omitempty"`
-	TTL           string               `yaml:"ttl,omitempty"` // Duration string like "1h", "24h"
-	Profile       SessionProfileConfig `yaml:"profile,omitempty"`
+	Enabled       bool                 `yaml:"enabled" jsonschema:"Enable session memory"`
+	Backend       string               `yaml:"backend,omitempty" jsonschema:"Where conversation history is kept: athyr (server sessions) or local (inside the agent)"` // "athyr", "local"
+	SessionPrefix string               `yaml:"session_prefix,omitempty" jsonschema:"Prefix for session IDs"`
+	TTL           string               `yaml:"ttl,omitempty" jsonschema:"Session time-to-live as a Go duration (e.g. 1h)"`                                   // Idle time before a session expires, like "1h", "24h"
+	MaxSessions   int                  `yaml:"max_sessions,omitempty" jsonschema:"Maximum tracked sessions; the least recently used is evicted beyond this"` // Default 1000
+	Store         string               `yaml:"store,omitempty" jsonschema:"File that persists session mappings across restarts (default: user cache directory)"`
+	ControlTopic  string               `yaml:"control_topic,omitempty" jsonschema:"Athyr topic that accepts session control messages (default none: session control is off)"`
+	Profile       SessionProfileConfig `yaml:"profile,omitempty" jsonschema:"Memory behavior settings"`
+}
+
+// GetBackend returns the memory backend, defaulting to Athyr server sessions.
+func (m *MemoryConfig) GetBackend() string {
+	if m.Backend == "" {
+		return MemoryBackendAthyr
+	}
+	return m.Backend
+}
+
+// GetTTL parses the session TTL. Zero means sessions never expire.
+func (m *MemoryConfig) GetTTL() (time.Duration, error) {
+	return parseDuration("memory.ttl", m.TTL, 0)
+}
+
+// GetMaxSessions returns the session cap, defaulting to 1000.
+func (m *MemoryConfig) GetMaxSessions() int {
+	if m.MaxSessions <= 0 {
+		return 1000
+	}
+	return m.MaxSessions
+}
+
+// GetStorePath returns the file that persists the agent's session mappings.
+// It defaults to athyr-agent/sessions/<agent>.json in the user cache directory.
+func (m *MemoryConfig) GetStorePath(agentName string) (string, error) {
+	if m.Store != "" {
+		return m.Store, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no session store location: %w", err)
+	}
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(agentName)
+	return filepath.Join(dir, "athyr-agent", "sessions", name+".json"), nil
 }
 
 // SessionProfileConfig defines session memory behavior.
 type SessionProfileConfig struct {
-	Type                   string `yaml:"type,omitempty"`                    // "rolling_window"
-	MaxTokens              int    `yaml:"max_tokens,omitempty"`              // Max tokens in memory
-	SummarizationThreshold int    `yaml:"summarization_threshold,omitempty"` // When to trigger summarization
+	Type                   string `yaml:"type,omitempty" jsonschema:"Memory management strategy"`                                                   // "rolling_window"
+	MaxTokens              int    `yaml:"max_tokens,omitempty" jsonschema:"Maximum tokens kept in memory"`                                          // Max tokens in memory
+	SummarizationThreshold int    `yaml:"summarization_threshold,omitempty" jsonschema:"Token count that triggers summarization of older messages"` // When to trigger summarization
 }
 
 // GetProfile returns the session profile with defaults applied.
@@ -130,7 +270,7 @@ func (m *MemoryConfig) GetProfile() SessionProfileConfig {
 
 // MCPConfig defines MCP server connections.
 type MCPConfig struct {
-	Servers []MCPServerConfig `yaml:"servers,omitempty"`
+	Servers []MCPServerConfig `yaml:"servers,omitempty" jsonschema:"MCP servers to connect to"`
 }
 
 // MCPServerConfig defines an MCP server to connect to.
@@ -138,18 +278,234 @@ type MCPConfig struct {
 //   - Command: spawns a local subprocess (stdio transport)
 //   - URL: connects to a remote server (Streamable HTTP transport)
 type MCPServerConfig struct {
-	Name    string            `yaml:"name"`
-	Command []string          `yaml:"command,omitempty"`
-	URL     string            `yaml:"url,omitempty"`
-	Env     map[string]string `yaml:"env,omitempty"`
+	Name         string               `yaml:"name" jsonschema:"Identifier for this server"`
+	Command      []string             `yaml:"command,omitempty" jsonschema:"Subprocess command and args (stdio transport)"`
+	URL          string               `yaml:"url,omitempty" jsonschema:"Remote server endpoint (Streamable HTTP transport)"`
+	Env          map[string]string    `yaml:"env,omitempty" jsonschema:"Environment variables for subprocess commands"`
+	InheritEnv   string               `yaml:"inherit_env,omitempty" jsonschema:"Which of the agent's environment variables the subprocess inherits: all, none or allowlist"` // Default all
+	EnvAllowlist []string             `yaml:"env_allowlist,omitempty" jsonschema:"Glob patterns of environment variables inherited with inherit_env: allowlist"`
+	EnvFile      string               `yaml:"env_file,omitempty" jsonschema:"File of KEY=VALUE lines added to the subprocess environment"` // Read when the server starts; env takes precedence
+	Cwd          string               `yaml:"cwd,omitempty" jsonschema:"Working directory of the subprocess (default the agent's)"`
+	StartTimeout string               `yaml:"start_timeout,omitempty" jsonschema:"Time allowed to start the server and discover its tools as a Go duration (default no limit)"`
+	Timeout      string               `yaml:"timeout,omitempty" jsonschema:"Time allowed per call to this server's tools as a Go duration"` // Overrides processing.tool_timeout
+	ToolTimeouts map[string]string    `yaml:"tool_timeouts,omitempty" jsonschema:"Time allowed per call to specific tools, by tool name"`   // Overrides timeout
+	Include      []string             `yaml:"include,omitempty" jsonschema:"Glob patterns of tools to expose (default all)"`
+	Exclude      []string             `yaml:"exclude,omitempty" jsonschema:"Glob patterns of tools to hide, applied after include"`
+	Prefix       string               `yaml:"prefix,omitempty" jsonschema:"Namespace for this server's tools, exposed as <prefix>__<tool>"`
+	Resources    []string             `yaml:"resources,omitempty" jsonschema:"Patterns of resource URIs the LLM may list and read (default none); * matches any characters"`
+	HealthCheck  MCPHealthCheckConfig `yaml:"health_check,omitempty" jsonschema:"How often the server is pinged to check that it still responds"`
+	Restart      MCPRestartConfig     `yaml:"restart,omitempty" jsonschema:"How the server is restarted when it exits or fails a health check"`
+}
+
+// Environment inheritance modes accepted by MCPServerConfig.InheritEnv.
+const (
+	InheritEnvAll       = "all"       // The agent's whole environment
+	InheritEnvNone      = "none"      // Only env_file and env
+	InheritEnvAllowlist = "allowlist" // The variables matching env_allowlist
+)
+
+// GetInheritEnv returns the environment inheritance mode, defaulting to all.
+func (s *MCPServerConfig) GetInheritEnv() string {
+	if s.InheritEnv == "" {
+		return InheritEnvAll
+	}
+	return s.InheritEnv
+}
+
+// InheritsEnv reports whether the subprocess inherits the agent's environment
+// variable named key.
+func (s *MCPServerConfig) InheritsEnv(key string) bool {
+	switch s.GetInheritEnv() {
+	case InheritEnvAll:
+		return true
+	case InheritEnvAllowlist:
+		return slices.ContainsFunc(s.EnvAllowlist, func(p string) bool { return matchTool(p, key) })
+	default:
+		return false
+	}
+}
+
+// GetStartTimeout returns the time allowed to start the server, or 0 for no limit.
+func (s *MCPServerConfig) GetStartTimeout() time.Duration {
+	d, _ := parseDuration("", s.StartTimeout, 0)
+	return d
+}
+
+// MCPHealthCheckConfig defines how an MCP server's health is checked.
+type MCPHealthCheckConfig struct {
+	Interval string `yaml:"interval,omitempty" jsonschema:"Time between pings as a Go duration"`           // Default 30s
+	Timeout  string `yaml:"timeout,omitempty" jsonschema:"Time to wait for a ping reply as a Go duration"` // Default 5s
+}
+
+// GetInterval returns the time between health check pings, defaulting to 30 seconds.
+func (h *MCPHealthCheckConfig) GetInterval() time.Duration {
+	d, _ := parseDuration("", h.Interval, 30*time.Second)
+	return d
+}
+
+// GetTimeout returns how long to wait for a ping reply, defaulting to 5 seconds.
+func (h *MCPHealthCheckConfig) GetTimeout() time.Duration {
+	d, _ := parseDuration("", h.Timeout, 5*time.Second)
+	return d
+}
+
+// MCPRestartConfig defines how a failed MCP server is restarted (stdio) or
+// reconnected to (HTTP).
+type MCPRestartConfig struct {
+	MaxAttempts int    `yaml:"max_attempts,omitempty" jsonschema:"Restart attempts in a row before giving up on the server (0 = unlimited)"`
+	MaxRestarts int    `yaml:"max_restarts,omitempty" jsonschema:"Successful restarts over the agent's lifetime before giving up on the server (0 = unlimited)"`
+	BaseBackoff string `yaml:"base_backoff,omitempty" jsonschema:"Delay before the first restart attempt as a Go duration"` // Doubles after each failed attempt; default 1s
+	MaxBackoff  string `yaml:"max_backoff,omitempty" jsonschema:"Maximum delay between restart attempts as a Go duration"`  // Default 30s
+}
+
+// GetBaseBackoff returns the delay before the first restart attempt, defaulting to 1 second.
+func (r *MCPRestartConfig) GetBaseBackoff() time.Duration {
+	d, _ := parseDuration("", r.BaseBackoff, time.Second)
+	return d
+}
+
+// GetMaxBackoff returns the maximum delay between restart attempts, defaulting to 30 seconds.
+func (r *MCPRestartConfig) GetMaxBackoff() time.Duration {
+	d, _ := parseDuration("", r.MaxBackoff, 30*time.Second)
+	return d
+}
+
+// ToolPrefixSeparator joins a server's prefix and a tool name.
+const ToolPrefixSeparator = "__"
+
+// toolPrefixPattern matches prefixes that keep tool names valid for LLM providers.
+var toolPrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ExposesTool reports whether the server's tool passes its include and exclude lists.
+func (s *MCPServerConfig) ExposesTool(tool string) bool {
+	if len(s.Include) > 0 && !slices.ContainsFunc(s.Include, func(p string) bool { return matchTool(p, tool) }) {
+		return false
+	}
+	return !slices.ContainsFunc(s.Exclude, func(p string) bool { return matchTool(p, tool) })
+}
+
+// ExposedToolName returns the name the server's tool is offered to the LLM under.
+func (s *MCPServerConfig) ExposedToolName(tool string) string {
+	if s.Prefix == "" {
+		return tool
+	}
+	return s.Prefix + ToolPrefixSeparator + tool
+}
+
+// validToolPattern reports whether pattern is a valid glob pattern.
+func validToolPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+// matchTool reports whether a tool name matches a glob pattern. Invalid
+// patterns are rejected by Validate and match nothing.
+func matchTool(pattern, tool string) bool {
+	ok, _ := path.Match(pattern, tool)
+	return ok
+}
+
+// ExposesResource reports whether the LLM may read the server's resource,
+// because its URI matches one of the resources patterns.
+func (s *MCPServerConfig) ExposesResource(uri string) bool {
+	return slices.ContainsFunc(s.Resources, func(p string) bool { return matchURI(p, uri) })
+}
+
+// matchURI reports whether a resource URI matches a pattern, in which * matches
+// any run of characters, including /, and ? matches any single character.
+func matchURI(pattern, uri string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\*`, `.*`)
+	re = strings.ReplaceAll(re, `\?`, `.`)
+	ok, _ := regexp.MatchString("^"+re+"$", uri)
+	return ok
+}
+
+// GetToolTimeout returns the time allowed for a call to tool: its entry in
+// tool_timeouts, else the server's timeout, else def.
+func (s *MCPServerConfig) GetToolTimeout(tool string, def time.Duration) time.Duration {
+	if v, ok := s.ToolTimeouts[tool]; ok {
+		d, _ := parseDuration("", v, def)
+		return d
+	}
+	d, _ := parseDuration("", s.Timeout, def)
+	return d
+}
+
+// ToolsConfig controls how the LLM's tool calls are executed.
+type ToolsConfig struct {
+	MaxParallel        int             `yaml:"max_parallel,omitempty" jsonschema:"Tool calls from one LLM response executed at once (1 = one at a time)"` // Default 4
+	MaxIterations      int             `yaml:"max_iterations,omitempty" jsonschema:"Rounds of tool calls per message before a final answer is requested"` // Default 10
+	RequireApproval    []string        `yaml:"require_approval,omitempty" jsonschema:"Glob patterns of tools that only run once a human approves the call"`
+	Approval           ApprovalConfig  `yaml:"approval,omitempty" jsonschema:"Where approval requests go and how long to wait for a decision"`
+	MaxResultBytes     int             `yaml:"max_result_bytes,omitempty" jsonschema:"Largest tool result sent to the LLM, in bytes (0 = no limit)"`
+	ToolMaxResultBytes map[string]int  `yaml:"tool_max_result_bytes,omitempty" jsonschema:"Result size limits for specific tools, by exposed name"` // Overrides max_result_bytes
+	Summarize          SummarizeConfig `yaml:"summarize,omitempty" jsonschema:"Summarize results over the size limit instead of truncating them"`
+}
+
+// ApprovalConfig defines how tool calls listed in require_approval are approved.
+type ApprovalConfig struct {
+	Topic   string `yaml:"topic,omitempty" jsonschema:"Topic approval requests are sent to as request/reply messages"`
+	Timeout string `yaml:"timeout,omitempty" jsonschema:"Time to wait for a decision as a Go duration; the call is denied after it"` // Default 5m
+}
+
+// RequiresApproval reports whether calls to the named tool need approval.
+// Names are matched as exposed to the LLM, including any prefix.
+func (t *ToolsConfig) RequiresApproval(tool string) bool {
+	return slices.ContainsFunc(t.RequireApproval, func(p string) bool { return matchTool(p, tool) })
+}
+
+// GetApprovalTimeout returns how long to wait for an approval decision, defaulting to 5 minutes.
+func (t *ToolsConfig) GetApprovalTimeout() time.Duration {
+	d, _ := parseDuration("", t.Approval.Timeout, 5*time.Minute)
+	return d
+}
+
+// GetMaxParallel returns how many tool calls may run at once, defaulting to 4.
+func (t *ToolsConfig) GetMaxParallel() int {
+	if t.MaxParallel <= 0 {
+		return 4
+	}
+	return t.MaxParallel
+}
+
+// GetMaxIterations returns the rounds of tool calls allowed per message, defaulting to 10.
+func (t *ToolsConfig) GetMaxIterations() int {
+	if t.MaxIterations <= 0 {
+		return 10
+	}
+	return t.MaxIterations
+}
+
+// GetMaxResultBytes returns the largest result of the named tool sent to the
+// LLM: its tool_max_result_bytes entry, else max_result_bytes. 0 means no limit.
+func (t *ToolsConfig) GetMaxResultBytes(tool string) int {
+	if n, ok := t.ToolMaxResultBytes[tool]; ok {
+		return n
+	}
+	return t.MaxResultBytes
+}
+
+// SummarizeConfig defines how tool results over the size limit are summarized.
+type SummarizeConfig struct {
+	Model         string `yaml:"model,omitempty" jsonschema:"Model that summarizes large results; results are truncated when unset"`
+	MaxInputBytes int    `yaml:"max_input_bytes,omitempty" jsonschema:"Largest result passed to the summarizer, in bytes; longer ones are truncated first"` // Default 131072
+}
+
+// GetMaxInputBytes returns the largest result passed to the summarizer, defaulting to 128 KiB.
+func (s *SummarizeConfig) GetMaxInputBytes() int {
+	if s.MaxInputBytes <= 0 {
+		return 128 * 1024
+	}
+	return s.MaxInputBytes
 }
 
 // ConnectionConfig defines SDK connection options.
 type ConnectionConfig struct {
-	Timeout     string `yaml:"timeout,omitempty"`      // Request timeout (e.g., "60s", "2m")
-	MaxRetries  int    `yaml:"max_retries,omitempty"`  // Max reconnection retries (0 = infinite)
-	BaseBackoff string `yaml:"base_backoff,omitempty"` // Initial backoff (e.g., "1s")
-	MaxBackoff  string `yaml:"max_backoff,omitempty"`  // Max backoff (e.g., "30s")
+	Timeout     string `yaml:"timeout,omitempty" jsonschema:"Request timeout as a Go duration (e.g. 60s)"`        // Request timeout (e.g., "60s", "2m")
+	MaxRetries  int    `yaml:"max_retries,omitempty" jsonschema:"Max reconnection retries (0 = infinite)"`        // Max reconnection retries (0 = infinite)
+	BaseBackoff string `yaml:"base_backoff,omitempty" jsonschema:"Initial reconnection backoff as a Go duration"` // Initial backoff (e.g., "1s")
+	MaxBackoff  string `yaml:"max_backoff,omitempty" jsonschema:"Maximum reconnection backoff as a Go duration"`  // Max backoff (e.g., "30s")
 }
 
 // ConnectionOptions holds parsed connection settings.
@@ -169,16 +525,9 @@ func (c *ConnectionConfig) GetOptions() (ConnectionOptions, error) {
 		MaxBackoff:     30 * time.Second,
 	}
 
-	// Parse timeout
-	if c.Timeout != "" {
-		d, err := time.ParseDuration(c.Timeout)
-		if err != nil {
-			return opts, fmt.Errorf("invalid connection.timeout: %w", err)
-		}
-		if d < 0 {
-			return opts, fmt.Errorf("connection.timeout cannot be negative: %s", c.Timeout)
-		}
-		opts.RequestTimeout = d
+	var err error
+	if opts.RequestTimeout, err = parseDuration("connection.timeout", c.Timeout, opts.RequestTimeout); err != nil {
+		return opts, err
 	}
 
 	// Max retries (no parsing needed, just use directly)
@@ -186,111 +535,584 @@ func (c *ConnectionConfig) GetOptions() (ConnectionOptions, error) {
 		opts.MaxRetries = c.MaxRetries
 	}
 
-	// Parse base backoff
-	if c.BaseBackoff != "" {
-		d, err := time.ParseDuration(c.BaseBackoff)
-		if err != nil {
-			return opts, fmt.Errorf("invalid connection.base_backoff: %w", err)
-		}
-		if d < 0 {
-			return opts, fmt.Errorf("connection.base_backoff cannot be negative: %s", c.BaseBackoff)
-		}
-		opts.BaseBackoff = d
+	if opts.BaseBackoff, err = parseDuration("connection.base_backoff", c.BaseBackoff, opts.BaseBackoff); err != nil {
+		return opts, err
+	}
+	if opts.MaxBackoff, err = parseDuration("connection.max_backoff", c.MaxBackoff, opts.MaxBackoff); err != nil {
+		return opts, err
 	}
 
-	// Parse max backoff
-	if c.MaxBackoff != "" {
-		d, err := time.ParseDuration(c.MaxBackoff)
-		if err != nil {
-			return opts, fmt.Errorf("invalid connection.max_backoff: %w", err)
-		}
-		if d < 0 {
-			return opts, fmt.Errorf("connection.max_backoff cannot be negative: %s", c.MaxBackoff)
+	return opts, nil
+}
+
+// ProcessingConfig defines how incoming messages are scheduled and the time
+// budgets for handling each one. A zero duration disables that limit.
+type ProcessingConfig struct {
+	Timeout        string `yaml:"timeout,omitempty" jsonschema:"Total time allowed per message as a Go duration (e.g. 2m)"`        // Whole message, including tool calls
+	LLMTimeout     string `yaml:"llm_timeout,omitempty" jsonschema:"Time allowed per LLM call as a Go duration"`                   // Each completion request
+	ToolTimeout    string `yaml:"tool_timeout,omitempty" jsonschema:"Time allowed per tool call as a Go duration"`                 // Each MCP tool call
+	PublishTimeout string `yaml:"publish_timeout,omitempty" jsonschema:"Time allowed per publish or reply as a Go duration"`       // Each publish or reply
+	MaxConcurrency int    `yaml:"max_concurrency,omitempty" jsonschema:"Maximum number of messages processed at the same time"`    // Worker pool size
+	QueueSize      int    `yaml:"queue_size,omitempty" jsonschema:"Messages that can wait for a worker before consumption pauses"` // Pending messages before backpressure
+}
+
+// ProcessingOptions holds parsed processing settings.
+type ProcessingOptions struct {
+	Timeout        time.Duration
+	LLMTimeout     time.Duration
+	ToolTimeout    time.Duration
+	PublishTimeout time.Duration
+	MaxConcurrency int
+	QueueSize      int
+}
+
+// GetOptions parses the processing config and returns options with defaults.
+// Per-phase budgets default to 0, so they are bounded only by the message timeout.
+func (p *ProcessingConfig) GetOptions() (ProcessingOptions, error) {
+	opts := ProcessingOptions{
+		Timeout:        60 * time.Second,
+		PublishTimeout: 10 * time.Second,
+		MaxConcurrency: 4,
+		QueueSize:      100,
+	}
+
+	var err error
+	if opts.Timeout, err = parseDuration("processing.timeout", p.Timeout, opts.Timeout); err != nil {
+		return opts, err
+	}
+	if opts.LLMTimeout, err = parseDuration("processing.llm_timeout", p.LLMTimeout, opts.LLMTimeout); err != nil {
+		return opts, err
+	}
+	if opts.ToolTimeout, err = parseDuration("processing.tool_timeout", p.ToolTimeout, opts.ToolTimeout); err != nil {
+		return opts, err
+	}
+	if opts.PublishTimeout, err = parseDuration("processing.publish_timeout", p.PublishTimeout, opts.PublishTimeout); err != nil {
+		return opts, err
+	}
+
+	if p.MaxConcurrency < 0 {
+		return opts, fmt.Errorf("processing.max_concurrency cannot be negative: %d", p.MaxConcurrency)
+	}
+	if p.MaxConcurrency != 0 {
+		opts.MaxConcurrency = p.MaxConcurrency
+	}
+	if p.QueueSize < 0 {
+		return opts, fmt.Errorf("processing.queue_size cannot be negative: %d", p.QueueSize)
+	}
+	if p.QueueSize != 0 {
+		opts.QueueSize = p.QueueSize
+	}
+
+	return opts, nil
+}
+
+// Error classes accepted by ErrorsConfig.RetryOn.
+const (
+	ErrorClassTimeout     = "timeout"      // Deadline exceeded
+	ErrorClassUnavailable = "unavailable"  // Service unreachable or connection lost
+	ErrorClassRateLimited = "rate_limited" // Rate limit or quota exceeded
+	ErrorClassServer      = "server_error" // Internal error on the server or LLM provider
+)
+
+// ErrorClasses lists every error class that can be retried.
+var ErrorClasses = []string{ErrorClassTimeout, ErrorClassUnavailable, ErrorClassRateLimited, ErrorClassServer}
+
+// ErrorsConfig defines how failing LLM calls and publishes are retried, and
+// where messages go once processing has failed for good.
+type ErrorsConfig struct {
+	MaxRetries  int      `yaml:"max_retries,omitempty" jsonschema:"Retries per LLM call or publish after the first attempt (0 = no retries)"` // 0 disables retries
+	BaseBackoff string   `yaml:"base_backoff,omitempty" jsonschema:"Delay before the first retry as a Go duration"`                           // Doubles after each retry (e.g., "500ms")
+	MaxBackoff  string   `yaml:"max_backoff,omitempty" jsonschema:"Maximum delay between retries as a Go duration"`                           // Cap for the doubling (e.g., "10s")
+	RetryOn     []string `yaml:"retry_on,omitempty" jsonschema:"Error classes to retry (default: all)"`                                       // timeout, unavailable, rate_limited, server_error
+	DeadLetter  string   `yaml:"dead_letter,omitempty" jsonschema:"Topic or plugin destination that receives messages that failed processing"`
+
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker,omitempty" jsonschema:"Per-model circuit breaker settings"`
+}
+
+// CircuitBreakerConfig defines when a failing model is skipped in favor of the fallback models.
+type CircuitBreakerConfig struct {
+	FailureThreshold int    `yaml:"failure_threshold,omitempty" jsonschema:"Consecutive transient failures that open a model's circuit"`                // Default 5
+	Cooldown         string `yaml:"cooldown,omitempty" jsonschema:"Time an open circuit waits before letting a probe request through as a Go duration"` // Default 30s
+}
+
+// ErrorOptions holds parsed error handling settings.
+type ErrorOptions struct {
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	RetryOn     map[string]bool
+	DeadLetter  string
+
+	FailureThreshold int           // Consecutive failures that open a model's circuit
+	Cooldown         time.Duration // Time before an open circuit allows a probe
+}
+
+// GetOptions parses the errors config and returns options with defaults.
+func (e *ErrorsConfig) GetOptions() (ErrorOptions, error) {
+	opts := ErrorOptions{
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		RetryOn:     make(map[string]bool),
+		DeadLetter:  e.DeadLetter,
+
+		FailureThreshold: 5,
+		Cooldown:         30 * time.Second,
+	}
+
+	if e.MaxRetries < 0 {
+		return opts, fmt.Errorf("errors.max_retries cannot be negative: %d", e.MaxRetries)
+	}
+	opts.MaxRetries = e.MaxRetries
+
+	var err error
+	if opts.BaseBackoff, err = parseDuration("errors.base_backoff", e.BaseBackoff, opts.BaseBackoff); err != nil {
+		return opts, err
+	}
+	if opts.MaxBackoff, err = parseDuration("errors.max_backoff", e.MaxBackoff, opts.MaxBackoff); err != nil {
+		return opts, err
+	}
+
+	if e.CircuitBreaker.FailureThreshold < 0 {
+		return opts, fmt.Errorf("errors.circuit_breaker.failure_threshold cannot be negative: %d", e.CircuitBreaker.FailureThreshold)
+	}
+	if e.CircuitBreaker.FailureThreshold != 0 {
+		opts.FailureThreshold = e.CircuitBreaker.FailureThreshold
+	}
+	if opts.Cooldown, err = parseDuration("errors.circuit_breaker.cooldown", e.CircuitBreaker.Cooldown, opts.Cooldown); err != nil {
+		return opts, err
+	}
+
+	classes := e.RetryOn
+	if len(classes) == 0 {
+		classes = ErrorClasses
+	}
+	for _, class := range classes {
+		if !isErrorClass(class) {
+			return opts, fmt.Errorf("unknown error class in errors.retry_on: %q", class)
 		}
-		opts.MaxBackoff = d
+		opts.RetryOn[class] = true
 	}
 
 	return opts, nil
 }
 
-// LoadFile loads and parses a YAML config file.
+// isErrorClass reports whether class is one of ErrorClasses.
+func isErrorClass(class string) bool {
+	for _, c := range ErrorClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// ShutdownConfig defines graceful shutdown behavior.
+type ShutdownConfig struct {
+	GracePeriod string `yaml:"grace_period,omitempty" jsonschema:"Time to let in-flight messages finish on shutdown as a Go duration"` // e.g. "30s"; 0 cancels immediately
+}
+
+// GetGracePeriod parses the grace period, defaulting to 30 seconds.
+func (s *ShutdownConfig) GetGracePeriod() (time.Duration, error) {
+	return parseDuration("shutdown.grace_period", s.GracePeriod, 30*time.Second)
+}
+
+// parseDuration parses a non-negative duration string, returning def if value is empty.
+func parseDuration(name, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return def, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if d < 0 {
+		return def, fmt.Errorf("%s cannot be negative: %s", name, value)
+	}
+	return d, nil
+}
+
+// LoadFile loads and parses a YAML config file, rejecting unknown fields.
 func LoadFile(path string) (*Config, error) {
+	return LoadFileWithOptions(path, LoadOptions{})
+}
+
+// LoadFileWithOptions loads and parses a YAML config file.
+// Errors include the file name along with line and column.
+func LoadFileWithOptions(path string, opts LoadOptions) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return Load(data)
+	return load(data, path, opts)
 }
 
-// Load parses YAML data into a Config.
+// Load parses YAML data into a Config, rejecting unknown fields.
 func Load(data []byte) (*Config, error) {
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	return LoadWithOptions(data, LoadOptions{})
+}
+
+// LoadWithOptions parses YAML data into a Config.
+// References like ${VAR}, ${VAR:-default} and ${file:/path} in values are
+// expanded before decoding; unresolved references are reported by Validate.
+func LoadWithOptions(data []byte, opts LoadOptions) (*Config, error) {
+	return load(data, "", opts)
+}
+
+func load(data []byte, file string, opts LoadOptions) (*Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
-	return &cfg, nil
+
+	cfg := &Config{
+		file:      file,
+		positions: make(map[string]position),
+	}
+	collectPositions(&root, "", cfg.positions)
+
+	// Detect unknown fields (typos) before decoding silently drops them
+	unknown := checkKnownFields(&root, reflect.TypeOf(cfg), "")
+	if len(unknown) > 0 && !opts.Lenient {
+		errs := make([]error, len(unknown))
+		for i, issue := range unknown {
+			errs[i] = cfg.fieldError(issue.path, issue.message)
+		}
+		return nil, fmt.Errorf("unknown fields in config (use --lenient to ignore):\n%w", errors.Join(errs...))
+	}
+	cfg.warnings = unknown
+
+	interp := newInterpolator()
+	interp.walk(&root, reflect.TypeOf(cfg), "")
+
+	if err := root.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	cfg.unresolved = interp.unresolved
+	cfg.secrets = interp.secrets
+	return cfg, nil
+}
+
+// Warnings returns non-fatal problems found while loading, such as unknown
+// fields ignored in lenient mode.
+func (c *Config) Warnings() []error {
+	warnings := make([]error, len(c.warnings))
+	for i, issue := range c.warnings {
+		warnings[i] = c.fieldError(issue.path, issue.message)
+	}
+	return warnings
 }
 
 // Validate checks that the config contains all required fields.
 func (c *Config) Validate() error {
 	var errs []error
 
+	// fail records an error for the given YAML path
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, c.fieldError(path, fmt.Sprintf(format, args...)))
+	}
+
+	for _, issue := range c.unresolved {
+		fail(issue.path, "%s", issue.message)
+	}
+
 	if c.Agent.Name == "" {
-		errs = append(errs, errors.New("agent.name is required"))
+		fail("agent.name", "agent.name is required")
 	}
 
 	if c.Agent.Model == "" {
-		errs = append(errs, errors.New("agent.model is required"))
+		fail("agent.model", "agent.model is required")
+	}
+	seenModels := map[string]bool{c.Agent.Model: true}
+	for i, model := range c.Agent.FallbackModels {
+		path := fmt.Sprintf("agent.fallback_models[%d]", i)
+		if model == "" {
+			fail(path, "%s cannot be empty", path)
+			continue
+		}
+		if seenModels[model] {
+			fail(path, "%s: duplicate model %q", path, model)
+		}
+		seenModels[model] = true
 	}
 
 	if len(c.Agent.Topics.Subscribe) == 0 {
-		errs = append(errs, errors.New("agent.topics.subscribe must have at least one topic"))
+		fail("agent.topics.subscribe", "agent.topics.subscribe must have at least one topic")
 	}
 
 	if len(c.Agent.Topics.Publish) == 0 {
-		errs = append(errs, errors.New("agent.topics.publish must have at least one topic"))
+		fail("agent.topics.publish", "agent.topics.publish must have at least one topic")
 	}
 
 	// Validate route definitions
 	for i, route := range c.Agent.Topics.Routes {
+		path := fmt.Sprintf("agent.topics.routes[%d]", i)
 		if route.Topic == "" {
-			errs = append(errs, fmt.Errorf("agent.topics.routes[%d].topic is required", i))
+			fail(path+".topic", "%s.topic is required", path)
 		}
 		if route.Description == "" {
-			errs = append(errs, fmt.Errorf("agent.topics.routes[%d].description is required", i))
+			fail(path+".description", "%s.description is required", path)
+		}
+	}
+
+	// Validate completion parameters
+	completion := c.Agent.Completion
+	if t := completion.Temperature; t != nil && (*t < 0 || *t > 2) {
+		fail("agent.completion.temperature", "agent.completion.temperature must be between 0 and 2, got %v", *t)
+	}
+	if completion.MaxTokens < 0 {
+		fail("agent.completion.max_tokens", "agent.completion.max_tokens cannot be negative: %d", completion.MaxTokens)
+	}
+	if p := completion.TopP; p != nil && (*p <= 0 || *p > 1) {
+		fail("agent.completion.top_p", "agent.completion.top_p must be greater than 0 and at most 1, got %v", *p)
+	}
+	for i, stop := range completion.Stop {
+		if stop == "" {
+			path := fmt.Sprintf("agent.completion.stop[%d]", i)
+			fail(path, "%s cannot be empty", path)
+		}
+	}
+	switch completion.ToolChoice {
+	case "", ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+	default:
+		fail("agent.completion.tool_choice", "agent.completion.tool_choice must be one of auto, none, required, got %q", completion.ToolChoice)
+	}
+
+	// Validate durations
+	durations := []struct{ name, value string }{
+		{"memory.ttl", c.Agent.Memory.TTL},
+		{"tools.approval.timeout", c.Agent.Tools.Approval.Timeout},
+		{"connection.timeout", c.Agent.Connection.Timeout},
+		{"connection.base_backoff", c.Agent.Connection.BaseBackoff},
+		{"connection.max_backoff", c.Agent.Connection.MaxBackoff},
+		{"processing.timeout", c.Agent.Processing.Timeout},
+		{"processing.llm_timeout", c.Agent.Processing.LLMTimeout},
+		{"processing.tool_timeout", c.Agent.Processing.ToolTimeout},
+		{"processing.publish_timeout", c.Agent.Processing.PublishTimeout},
+		{"errors.base_backoff", c.Agent.Errors.BaseBackoff},
+		{"errors.max_backoff", c.Agent.Errors.MaxBackoff},
+		{"errors.circuit_breaker.cooldown", c.Agent.Errors.CircuitBreaker.Cooldown},
+		{"shutdown.grace_period", c.Agent.Shutdown.GracePeriod},
+	}
+	for _, d := range durations {
+		if _, err := parseDuration("agent."+d.name, d.value, 0); err != nil {
+			fail("agent."+d.name, "%v", err)
+		}
+	}
+
+	if c.Agent.Streaming.ChunkSize < 0 {
+		fail("agent.streaming.chunk_size", "agent.streaming.chunk_size cannot be negative: %d", c.Agent.Streaming.ChunkSize)
+	}
+
+	switch c.Agent.Memory.Backend {
+	case "", MemoryBackendAthyr, MemoryBackendLocal:
+	default:
+		fail("agent.memory.backend", "agent.memory.backend must be one of athyr, local, got %q", c.Agent.Memory.Backend)
+	}
+	if c.Agent.Memory.MaxSessions < 0 {
+		fail("agent.memory.max_sessions", "agent.memory.max_sessions cannot be negative: %d", c.Agent.Memory.MaxSessions)
+	}
+	if c.Agent.Memory.Backend != "" && !c.Agent.Memory.Enabled {
+		fail("agent.memory.backend", "agent.memory.backend requires agent.memory.enabled")
+	}
+	// Only the local backend summarizes; an unset threshold keeps its old default
+	if mem := c.Agent.Memory; mem.Enabled && mem.GetBackend() == MemoryBackendLocal && mem.Profile.SummarizationThreshold != 0 {
+		if profile := mem.GetProfile(); profile.SummarizationThreshold >= profile.MaxTokens {
+			fail("agent.memory.profile.summarization_threshold", "agent.memory.profile.summarization_threshold must be lower than max_tokens (%d), got %d", profile.MaxTokens, profile.SummarizationThreshold)
+		}
+	}
+	if topic := c.Agent.Memory.ControlTopic; topic != "" {
+		switch {
+		case !c.Agent.Memory.Enabled:
+			fail("agent.memory.control_topic", "agent.memory.control_topic requires agent.memory.enabled")
+		case slices.Contains(c.Agent.Topics.Subscribe, topic) || slices.Contains(c.Agent.Topics.Publish, topic):
+			fail("agent.memory.control_topic", "agent.memory.control_topic must be a dedicated topic, not a subscribe or publish topic: %q", topic)
+		case slices.ContainsFunc(c.Agent.Plugins, func(p PluginConfig) bool { return p.Name == topic }):
+			fail("agent.memory.control_topic", "agent.memory.control_topic must be an Athyr topic, not a plugin: %q", topic)
+		}
+	}
+	if c.Agent.Tools.MaxParallel < 0 {
+		fail("agent.tools.max_parallel", "agent.tools.max_parallel cannot be negative: %d", c.Agent.Tools.MaxParallel)
+	}
+	if c.Agent.Tools.MaxIterations < 0 {
+		fail("agent.tools.max_iterations", "agent.tools.max_iterations cannot be negative: %d", c.Agent.Tools.MaxIterations)
+	}
+	if c.Agent.Tools.MaxResultBytes < 0 {
+		fail("agent.tools.max_result_bytes", "agent.tools.max_result_bytes cannot be negative: %d", c.Agent.Tools.MaxResultBytes)
+	}
+	for _, tool := range slices.Sorted(maps.Keys(c.Agent.Tools.ToolMaxResultBytes)) {
+		if n := c.Agent.Tools.ToolMaxResultBytes[tool]; n < 0 {
+			path := "agent.tools.tool_max_result_bytes." + tool
+			fail(path, "%s cannot be negative: %d", path, n)
+		}
+	}
+	if c.Agent.Tools.Summarize.MaxInputBytes < 0 {
+		fail("agent.tools.summarize.max_input_bytes", "agent.tools.summarize.max_input_bytes cannot be negative: %d", c.Agent.Tools.Summarize.MaxInputBytes)
+	}
+	for i, pattern := range c.Agent.Tools.RequireApproval {
+		if !validToolPattern(pattern) {
+			fail(fmt.Sprintf("agent.tools.require_approval[%d]", i), "agent.tools.require_approval[%d]: invalid glob pattern %q", i, pattern)
+		}
+	}
+	if c.Agent.Processing.MaxConcurrency < 0 {
+		fail("agent.processing.max_concurrency", "agent.processing.max_concurrency cannot be negative: %d", c.Agent.Processing.MaxConcurrency)
+	}
+	if c.Agent.Processing.QueueSize < 0 {
+		fail("agent.processing.queue_size", "agent.processing.queue_size cannot be negative: %d", c.Agent.Processing.QueueSize)
+	}
+
+	// Validate error handling
+	if c.Agent.Errors.MaxRetries < 0 {
+		fail("agent.errors.max_retries", "agent.errors.max_retries cannot be negative: %d", c.Agent.Errors.MaxRetries)
+	}
+	if c.Agent.Errors.CircuitBreaker.FailureThreshold < 0 {
+		fail("agent.errors.circuit_breaker.failure_threshold", "agent.errors.circuit_breaker.failure_threshold cannot be negative: %d", c.Agent.Errors.CircuitBreaker.FailureThreshold)
+	}
+	for i, class := range c.Agent.Errors.RetryOn {
+		if !isErrorClass(class) {
+			path := fmt.Sprintf("agent.errors.retry_on[%d]", i)
+			fail(path, "%s must be one of %s, got %q", path, strings.Join(ErrorClasses, ", "), class)
+		}
+	}
+	if dl := c.Agent.Errors.DeadLetter; dl != "" {
+		for _, topic := range c.Agent.Topics.Subscribe {
+			if topic == dl {
+				fail("agent.errors.dead_letter", "agent.errors.dead_letter cannot be a subscribed topic: %q", dl)
+			}
 		}
 	}
 
 	// Validate plugin definitions
 	pluginNames := make(map[string]bool)
 	for i, plugin := range c.Agent.Plugins {
+		path := fmt.Sprintf("agent.plugins[%d]", i)
 		if plugin.Name == "" {
-			errs = append(errs, fmt.Errorf("agent.plugins[%d].name is required", i))
+			fail(path+".name", "%s.name is required", path)
 		}
 		if plugin.File == "" {
-			errs = append(errs, fmt.Errorf("agent.plugins[%d].file is required", i))
+			fail(path+".file", "%s.file is required", path)
 		}
 		if plugin.Name != "" {
 			if pluginNames[plugin.Name] {
-				errs = append(errs, fmt.Errorf("agent.plugins[%d]: duplicate plugin name %q", i, plugin.Name))
+				fail(path+".name", "%s: duplicate plugin name %q", path, plugin.Name)
 			}
 			pluginNames[plugin.Name] = true
 		}
 	}
 
+	if from := c.Agent.InstructionsFrom; from.IsSet() {
+		if c.Agent.Instructions != "" {
+			fail("agent.instructions_from", "agent.instructions_from cannot be combined with agent.instructions")
+		}
+		if from.Prompt == "" {
+			fail("agent.instructions_from.prompt", "agent.instructions_from.prompt is required")
+		}
+		if from.Server == "" {
+			fail("agent.instructions_from.server", "agent.instructions_from.server is required")
+		} else if !slices.ContainsFunc(c.Agent.MCP.Servers, func(s MCPServerConfig) bool { return s.Name == from.Server }) {
+			fail("agent.instructions_from.server", "agent.instructions_from.server: no MCP server named %q", from.Server)
+		}
+	}
+
 	// Validate MCP server definitions
+	serverNames := make(map[string]bool)
 	for i, srv := range c.Agent.MCP.Servers {
+		path := fmt.Sprintf("agent.mcp.servers[%d]", i)
 		if srv.Name == "" {
-			errs = append(errs, fmt.Errorf("agent.mcp.servers[%d].name is required", i))
+			fail(path+".name", "%s.name is required", path)
+		} else {
+			if serverNames[srv.Name] {
+				fail(path+".name", "%s: duplicate MCP server name %q", path, srv.Name)
+			}
+			serverNames[srv.Name] = true
 		}
 		hasCommand := len(srv.Command) > 0
 		hasURL := srv.URL != ""
 		if hasCommand && hasURL {
-			errs = append(errs, fmt.Errorf("agent.mcp.servers[%d] must specify either command or url, not both", i))
+			fail(path+".url", "%s must specify either command or url, not both", path)
 		}
 		if !hasCommand && !hasURL {
-			errs = append(errs, fmt.Errorf("agent.mcp.servers[%d] must specify either command or url", i))
+			fail(path, "%s must specify either command or url", path)
+		}
+		if _, err := parseDuration(path+".timeout", srv.Timeout, 0); err != nil {
+			fail(path+".timeout", "%v", err)
+		}
+		for _, tool := range slices.Sorted(maps.Keys(srv.ToolTimeouts)) {
+			name := path + ".tool_timeouts." + tool
+			if _, err := parseDuration(name, srv.ToolTimeouts[tool], 0); err != nil {
+				fail(name, "%v", err)
+			}
+		}
+		for j, pattern := range srv.Include {
+			if !validToolPattern(pattern) {
+				fail(fmt.Sprintf("%s.include[%d]", path, j), "%s.include[%d]: invalid glob pattern %q", path, j, pattern)
+			}
+		}
+		for j, pattern := range srv.Exclude {
+			if !validToolPattern(pattern) {
+				fail(fmt.Sprintf("%s.exclude[%d]", path, j), "%s.exclude[%d]: invalid glob pattern %q", path, j, pattern)
+			}
+		}
+		if srv.Prefix != "" && !toolPrefixPattern.MatchString(srv.Prefix) {
+			fail(path+".prefix", "%s.prefix may only contain letters, digits, _ and -, got %q", path, srv.Prefix)
+		}
+		for _, d := range []struct{ name, value string }{
+			{path + ".health_check.interval", srv.HealthCheck.Interval},
+			{path + ".health_check.timeout", srv.HealthCheck.Timeout},
+			{path + ".restart.base_backoff", srv.Restart.BaseBackoff},
+			{path + ".restart.max_backoff", srv.Restart.MaxBackoff},
+		} {
+			if _, err := parseDuration(d.name, d.value, 0); err != nil {
+				fail(d.name, "%v", err)
+			}
+		}
+		if srv.Restart.MaxAttempts < 0 {
+			fail(path+".restart.max_attempts", "%s.restart.max_attempts cannot be negative: %d", path, srv.Restart.MaxAttempts)
+		}
+		if srv.Restart.MaxRestarts < 0 {
+			fail(path+".restart.max_restarts", "%s.restart.max_restarts cannot be negative: %d", path, srv.Restart.MaxRestarts)
+		}
+
+		// Environment inheritance and the working directory only apply to subprocesses
+		if hasURL {
+			for _, field := range []struct {
+				name string
+				set  bool
+			}{
+				{"inherit_env", srv.InheritEnv != ""},
+				{"env_allowlist", len(srv.EnvAllowlist) > 0},
+				{"env_file", srv.EnvFile != ""},
+				{"cwd", srv.Cwd != ""},
+			} {
+				if field.set {
+					fail(path+"."+field.name, "%s.%s only applies to servers with a command", path, field.name)
+				}
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(srv.Env)) {
+			if key == "" || strings.ContainsAny(key, "=\x00") {
+				fail(path+".env", "%s.env: invalid variable name %q", path, key)
+			}
+		}
+		switch srv.InheritEnv {
+		case "", InheritEnvAll, InheritEnvNone:
+			if len(srv.EnvAllowlist) > 0 {
+				fail(path+".env_allowlist", "%s.env_allowlist requires inherit_env: allowlist", path)
+			}
+		case InheritEnvAllowlist:
+			if len(srv.EnvAllowlist) == 0 {
+				fail(path+".env_allowlist", "%s.env_allowlist is required with inherit_env: allowlist", path)
+			}
+		default:
+			fail(path+".inherit_env", "%s.inherit_env must be one of all, none, allowlist, got %q", path, srv.InheritEnv)
+		}
+		for j, pattern := range srv.EnvAllowlist {
+			if !validToolPattern(pattern) {
+				fail(fmt.Sprintf("%s.env_allowlist[%d]", path, j), "%s.env_allowlist[%d]: invalid glob pattern %q", path, j, pattern)
+			}
+		}
+		if _, err := parseDuration(path+".start_timeout", srv.StartTimeout, 0); err != nil {
+			fail(path+".start_timeout", "%v", err)
 		}
 	}
 
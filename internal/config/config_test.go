@@ -1,6 +1,7 @@
 package config
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -227,6 +228,30 @@ func TestValidate_MCPServerWithoutName(t *testing.T) {
 	}
 }
 
+func TestValidate_MCPServerDuplicateName(t *testing.T) {
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			MCP: MCPConfig{
+				Servers: []MCPServerConfig{
+					{Name: "github", Command: []string{"github-mcp-server"}, Prefix: "gh"},
+					{Name: "github", URL: "http://localhost:8080/mcp", Prefix: "remote"},
+				},
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `agent.mcp.servers[1]: duplicate MCP server name "github"`) {
+		t.Errorf("Validate() error = %v, want duplicate server name rejected", err)
+	}
+}
+
 func TestValidate_MCPServerWithoutCommandOrURL(t *testing.T) {
 	cfg := &Config{
 		Agent: AgentConfig{
@@ -835,3 +860,959 @@ func TestValidate_NoTopicsWithPlugins(t *testing.T) {
 		t.Fatalf("Validate() unexpected error = %v", err)
 	}
 }
+
+func TestLoad_WithCompletion(t *testing.T) {
+	yaml := `
+agent:
+  name: test-agent
+  model: gpt-4
+  topics:
+    subscribe: [input]
+    publish: [output]
+  completion:
+    temperature: 0
+    max_tokens: 8192
+    top_p: 0.9
+    stop: ["END", "###"]
+    seed: 42
+    tool_choice: required
+`
+	cfg, err := Load([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	completion := cfg.Agent.Completion
+	if completion.Temperature == nil || *completion.Temperature != 0 {
+		t.Errorf("Completion.Temperature = %v, want 0", completion.Temperature)
+	}
+	if completion.MaxTokens != 8192 {
+		t.Errorf("Completion.MaxTokens = %v, want 8192", completion.MaxTokens)
+	}
+	if completion.TopP == nil || *completion.TopP != 0.9 {
+		t.Errorf("Completion.TopP = %v, want 0.9", completion.TopP)
+	}
+	if len(completion.Stop) != 2 || completion.Stop[0] != "END" {
+		t.Errorf("Completion.Stop = %v, want [END ###]", completion.Stop)
+	}
+	if completion.Seed == nil || *completion.Seed != 42 {
+		t.Errorf("Completion.Seed = %v, want 42", completion.Seed)
+	}
+	if completion.ToolChoice != "required" {
+		t.Errorf("Completion.ToolChoice = %v, want required", completion.ToolChoice)
+	}
+}
+
+func TestAgentConfig_GetCompletion_WithDefaults(t *testing.T) {
+	cfg := AgentConfig{}
+
+	completion := cfg.GetCompletion()
+
+	if completion.Temperature == nil || *completion.Temperature != 0.7 {
+		t.Errorf("Completion.Temperature = %v, want 0.7", completion.Temperature)
+	}
+	if completion.MaxTokens != 2048 {
+		t.Errorf("Completion.MaxTokens = %v, want 2048", completion.MaxTokens)
+	}
+	if completion.TopP != nil {
+		t.Errorf("Completion.TopP = %v, want nil", *completion.TopP)
+	}
+	if completion.Seed != nil {
+		t.Errorf("Completion.Seed = %v, want nil", *completion.Seed)
+	}
+	if completion.ToolChoice != ToolChoiceAuto {
+		t.Errorf("Completion.ToolChoice = %v, want auto", completion.ToolChoice)
+	}
+}
+
+func TestAgentConfig_GetCompletion_ZeroTemperature(t *testing.T) {
+	temperature := 0.0
+	cfg := AgentConfig{
+		Completion: CompletionConfig{
+			Temperature: &temperature,
+			MaxTokens:   512,
+		},
+	}
+
+	completion := cfg.GetCompletion()
+
+	if *completion.Temperature != 0 {
+		t.Errorf("Completion.Temperature = %v, want 0 (explicit zero kept)", *completion.Temperature)
+	}
+	if completion.MaxTokens != 512 {
+		t.Errorf("Completion.MaxTokens = %v, want 512", completion.MaxTokens)
+	}
+}
+
+func TestValidate_InvalidCompletion(t *testing.T) {
+	temperature := 2.5
+	topP := 0.0
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Completion: CompletionConfig{
+				Temperature: &temperature,
+				MaxTokens:   -1,
+				TopP:        &topP,
+				Stop:        []string{""},
+				ToolChoice:  "sometimes",
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error for invalid completion parameters")
+	}
+	for _, want := range []string{
+		"agent.completion.temperature",
+		"agent.completion.max_tokens",
+		"agent.completion.top_p",
+		"agent.completion.stop[0]",
+		"agent.completion.tool_choice",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestProcessingConfig_GetOptions_Defaults(t *testing.T) {
+	cfg := ProcessingConfig{}
+
+	opts, err := cfg.GetOptions()
+	if err != nil {
+		t.Fatalf("GetOptions() error = %v", err)
+	}
+
+	if opts.Timeout != 60*time.Second {
+		t.Errorf("Timeout = %v, want 60s", opts.Timeout)
+	}
+	if opts.LLMTimeout != 0 {
+		t.Errorf("LLMTimeout = %v, want 0 (bounded by timeout)", opts.LLMTimeout)
+	}
+	if opts.ToolTimeout != 0 {
+		t.Errorf("ToolTimeout = %v, want 0 (bounded by timeout)", opts.ToolTimeout)
+	}
+	if opts.PublishTimeout != 10*time.Second {
+		t.Errorf("PublishTimeout = %v, want 10s", opts.PublishTimeout)
+	}
+}
+
+func TestLoad_WithProcessing(t *testing.T) {
+	yaml := `
+agent:
+  name: test
+  model: gpt-4
+  topics:
+    subscribe: [input]
+    publish: [output]
+  processing:
+    timeout: 5m
+    llm_timeout: 90s
+    tool_timeout: 30s
+    publish_timeout: 5s
+`
+	cfg, err := Load([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	opts, err := cfg.Agent.Processing.GetOptions()
+	if err != nil {
+		t.Fatalf("GetOptions() error = %v", err)
+	}
+	if opts.Timeout != 5*time.Minute {
+		t.Errorf("Timeout = %v, want 5m", opts.Timeout)
+	}
+	if opts.LLMTimeout != 90*time.Second {
+		t.Errorf("LLMTimeout = %v, want 90s", opts.LLMTimeout)
+	}
+	if opts.ToolTimeout != 30*time.Second {
+		t.Errorf("ToolTimeout = %v, want 30s", opts.ToolTimeout)
+	}
+	if opts.PublishTimeout != 5*time.Second {
+		t.Errorf("PublishTimeout = %v, want 5s", opts.PublishTimeout)
+	}
+}
+
+func TestProcessingConfig_GetOptions_ZeroDisablesTimeout(t *testing.T) {
+	cfg := ProcessingConfig{Timeout: "0"}
+
+	opts, err := cfg.GetOptions()
+	if err != nil {
+		t.Fatalf("GetOptions() error = %v", err)
+	}
+	if opts.Timeout != 0 {
+		t.Errorf("Timeout = %v, want 0", opts.Timeout)
+	}
+}
+
+func TestValidate_InvalidDurations(t *testing.T) {
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Connection: ConnectionConfig{
+				Timeout: "soon",
+			},
+			Processing: ProcessingConfig{
+				Timeout:     "-1m",
+				ToolTimeout: "30",
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error for invalid durations")
+	}
+	for _, want := range []string{
+		"agent.connection.timeout",
+		"agent.processing.timeout cannot be negative",
+		"agent.processing.tool_timeout",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestProcessingConfig_GetOptions_Concurrency(t *testing.T) {
+	opts, err := (&ProcessingConfig{}).GetOptions()
+	if err != nil {
+		t.Fatalf("GetOptions() error = %v", err)
+	}
+	if opts.MaxConcurrency != 4 {
+		t.Errorf("MaxConcurrency = %d, want 4", opts.MaxConcurrency)
+	}
+	if opts.QueueSize != 100 {
+		t.Errorf("QueueSize = %d, want 100", opts.QueueSize)
+	}
+
+	opts, err = (&ProcessingConfig{MaxConcurrency: 8, QueueSize: 20}).GetOptions()
+	if err != nil {
+		t.Fatalf("GetOptions() error = %v", err)
+	}
+	if opts.MaxConcurrency != 8 || opts.QueueSize != 20 {
+		t.Errorf("opts = %+v, want MaxConcurrency 8 and QueueSize 20", opts)
+	}
+
+	if _, err := (&ProcessingConfig{MaxConcurrency: -1}).GetOptions(); err == nil {
+		t.Error("GetOptions() expected error for negative max_concurrency")
+	}
+	if _, err := (&ProcessingConfig{QueueSize: -1}).GetOptions(); err == nil {
+		t.Error("GetOptions() expected error for negative queue_size")
+	}
+}
+
+func TestShutdownConfig_GetGracePeriod(t *testing.T) {
+	d, err := (&ShutdownConfig{}).GetGracePeriod()
+	if err != nil {
+		t.Fatalf("GetGracePeriod() error = %v", err)
+	}
+	if d != 30*time.Second {
+		t.Errorf("GracePeriod = %v, want 30s", d)
+	}
+
+	d, err = (&ShutdownConfig{GracePeriod: "2m"}).GetGracePeriod()
+	if err != nil {
+		t.Fatalf("GetGracePeriod() error = %v", err)
+	}
+	if d != 2*time.Minute {
+		t.Errorf("GracePeriod = %v, want 2m", d)
+	}
+
+	if _, err := (&ShutdownConfig{GracePeriod: "-1s"}).GetGracePeriod(); err == nil {
+		t.Error("GetGracePeriod() expected error for negative duration")
+	}
+}
+
+func TestErrorsConfig_GetOptions(t *testing.T) {
+	opts, err := (&ErrorsConfig{}).GetOptions()
+	if err != nil {
+		t.Fatalf("GetOptions() error = %v", err)
+	}
+	if opts.MaxRetries != 0 {
+		t.Errorf("MaxRetries = %d, want 0", opts.MaxRetries)
+	}
+	if opts.BaseBackoff != 500*time.Millisecond || opts.MaxBackoff != 10*time.Second {
+		t.Errorf("backoff = %v..%v, want 500ms..10s", opts.BaseBackoff, opts.MaxBackoff)
+	}
+	for _, class := range ErrorClasses {
+		if !opts.RetryOn[class] {
+			t.Errorf("RetryOn[%s] = false, want every class retried by default", class)
+		}
+	}
+
+	opts, err = (&ErrorsConfig{
+		MaxRetries:  3,
+		BaseBackoff: "1s",
+		RetryOn:     []string{ErrorClassRateLimited},
+		DeadLetter:  "failed",
+	}).GetOptions()
+	if err != nil {
+		t.Fatalf("GetOptions() error = %v", err)
+	}
+	if opts.MaxRetries != 3 || opts.BaseBackoff != time.Second || opts.DeadLetter != "failed" {
+		t.Errorf("opts = %+v, want 3 retries, 1s backoff and dead letter", opts)
+	}
+	if !opts.RetryOn[ErrorClassRateLimited] || opts.RetryOn[ErrorClassTimeout] {
+		t.Errorf("RetryOn = %v, want only rate_limited", opts.RetryOn)
+	}
+
+	if _, err := (&ErrorsConfig{RetryOn: []string{"everything"}}).GetOptions(); err == nil {
+		t.Error("GetOptions() expected error for unknown error class")
+	}
+	if _, err := (&ErrorsConfig{MaxRetries: -1}).GetOptions(); err == nil {
+		t.Error("GetOptions() expected error for negative max_retries")
+	}
+}
+
+func TestValidate_Errors(t *testing.T) {
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Errors: ErrorsConfig{
+				MaxRetries: -1,
+				MaxBackoff: "later",
+				RetryOn:    []string{"timeout", "flaky"},
+				DeadLetter: "input",
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error for invalid errors config")
+	}
+	for _, want := range []string{
+		"agent.errors.max_retries cannot be negative",
+		"agent.errors.max_backoff",
+		`agent.errors.retry_on[1] must be one of timeout, unavailable, rate_limited, server_error, got "flaky"`,
+		"agent.errors.dead_letter cannot be a subscribed topic",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestAgentConfig_GetModels(t *testing.T) {
+	a := AgentConfig{Model: "primary", FallbackModels: []string{"secondary", "tertiary"}}
+
+	got := a.GetModels()
+	if strings.Join(got, ",") != "primary,secondary,tertiary" {
+		t.Errorf("GetModels() = %v, want primary, secondary, tertiary", got)
+	}
+	if len(a.FallbackModels) != 2 {
+		t.Errorf("GetModels() modified FallbackModels: %v", a.FallbackModels)
+	}
+}
+
+func TestValidate_FallbackModels(t *testing.T) {
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:           "test",
+			Model:          "gpt-4",
+			FallbackModels: []string{"claude", "", "gpt-4"},
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Errors: ErrorsConfig{
+				CircuitBreaker: CircuitBreakerConfig{FailureThreshold: -1, Cooldown: "never"},
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error")
+	}
+	for _, want := range []string{
+		"agent.fallback_models[1] cannot be empty",
+		`agent.fallback_models[2]: duplicate model "gpt-4"`,
+		"agent.errors.circuit_breaker.failure_threshold cannot be negative",
+		"agent.errors.circuit_breaker.cooldown",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestMemoryConfig_Sessions(t *testing.T) {
+	m := MemoryConfig{}
+	if ttl, err := m.GetTTL(); err != nil || ttl != 0 {
+		t.Errorf("GetTTL() = %v, %v, want 0 (never expire)", ttl, err)
+	}
+	if got := m.GetMaxSessions(); got != 1000 {
+		t.Errorf("GetMaxSessions() = %d, want 1000", got)
+	}
+	path, err := m.GetStorePath("team/agent")
+	if err != nil {
+		t.Fatalf("GetStorePath() error = %v", err)
+	}
+	if filepath.Base(path) != "team_agent.json" || filepath.Base(filepath.Dir(path)) != "sessions" {
+		t.Errorf("GetStorePath() = %q, want .../sessions/team_agent.json", path)
+	}
+
+	m = MemoryConfig{TTL: "24h", MaxSessions: 50, Store: "/var/lib/agent/sessions.json"}
+	if ttl, err := m.GetTTL(); err != nil || ttl != 24*time.Hour {
+		t.Errorf("GetTTL() = %v, %v, want 24h", ttl, err)
+	}
+	if got := m.GetMaxSessions(); got != 50 {
+		t.Errorf("GetMaxSessions() = %d, want 50", got)
+	}
+	if path, _ := m.GetStorePath("agent"); path != "/var/lib/agent/sessions.json" {
+		t.Errorf("GetStorePath() = %q, want configured store", path)
+	}
+
+	if _, err := (&MemoryConfig{TTL: "forever"}).GetTTL(); err == nil {
+		t.Error("GetTTL() expected error for invalid duration")
+	}
+}
+
+func TestMemoryConfig_Backend(t *testing.T) {
+	if got := (&MemoryConfig{}).GetBackend(); got != MemoryBackendAthyr {
+		t.Errorf("GetBackend() = %q, want %q", got, MemoryBackendAthyr)
+	}
+
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Memory: MemoryConfig{Enabled: true, Backend: MemoryBackendLocal},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want local backend accepted", err)
+	}
+
+	cfg.Agent.Memory.Backend = "redis"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "agent.memory.backend must be one of athyr, local") {
+		t.Errorf("Validate() error = %v, want unknown backend rejected", err)
+	}
+
+	// Without enabled, nothing would be stored
+	cfg.Agent.Memory = MemoryConfig{Backend: MemoryBackendLocal}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "agent.memory.backend requires agent.memory.enabled") {
+		t.Errorf("Validate() error = %v, want backend rejected without enabled", err)
+	}
+
+	// Lowering max_tokens alone keeps working, as it did before thresholds were checked
+	cfg.Agent.Memory = MemoryConfig{Enabled: true, Backend: MemoryBackendLocal, Profile: SessionProfileConfig{MaxTokens: 2000}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want max_tokens without a threshold accepted", err)
+	}
+	cfg.Agent.Memory.Profile.SummarizationThreshold = 2500
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "summarization_threshold must be lower than max_tokens (2000), got 2500") {
+		t.Errorf("Validate() error = %v, want the threshold rejected above max_tokens", err)
+	}
+	cfg.Agent.Memory.Profile.SummarizationThreshold = 1500
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	// The athyr backend doesn't summarize locally, so the threshold isn't checked
+	cfg.Agent.Memory = MemoryConfig{Enabled: true, Profile: SessionProfileConfig{MaxTokens: 2000, SummarizationThreshold: 2500}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want the athyr backend's threshold left unchecked", err)
+	}
+}
+
+func TestMemoryConfig_ControlTopic(t *testing.T) {
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Memory: MemoryConfig{Enabled: true, ControlTopic: "sessions"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want dedicated control topic accepted", err)
+	}
+
+	cfg.Agent.Memory.ControlTopic = "input"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "agent.memory.control_topic must be a dedicated topic") {
+		t.Errorf("Validate() error = %v, want subscribe topic rejected", err)
+	}
+
+	cfg.Agent.Memory = MemoryConfig{ControlTopic: "sessions"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "agent.memory.control_topic requires agent.memory.enabled") {
+		t.Errorf("Validate() error = %v, want control topic rejected without memory", err)
+	}
+}
+
+func TestStreamingConfig_ChunkSize(t *testing.T) {
+	if got := (&StreamingConfig{}).GetChunkSize(); got != 64 {
+		t.Errorf("GetChunkSize() = %d, want 64", got)
+	}
+	if got := (&StreamingConfig{ChunkSize: 16}).GetChunkSize(); got != 16 {
+		t.Errorf("GetChunkSize() = %d, want 16", got)
+	}
+
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Streaming: StreamingConfig{Enabled: true, ChunkSize: -1},
+		},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "agent.streaming.chunk_size") {
+		t.Errorf("Validate() error = %v, want negative chunk_size rejected", err)
+	}
+}
+
+func TestToolsConfig_MaxParallel(t *testing.T) {
+	if got := (&ToolsConfig{}).GetMaxParallel(); got != 4 {
+		t.Errorf("GetMaxParallel() = %d, want 4", got)
+	}
+	if got := (&ToolsConfig{MaxParallel: 1}).GetMaxParallel(); got != 1 {
+		t.Errorf("GetMaxParallel() = %d, want 1", got)
+	}
+
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Tools: ToolsConfig{MaxParallel: -1},
+		},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "agent.tools.max_parallel") {
+		t.Errorf("Validate() error = %v, want negative max_parallel rejected", err)
+	}
+}
+
+func TestMCPServerConfig_GetToolTimeout(t *testing.T) {
+	srv := MCPServerConfig{
+		Timeout:      "30s",
+		ToolTimeouts: map[string]string{"slow_tool": "2m"},
+	}
+	if got := srv.GetToolTimeout("slow_tool", time.Second); got != 2*time.Minute {
+		t.Errorf("GetToolTimeout(slow_tool) = %v, want 2m", got)
+	}
+	if got := srv.GetToolTimeout("other", time.Second); got != 30*time.Second {
+		t.Errorf("GetToolTimeout(other) = %v, want 30s", got)
+	}
+	if got := (&MCPServerConfig{}).GetToolTimeout("other", time.Second); got != time.Second {
+		t.Errorf("GetToolTimeout() = %v, want the default", got)
+	}
+}
+
+func TestValidate_ToolLimits(t *testing.T) {
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Tools: ToolsConfig{MaxIterations: -1},
+			MCP: MCPConfig{Servers: []MCPServerConfig{{
+				Name:         "search",
+				URL:          "http://localhost:8080",
+				Timeout:      "soon",
+				ToolTimeouts: map[string]string{"lookup": "-1s"},
+			}}},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error for invalid tool limits")
+	}
+	for _, want := range []string{
+		"agent.tools.max_iterations",
+		"agent.mcp.servers[0].timeout",
+		"agent.mcp.servers[0].tool_timeouts.lookup",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestMCPServerConfig_ExposedTools(t *testing.T) {
+	srv := MCPServerConfig{
+		Include: []string{"search*", "get_?"},
+		Exclude: []string{"search_private"},
+	}
+	for tool, want := range map[string]bool{
+		"search":         true,
+		"search_issues":  true,
+		"get_x":          true,
+		"get_xy":         false,
+		"search_private": false,
+		"delete":         false,
+	} {
+		if got := srv.ExposesTool(tool); got != want {
+			t.Errorf("ExposesTool(%q) = %v, want %v", tool, got, want)
+		}
+	}
+
+	if got := (&MCPServerConfig{Exclude: []string{"delete_*"}}).ExposesTool("search"); !got {
+		t.Error("ExposesTool(search) = false, want tools exposed without include")
+	}
+
+	if got := srv.ExposedToolName("search"); got != "search" {
+		t.Errorf("ExposedToolName() = %q, want search", got)
+	}
+	srv.Prefix = "github"
+	if got := srv.ExposedToolName("search"); got != "github__search" {
+		t.Errorf("ExposedToolName() = %q, want github__search", got)
+	}
+}
+
+func TestValidate_ToolFilters(t *testing.T) {
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			MCP: MCPConfig{Servers: []MCPServerConfig{{
+				Name:    "github",
+				URL:     "http://localhost:8080",
+				Include: []string{"search", "[bad"},
+				Exclude: []string{"\\"},
+				Prefix:  "git hub",
+			}}},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error for invalid tool filters")
+	}
+	for _, want := range []string{
+		"agent.mcp.servers[0].include[1]",
+		"agent.mcp.servers[0].exclude[0]",
+		"agent.mcp.servers[0].prefix",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "include[0]") {
+		t.Errorf("error = %v, want valid patterns accepted", err)
+	}
+}
+
+func TestToolsConfig_Approval(t *testing.T) {
+	tools := ToolsConfig{RequireApproval: []string{"delete_*", "github__merge_pr"}}
+	for tool, want := range map[string]bool{
+		"delete_record":    true,
+		"github__merge_pr": true,
+		"merge_pr":         false,
+		"search":           false,
+	} {
+		if got := tools.RequiresApproval(tool); got != want {
+			t.Errorf("RequiresApproval(%q) = %v, want %v", tool, got, want)
+		}
+	}
+
+	if got := tools.GetApprovalTimeout(); got != 5*time.Minute {
+		t.Errorf("GetApprovalTimeout() = %v, want 5m", got)
+	}
+	tools.Approval.Timeout = "30s"
+	if got := tools.GetApprovalTimeout(); got != 30*time.Second {
+		t.Errorf("GetApprovalTimeout() = %v, want 30s", got)
+	}
+
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Tools: ToolsConfig{
+				RequireApproval: []string{"[delete"},
+				Approval:        ApprovalConfig{Timeout: "later"},
+			},
+		},
+	}
+	err := cfg.Validate()
+	for _, want := range []string{"agent.tools.require_approval[0]", "agent.tools.approval.timeout"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestToolsConfig_MaxResultBytes(t *testing.T) {
+	tools := ToolsConfig{
+		MaxResultBytes:     4096,
+		ToolMaxResultBytes: map[string]int{"read_file": 65536, "fetch": 0},
+	}
+	for tool, want := range map[string]int{"read_file": 65536, "fetch": 0, "search": 4096} {
+		if got := tools.GetMaxResultBytes(tool); got != want {
+			t.Errorf("GetMaxResultBytes(%q) = %d, want %d", tool, got, want)
+		}
+	}
+	if got := tools.Summarize.GetMaxInputBytes(); got != 128*1024 {
+		t.Errorf("GetMaxInputBytes() = %d, want 131072", got)
+	}
+
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Tools: ToolsConfig{
+				MaxResultBytes:     -1,
+				ToolMaxResultBytes: map[string]int{"read_file": -1},
+				Summarize:          SummarizeConfig{MaxInputBytes: -1},
+			},
+		},
+	}
+	err := cfg.Validate()
+	for _, want := range []string{"agent.tools.max_result_bytes", "agent.tools.tool_max_result_bytes.read_file", "agent.tools.summarize.max_input_bytes"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestMCPServerConfig_ExposesResource(t *testing.T) {
+	srv := MCPServerConfig{Resources: []string{"file:///docs/*", "db://customers/?"}}
+	for uri, want := range map[string]bool{
+		"file:///docs/guide.md":      true,
+		"file:///docs/api/errors.md": true,
+		"file:///etc/passwd":         false,
+		"db://customers/1":           true,
+		"db://customers/12":          false,
+		"file:///docs.md":            false,
+	} {
+		if got := srv.ExposesResource(uri); got != want {
+			t.Errorf("ExposesResource(%q) = %v, want %v", uri, got, want)
+		}
+	}
+	if (&MCPServerConfig{}).ExposesResource("file:///docs/guide.md") {
+		t.Error("ExposesResource() = true without resources, want false")
+	}
+}
+
+func TestConfig_InstructionsFrom(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Agent: AgentConfig{
+				Name:  "test",
+				Model: "gpt-4",
+				Topics: TopicsConfig{
+					Subscribe: []string{"input"},
+					Publish:   []string{"output"},
+				},
+				MCP: MCPConfig{Servers: []MCPServerConfig{{Name: "prompts", Command: []string{"prompt-server"}}}},
+			},
+		}
+	}
+
+	cfg := base()
+	cfg.Agent.InstructionsFrom = InstructionsFromConfig{Server: "prompts", Prompt: "support", Args: map[string]string{"team": "billing"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name string
+		from InstructionsFromConfig
+		inst string
+		want string
+	}{
+		{"unknown server", InstructionsFromConfig{Server: "other", Prompt: "support"}, "", `no MCP server named "other"`},
+		{"missing prompt", InstructionsFromConfig{Server: "prompts"}, "", "agent.instructions_from.prompt is required"},
+		{"missing server", InstructionsFromConfig{Prompt: "support"}, "", "agent.instructions_from.server is required"},
+		{"both set", InstructionsFromConfig{Server: "prompts", Prompt: "support"}, "Be helpful.", "cannot be combined with agent.instructions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			cfg.Agent.InstructionsFrom = tt.from
+			cfg.Agent.Instructions = tt.inst
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMCPServerConfig_Supervision(t *testing.T) {
+	var srv MCPServerConfig
+	if got := srv.HealthCheck.GetInterval(); got != 30*time.Second {
+		t.Errorf("GetInterval() = %v, want 30s", got)
+	}
+	if got := srv.HealthCheck.GetTimeout(); got != 5*time.Second {
+		t.Errorf("GetTimeout() = %v, want 5s", got)
+	}
+	if got := srv.Restart.GetBaseBackoff(); got != time.Second {
+		t.Errorf("GetBaseBackoff() = %v, want 1s", got)
+	}
+	if got := srv.Restart.GetMaxBackoff(); got != 30*time.Second {
+		t.Errorf("GetMaxBackoff() = %v, want 30s", got)
+	}
+
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			MCP: MCPConfig{Servers: []MCPServerConfig{{
+				Name:        "files",
+				Command:     []string{"files-server"},
+				HealthCheck: MCPHealthCheckConfig{Interval: "often"},
+				Restart:     MCPRestartConfig{MaxAttempts: -1, MaxBackoff: "-1s"},
+			}}},
+		},
+	}
+	err := cfg.Validate()
+	for _, want := range []string{
+		"agent.mcp.servers[0].health_check.interval",
+		"agent.mcp.servers[0].restart.max_attempts",
+		"agent.mcp.servers[0].restart.max_backoff",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestMCPServerConfig_InheritsEnv(t *testing.T) {
+	tests := []struct {
+		srv  MCPServerConfig
+		key  string
+		want bool
+	}{
+		{MCPServerConfig{}, "PATH", true},
+		{MCPServerConfig{InheritEnv: InheritEnvNone}, "PATH", false},
+		{MCPServerConfig{InheritEnv: InheritEnvAllowlist, EnvAllowlist: []string{"PATH", "LC_*"}}, "PATH", true},
+		{MCPServerConfig{InheritEnv: InheritEnvAllowlist, EnvAllowlist: []string{"PATH", "LC_*"}}, "LC_ALL", true},
+		{MCPServerConfig{InheritEnv: InheritEnvAllowlist, EnvAllowlist: []string{"PATH", "LC_*"}}, "AWS_SECRET_ACCESS_KEY", false},
+	}
+	for _, tt := range tests {
+		if got := tt.srv.InheritsEnv(tt.key); got != tt.want {
+			t.Errorf("InheritsEnv(%q) with %q %v = %v, want %v", tt.key, tt.srv.InheritEnv, tt.srv.EnvAllowlist, got, tt.want)
+		}
+	}
+}
+
+func TestMCPServerConfig_Environment(t *testing.T) {
+	var srv MCPServerConfig
+	if got := srv.GetStartTimeout(); got != 0 {
+		t.Errorf("GetStartTimeout() = %v, want 0", got)
+	}
+
+	cfg := &Config{
+		Agent: AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			MCP: MCPConfig{Servers: []MCPServerConfig{
+				{
+					Name:         "files",
+					Command:      []string{"files-server"},
+					Env:          map[string]string{"A=B": "c"},
+					InheritEnv:   "some",
+					StartTimeout: "soon",
+					Restart:      MCPRestartConfig{MaxRestarts: -1},
+				},
+				{
+					Name:         "search",
+					Command:      []string{"search-server"},
+					InheritEnv:   InheritEnvAllowlist,
+					EnvAllowlist: []string{"["},
+				},
+				{
+					Name:       "tickets",
+					Command:    []string{"tickets-server"},
+					InheritEnv: InheritEnvAllowlist,
+				},
+				{
+					Name:    "remote",
+					URL:     "http://localhost:8080/mcp",
+					EnvFile: ".env",
+					Cwd:     "/srv",
+				},
+			}},
+		},
+	}
+	err := cfg.Validate()
+	for _, want := range []string{
+		`agent.mcp.servers[0].env: invalid variable name "A=B"`,
+		"agent.mcp.servers[0].inherit_env must be one of all, none, allowlist",
+		"agent.mcp.servers[0].start_timeout",
+		"agent.mcp.servers[0].restart.max_restarts",
+		"agent.mcp.servers[1].env_allowlist[0]: invalid glob pattern",
+		"agent.mcp.servers[2].env_allowlist is required with inherit_env: allowlist",
+		"agent.mcp.servers[3].env_file only applies to servers with a command",
+		"agent.mcp.servers[3].cwd only applies to servers with a command",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want to contain %q", err, want)
+		}
+	}
+
+	cfg.Agent.MCP.Servers = []MCPServerConfig{{
+		Name:         "files",
+		Command:      []string{"files-server"},
+		InheritEnv:   InheritEnvNone,
+		EnvAllowlist: []string{"PATH"},
+	}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "env_allowlist requires inherit_env: allowlist") {
+		t.Errorf("Validate() error = %v, want env_allowlist rejected without allowlist mode", err)
+	}
+
+	cfg.Agent.MCP.Servers[0].EnvAllowlist = nil
+	cfg.Agent.MCP.Servers[0].EnvFile = ".env"
+	cfg.Agent.MCP.Servers[0].Cwd = "/srv/files"
+	cfg.Agent.MCP.Servers[0].StartTimeout = "10s"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is a config error tied to a YAML path and, when the config was
+// loaded from YAML, the source position of that path.
+type FieldError struct {
+	File    string // Source file, empty if loaded from bytes
+	Line    int    // 1-based line, 0 if unknown
+	Column  int    // 1-based column, 0 if unknown
+	Path    string // YAML path, e.g. agent.mcp.servers[1].url
+	Message string // Human-readable message
+}
+
+// Error formats the error as file:line:column: message when the position is known.
+func (e *FieldError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	if e.File == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// position is a line/column location in the YAML source.
+type position struct {
+	line   int
+	column int
+}
+
+// fieldIssue is a problem found while loading, reported later by Validate.
+type fieldIssue struct {
+	path    string
+	message string
+}
+
+// collectPositions records the source position of every YAML path below node.
+// Mapping entries use the key's position so errors point at the field name.
+func collectPositions(node *yaml.Node, path string, positions map[string]position) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectPositions(child, path, positions)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			childPath := joinPath(path, key.Value)
+			positions[childPath] = position{line: key.Line, column: key.Column}
+			collectPositions(node.Content[i+1], childPath, positions)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			childPath := path + "[" + strconv.Itoa(i) + "]"
+			positions[childPath] = position{line: child.Line, column: child.Column}
+			collectPositions(child, childPath, positions)
+		}
+	}
+}
+
+// joinPath appends a mapping key to a YAML path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// fieldError builds a FieldError for path, using the position of the closest
+// enclosing path present in the source (e.g. a missing agent.name points at agent).
+func (c *Config) fieldError(path, message string) *FieldError {
+	fe := &FieldError{File: c.file, Path: path, Message: message}
+	for p := path; p != ""; p = parentPath(p) {
+		if pos, ok := c.positions[p]; ok {
+			fe.Line = pos.line
+			fe.Column = pos.column
+			break
+		}
+	}
+	return fe
+}
+
+// parentPath strips the last key or index from a YAML path.
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		if i := strings.LastIndex(path, "["); i >= 0 {
+			return path[:i]
+		}
+	}
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
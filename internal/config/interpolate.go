@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretMask replaces secret values in redacted output.
+const secretMask = "****"
+
+// referencePattern matches ${...} references and the $$ escape sequence.
+var referencePattern = regexp.MustCompile(`\$\$|\$\{([^}]*)\}`)
+
+// interpolator expands ${VAR}, ${VAR:-default} and ${file:/path} references
+// in YAML scalar values, tracking unresolved references and secret values.
+type interpolator struct {
+	lookupEnv  func(string) (string, bool)
+	readFile   func(string) ([]byte, error)
+	unresolved []fieldIssue
+	secrets    []string // values read from ${file:...} references
+}
+
+func newInterpolator() *interpolator {
+	return &interpolator{
+		lookupEnv: os.LookupEnv,
+		readFile:  os.ReadFile,
+	}
+}
+
+// walk expands references in every scalar value below node. The type t is
+// the Go type the node decodes into, or nil when it isn't known.
+// Mapping keys are left untouched.
+func (in *interpolator) walk(node *yaml.Node, t reflect.Type, path string) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			in.walk(child, t, path)
+		}
+	case yaml.MappingNode:
+		var fields map[string]reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = yamlFields(t)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			var child reflect.Type
+			switch {
+			case fields != nil:
+				child = fields[key]
+			case t != nil && t.Kind() == reflect.Map:
+				child = t.Elem()
+			}
+			in.walk(node.Content[i+1], child, joinPath(path, key))
+		}
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i, child := range node.Content {
+			in.walk(child, elem, path+"["+strconv.Itoa(i)+"]")
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return
+		}
+		original := node.Value
+		expanded := in.expand(original, path)
+		if expanded == original {
+			return
+		}
+		node.Value = expanded
+		if node.Style != 0 {
+			return
+		}
+		// Let plain scalars be re-resolved so ${PORT} can decode into an int,
+		// but keep string fields strings: a value of "null" or "~" would
+		// otherwise decode as "". Untyped values (plugin config) are only
+		// re-resolved when the whole scalar is a single non-null reference.
+		switch {
+		case t != nil && t.Kind() == reflect.String:
+			node.Tag = "!!str"
+		case t != nil && t.Kind() != reflect.Interface:
+			node.Tag = ""
+		case singleReference(original) && !nullValues[expanded]:
+			node.Tag = ""
+		default:
+			node.Tag = "!!str"
+		}
+	}
+}
+
+// nullValues are the plain scalars YAML resolves to null.
+var nullValues = map[string]bool{"": true, "~": true, "null": true, "Null": true, "NULL": true}
+
+// singleReference reports whether value consists of exactly one ${...} reference.
+func singleReference(value string) bool {
+	loc := referencePattern.FindStringIndex(value)
+	return loc != nil && loc[0] == 0 && loc[1] == len(value) && value != "$$"
+}
+
+// expand replaces all references in value. The path is used for error reporting.
+func (in *interpolator) expand(value, path string) string {
+	return referencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		ref := match[2 : len(match)-1]
+
+		// ${file:/path} reads a secret from disk
+		if file, ok := strings.CutPrefix(ref, "file:"); ok {
+			if file == "" {
+				in.unresolve(path, match, "empty file path")
+				return ""
+			}
+			data, err := in.readFile(file)
+			if err != nil {
+				in.unresolve(path, match, err.Error())
+				return ""
+			}
+			secret := strings.TrimRight(string(data), "\r\n")
+			if secret != "" {
+				in.secrets = append(in.secrets, secret)
+			}
+			return secret
+		}
+
+		// ${VAR} or ${VAR:-default}
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if name == "" {
+			in.unresolve(path, match, "empty variable name")
+			return ""
+		}
+		if v, ok := in.lookupEnv(name); ok && (v != "" || !hasDefault) {
+			return v
+		}
+		if hasDefault {
+			return def
+		}
+		in.unresolve(path, match, "environment variable "+name+" is not set")
+		return ""
+	})
+}
+
+func (in *interpolator) unresolve(path, ref, reason string) {
+	in.unresolved = append(in.unresolved, fieldIssue{
+		path:    path,
+		message: fmt.Sprintf("%s: unresolved reference %s (%s)", path, ref, reason),
+	})
+}
+
+// Redact replaces any secret values loaded via ${file:...} references with a mask.
+// Use it before logging or displaying values that may contain secrets.
+func (c *Config) Redact(s string) string {
+	if len(c.secrets) == 0 || s == "" {
+		return s
+	}
+
+	// Replace longer secrets first so overlapping values are fully masked
+	secrets := append([]string(nil), c.secrets...)
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, secretMask)
+	}
+	return s
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_InterpolatesEnvVars(t *testing.T) {
+	t.Setenv("ATHYR_TEST_MODEL", "gpt-4")
+	t.Setenv("ATHYR_TEST_TOKEN", "tok-123")
+	t.Setenv("ATHYR_TEST_MAX", "8192")
+
+	yaml := `
+agent:
+  name: test-agent
+  model: ${ATHYR_TEST_MODEL}
+  topics:
+    subscribe: [input]
+    publish: [output]
+  completion:
+    max_tokens: ${ATHYR_TEST_MAX}
+  mcp:
+    servers:
+      - name: gateway
+        url: https://${ATHYR_TEST_HOST:-mcp.example.com}/tools
+        env:
+          TOKEN: "Bearer ${ATHYR_TEST_TOKEN}"
+  plugins:
+    - name: webhook
+      file: ./webhook.lua
+      config:
+        api_key: ${ATHYR_TEST_TOKEN}
+        price: $$5
+`
+	cfg, err := Load([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if cfg.Agent.Model != "gpt-4" {
+		t.Errorf("Model = %v, want gpt-4", cfg.Agent.Model)
+	}
+	if cfg.Agent.Completion.MaxTokens != 8192 {
+		t.Errorf("Completion.MaxTokens = %v, want 8192", cfg.Agent.Completion.MaxTokens)
+	}
+	srv := cfg.Agent.MCP.Servers[0]
+	if srv.URL != "https://mcp.example.com/tools" {
+		t.Errorf("URL = %v, want default host", srv.URL)
+	}
+	if srv.Env["TOKEN"] != "Bearer tok-123" {
+		t.Errorf("Env[TOKEN] = %v, want 'Bearer tok-123'", srv.Env["TOKEN"])
+	}
+	pluginCfg := cfg.Agent.Plugins[0].Config
+	if pluginCfg["api_key"] != "tok-123" {
+		t.Errorf("plugin api_key = %v, want tok-123", pluginCfg["api_key"])
+	}
+	if pluginCfg["price"] != "$5" {
+		t.Errorf("plugin price = %v, want $5 (escaped)", pluginCfg["price"])
+	}
+}
+
+func TestLoad_InterpolatesEmptyEnvVarWithDefault(t *testing.T) {
+	t.Setenv("ATHYR_TEST_EMPTY", "")
+
+	cfg, err := Load([]byte(`
+agent:
+  model: ${ATHYR_TEST_EMPTY:-fallback-model}
+  description: "${ATHYR_TEST_EMPTY}"
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Agent.Model != "fallback-model" {
+		t.Errorf("Model = %v, want fallback-model", cfg.Agent.Model)
+	}
+	if cfg.Agent.Description != "" {
+		t.Errorf("Description = %v, want empty (set but empty)", cfg.Agent.Description)
+	}
+	if len(cfg.unresolved) != 0 {
+		t.Errorf("unresolved = %v, want none", cfg.unresolved)
+	}
+}
+
+func TestLoad_InterpolatesNullLikeValuesAsStrings(t *testing.T) {
+	t.Setenv("API_KEY", "null")
+	t.Setenv("ATHYR_TEST_MAX", "4096")
+
+	cfg, err := Load([]byte(`
+agent:
+  model: ${API_KEY}
+  completion:
+    max_tokens: ${ATHYR_TEST_MAX}
+  mcp:
+    servers:
+      - name: gateway
+        url: https://mcp.example.com/tools
+        env:
+          API_KEY: ${API_KEY}
+  plugins:
+    - name: webhook
+      file: ./webhook.lua
+      config:
+        api_key: ${API_KEY}
+        port: ${ATHYR_TEST_MAX}
+        label: v${ATHYR_TEST_MAX}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Agent.Model != "null" {
+		t.Errorf("Model = %q, want null", cfg.Agent.Model)
+	}
+	if cfg.Agent.Completion.MaxTokens != 4096 {
+		t.Errorf("Completion.MaxTokens = %v, want 4096", cfg.Agent.Completion.MaxTokens)
+	}
+	if got := cfg.Agent.MCP.Servers[0].Env["API_KEY"]; got != "null" {
+		t.Errorf("Env[API_KEY] = %q, want null", got)
+	}
+	pluginCfg := cfg.Agent.Plugins[0].Config
+	if pluginCfg["api_key"] != "null" {
+		t.Errorf("plugin api_key = %#v, want \"null\"", pluginCfg["api_key"])
+	}
+	if pluginCfg["port"] != 4096 {
+		t.Errorf("plugin port = %#v, want 4096", pluginCfg["port"])
+	}
+	if pluginCfg["label"] != "v4096" {
+		t.Errorf("plugin label = %#v, want \"v4096\"", pluginCfg["label"])
+	}
+}
+
+func TestLoad_InterpolatesFileSecrets(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "api_key")
+	if err := os.WriteFile(secretPath, []byte("s3cr3t-value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load([]byte(`
+agent:
+  name: test-agent
+  model: gpt-4
+  topics:
+    subscribe: [input]
+    publish: [output]
+  mcp:
+    servers:
+      - name: gateway
+        url: https://mcp.example.com/tools?key=${file:` + secretPath + `}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	url := cfg.Agent.MCP.Servers[0].URL
+	if url != "https://mcp.example.com/tools?key=s3cr3t-value" {
+		t.Errorf("URL = %v, want secret expanded with trailing newline trimmed", url)
+	}
+
+	redacted := cfg.Redact("connecting to " + url)
+	if strings.Contains(redacted, "s3cr3t-value") {
+		t.Errorf("Redact() = %v, secret not masked", redacted)
+	}
+	if redacted != "connecting to https://mcp.example.com/tools?key=****" {
+		t.Errorf("Redact() = %v, want masked URL", redacted)
+	}
+}
+
+func TestValidate_ReportsUnresolvedReferences(t *testing.T) {
+	cfg, err := Load([]byte(`
+agent:
+  name: test-agent
+  model: gpt-4
+  topics:
+    subscribe: [input]
+    publish: [output]
+  mcp:
+    servers:
+      - name: local
+        command: ["tool"]
+      - name: gateway
+        url: ${ATHYR_TEST_UNSET_URL}
+        env:
+          KEY: ${file:/nonexistent/athyr/secret}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error for unresolved references")
+	}
+	if !strings.Contains(err.Error(), "agent.mcp.servers[1].url: unresolved reference ${ATHYR_TEST_UNSET_URL}") {
+		t.Errorf("error = %v, want unresolved url reference with YAML path", err)
+	}
+	if !strings.Contains(err.Error(), "agent.mcp.servers[1].env.KEY: unresolved reference ${file:/nonexistent/athyr/secret}") {
+		t.Errorf("error = %v, want unresolved file reference with YAML path", err)
+	}
+}
+
+func TestConfig_Redact_NoSecrets(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.Redact("plain value"); got != "plain value" {
+		t.Errorf("Redact() = %v, want unchanged", got)
+	}
+}
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// SchemaDraft is the JSON Schema dialect emitted by Schema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches Go duration strings accepted by time.ParseDuration (e.g. "1h30m", "500ms").
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+// referenceSchemaPattern matches strings containing a ${...} reference, which
+// are accepted in place of typed values since they are expanded at load time.
+const referenceSchemaPattern = `\$\{[^}]*\}`
+
+// Schema returns a JSON Schema describing the agent YAML format.
+// Properties are generated from the config structs' yaml and jsonschema tags;
+// constraints that can't be expressed as tags are applied afterwards.
+func Schema() *jsonschema.Schema {
+	s := schemaFor(reflect.TypeOf(Config{}))
+	s.Schema = SchemaDraft
+	s.Title = "athyr-agent configuration"
+	s.Required = []string{"agent"}
+
+	constrain(s, "agent", func(s *jsonschema.Schema) {
+		s.Required = []string{"name", "model", "topics"}
+		s.Not = &jsonschema.Schema{Required: []string{"instructions", "instructions_from"}}
+	})
+	constrain(s, "agent.plugins[]", func(s *jsonschema.Schema) {
+		s.Required = []string{"name", "file"}
+	})
+	constrain(s, "agent.topics", func(s *jsonschema.Schema) {
+		s.Required = []string{"subscribe", "publish"}
+	})
+	constrain(s, "agent.topics.subscribe", func(s *jsonschema.Schema) {
+		s.MinItems = jsonschema.Ptr(1)
+	})
+	constrain(s, "agent.topics.publish", func(s *jsonschema.Schema) {
+		s.MinItems = jsonschema.Ptr(1)
+	})
+	constrain(s, "agent.topics.routes[]", func(s *jsonschema.Schema) {
+		s.Required = []string{"topic", "description"}
+	})
+
+	constrain(s, "agent.fallback_models[]", func(s *jsonschema.Schema) {
+		s.MinLength = jsonschema.Ptr(1)
+	})
+
+	// Completion parameters
+	constrain(s, "agent.completion.temperature", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+		s.Maximum = jsonschema.Ptr(2.0)
+	})
+	constrain(s, "agent.completion.max_tokens", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.completion.top_p", func(s *jsonschema.Schema) {
+		s.ExclusiveMinimum = jsonschema.Ptr(0.0)
+		s.Maximum = jsonschema.Ptr(1.0)
+	})
+	constrain(s, "agent.completion.stop[]", func(s *jsonschema.Schema) {
+		s.MinLength = jsonschema.Ptr(1)
+	})
+	constrain(s, "agent.completion.tool_choice", func(s *jsonschema.Schema) {
+		s.Enum = []any{ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired}
+	})
+
+	// Streaming
+	constrain(s, "agent.streaming.chunk_size", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+
+	// Memory
+	constrain(s, "agent.memory.ttl", durationConstraint)
+	constrain(s, "agent.memory.backend", func(s *jsonschema.Schema) {
+		s.Enum = []any{MemoryBackendAthyr, MemoryBackendLocal}
+	})
+	constrain(s, "agent.memory.max_sessions", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.memory.profile.type", func(s *jsonschema.Schema) {
+		s.Enum = []any{"rolling_window"}
+	})
+
+	// MCP servers use exactly one transport
+	constrain(s, "agent.mcp.servers[]", func(s *jsonschema.Schema) {
+		s.Required = []string{"name"}
+		s.OneOf = []*jsonschema.Schema{
+			{Required: []string{"command"}},
+			{Required: []string{"url"}},
+		}
+	})
+	constrain(s, "agent.mcp.servers[].command", func(s *jsonschema.Schema) {
+		s.MinItems = jsonschema.Ptr(1)
+	})
+	constrain(s, "agent.mcp.servers[].timeout", durationConstraint)
+	constrain(s, "agent.mcp.servers[].health_check.interval", durationConstraint)
+	constrain(s, "agent.mcp.servers[].health_check.timeout", durationConstraint)
+	constrain(s, "agent.mcp.servers[].restart.base_backoff", durationConstraint)
+	constrain(s, "agent.mcp.servers[].restart.max_backoff", durationConstraint)
+	constrain(s, "agent.mcp.servers[].restart.max_attempts", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.mcp.servers[].restart.max_restarts", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.mcp.servers[].start_timeout", durationConstraint)
+	constrain(s, "agent.mcp.servers[].inherit_env", func(s *jsonschema.Schema) {
+		s.Enum = []any{InheritEnvAll, InheritEnvNone, InheritEnvAllowlist}
+	})
+	constrain(s, "agent.mcp.servers[].prefix", func(s *jsonschema.Schema) {
+		s.Pattern = toolPrefixPattern.String()
+	})
+	constrain(s, "agent.mcp.servers[].tool_timeouts", func(s *jsonschema.Schema) {
+		durationConstraint(s.AdditionalProperties)
+	})
+
+	// Tools
+	constrain(s, "agent.tools.max_parallel", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.tools.max_iterations", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.tools.approval.timeout", durationConstraint)
+	constrain(s, "agent.tools.max_result_bytes", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.tools.tool_max_result_bytes", func(s *jsonschema.Schema) {
+		s.AdditionalProperties.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.tools.summarize.max_input_bytes", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+
+	// Connection
+	constrain(s, "agent.connection.timeout", durationConstraint)
+	constrain(s, "agent.connection.max_retries", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.connection.base_backoff", durationConstraint)
+	constrain(s, "agent.connection.max_backoff", durationConstraint)
+
+	// Processing
+	constrain(s, "agent.processing.timeout", durationConstraint)
+	constrain(s, "agent.processing.llm_timeout", durationConstraint)
+	constrain(s, "agent.processing.tool_timeout", durationConstraint)
+	constrain(s, "agent.processing.publish_timeout", durationConstraint)
+	constrain(s, "agent.processing.max_concurrency", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.processing.queue_size", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+
+	// Errors
+	constrain(s, "agent.errors.max_retries", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.errors.base_backoff", durationConstraint)
+	constrain(s, "agent.errors.max_backoff", durationConstraint)
+	constrain(s, "agent.errors.circuit_breaker.failure_threshold", func(s *jsonschema.Schema) {
+		s.Minimum = jsonschema.Ptr(0.0)
+	})
+	constrain(s, "agent.errors.circuit_breaker.cooldown", durationConstraint)
+	constrain(s, "agent.errors.retry_on[]", func(s *jsonschema.Schema) {
+		s.Enum = make([]any, len(ErrorClasses))
+		for i, class := range ErrorClasses {
+			s.Enum[i] = class
+		}
+	})
+
+	// Shutdown
+	constrain(s, "agent.shutdown.grace_period", durationConstraint)
+
+	return s
+}
+
+// durationConstraint restricts a string property to Go duration syntax
+// or a ${...} reference.
+func durationConstraint(s *jsonschema.Schema) {
+	s.Pattern = durationPattern + "|" + referenceSchemaPattern
+}
+
+// scalarSchema describes a non-string scalar, which may also be given as a
+// string containing a ${...} reference. Numeric constraints such as minimum
+// only apply to numbers, so they still hold for literal values.
+func scalarSchema(typ string) *jsonschema.Schema {
+	return &jsonschema.Schema{Types: []string{typ, "string"}, Pattern: referenceSchemaPattern}
+}
+
+// constrain applies fn to the schema at path. Path segments are property names
+// separated by dots; a "[]" suffix selects the item schema of an array.
+// It panics if the path doesn't exist, so schema and structs can't drift apart.
+func constrain(root *jsonschema.Schema, path string, fn func(*jsonschema.Schema)) {
+	s := root
+	for _, segment := range strings.Split(path, ".") {
+		name, isItems := strings.CutSuffix(segment, "[]")
+		s = s.Properties[name]
+		if s == nil {
+			panic(fmt.Sprintf("config schema: unknown path %q", path))
+		}
+		if isItems {
+			s = s.Items
+			if s == nil {
+				panic(fmt.Sprintf("config schema: %q is not an array", path))
+			}
+		}
+	}
+	fn(s)
+}
+
+// schemaFor builds the schema for a Go type as it appears in agent YAML.
+func schemaFor(t reflect.Type) *jsonschema.Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonschema.Schema{Type: "string"}
+	case reflect.Bool:
+		return scalarSchema("boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return scalarSchema("integer")
+	case reflect.Float32, reflect.Float64:
+		return scalarSchema("number")
+	case reflect.Slice, reflect.Array:
+		return &jsonschema.Schema{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return &jsonschema.Schema{Type: "object"} // Free-form
+		}
+		return &jsonschema.Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem())}
+	case reflect.Struct:
+		s := &jsonschema.Schema{
+			Type:                 "object",
+			Properties:           make(map[string]*jsonschema.Schema),
+			AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			prop := schemaFor(f.Type)
+			prop.Description = f.Tag.Get("jsonschema")
+			s.Properties[name] = prop
+		}
+		return s
+	}
+	return &jsonschema.Schema{}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// validateAgainstSchema decodes YAML into generic JSON values and validates it.
+func validateAgainstSchema(t *testing.T, data []byte) error {
+	t.Helper()
+
+	resolved, err := Schema().Resolve(nil)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	// Round-trip through JSON so values have the types the validator expects
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var instance any
+	if err := json.Unmarshal(raw, &instance); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	return resolved.Validate(instance)
+}
+
+func TestSchema_ValidatesExamples(t *testing.T) {
+	var files []string
+	err := filepath.WalkDir("../../examples", func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".yaml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir() error = %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no example files found")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if err := validateAgainstSchema(t, data); err != nil {
+				t.Errorf("example does not match schema: %v", err)
+			}
+		})
+	}
+}
+
+func TestSchema_Metadata(t *testing.T) {
+	s := Schema()
+	if s.Schema != SchemaDraft {
+		t.Errorf("$schema = %q, want %q", s.Schema, SchemaDraft)
+	}
+
+	// Every field documented in Go must carry a description in the schema
+	var check func(path string, s *jsonschema.Schema)
+	check = func(path string, s *jsonschema.Schema) {
+		for name, prop := range s.Properties {
+			propPath := joinPath(path, name)
+			if prop.Description == "" {
+				t.Errorf("%s: missing description", propPath)
+			}
+			check(propPath, prop)
+			if prop.Items != nil {
+				check(propPath+"[]", prop.Items)
+			}
+		}
+	}
+	check("", s)
+
+	if _, err := json.Marshal(s); err != nil {
+		t.Errorf("json.Marshal() error = %v", err)
+	}
+}
+
+func TestSchema_MatchesStructs(t *testing.T) {
+	// Every yaml field of the config structs appears as a schema property
+	var check func(path string, typ reflect.Type, s *jsonschema.Schema)
+	check = func(path string, typ reflect.Type, s *jsonschema.Schema) {
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+			if s.Items != nil {
+				s = s.Items
+			}
+		}
+		if typ.Kind() != reflect.Struct {
+			return
+		}
+		for name, field := range yamlFields(typ) {
+			prop, ok := s.Properties[name]
+			if !ok {
+				t.Errorf("%s: missing from schema", joinPath(path, name))
+				continue
+			}
+			check(joinPath(path, name), field, prop)
+		}
+	}
+	check("", reflect.TypeOf(Config{}), Schema())
+}
+
+func TestSchema_RejectsInvalid(t *testing.T) {
+	base := `
+agent:
+  name: test
+  model: gpt-4
+  topics:
+    subscribe: [in]
+    publish: [out]
+`
+	tests := []struct {
+		name  string
+		extra string
+	}{
+		{"unknown field", "  modle: gpt-4\n"},
+		{"command and url", "  mcp:\n    servers:\n      - name: both\n        command: [server]\n        url: http://localhost\n"},
+		{"neither command nor url", "  mcp:\n    servers:\n      - name: none\n"},
+		{"bad profile type", "  memory:\n    enabled: true\n    profile:\n      type: sliding\n"},
+		{"bad duration", "  connection:\n    timeout: 30 seconds\n"},
+		{"bad tool_choice", "  completion:\n    tool_choice: always\n"},
+		{"temperature out of range", "  completion:\n    temperature: 3\n"},
+		{"wrong type", "  completion:\n    max_tokens: lots\n"},
+		{"instructions and instructions_from", "  instructions: Be brief.\n  instructions_from:\n    server: docs\n    prompt: system\n"},
+	}
+
+	if err := validateAgainstSchema(t, []byte(base)); err != nil {
+		t.Fatalf("base config does not match schema: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAgainstSchema(t, []byte(base+tt.extra)); err == nil {
+				t.Error("expected schema validation error")
+			}
+		})
+	}
+}
+
+func TestSchema_AcceptsDurations(t *testing.T) {
+	for _, d := range []string{"0", "30s", "1h30m", "500ms", "1.5h"} {
+		data := `
+agent:
+  name: test
+  model: gpt-4
+  topics:
+    subscribe: [in]
+    publish: [out]
+  connection:
+    timeout: "` + d + `"
+`
+		if err := validateAgainstSchema(t, []byte(data)); err != nil {
+			t.Errorf("duration %q rejected: %v", d, err)
+		}
+	}
+}
+
+func TestSchema_AcceptsReferences(t *testing.T) {
+	data := `
+agent:
+  name: test
+  model: ${MODEL}
+  topics:
+    subscribe: [in]
+    publish: [out]
+  completion:
+    max_tokens: ${MAX_TOKENS:-4096}
+    temperature: ${TEMPERATURE}
+  streaming:
+    enabled: ${STREAMING:-false}
+  connection:
+    timeout: ${CONNECT_TIMEOUT:-30s}
+`
+	if err := validateAgainstSchema(t, []byte(data)); err != nil {
+		t.Errorf("references rejected: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkKnownFields reports mapping keys under node that don't correspond to a
+// yaml-tagged field of t, with a "did you mean" suggestion when a known field
+// is a close match. Free-form maps (e.g. plugin config) accept any key.
+func checkKnownFields(node *yaml.Node, t reflect.Type, path string) []fieldIssue {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var issues []fieldIssue
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			issues = append(issues, checkKnownFields(child, t, path)...)
+		}
+	case yaml.MappingNode:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := yamlFields(t)
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				childPath := joinPath(path, key)
+				field, ok := fields[key]
+				if !ok {
+					issues = append(issues, fieldIssue{
+						path:    childPath,
+						message: unknownFieldMessage(childPath, key, fields),
+					})
+					continue
+				}
+				issues = append(issues, checkKnownFields(node.Content[i+1], field, childPath)...)
+			}
+		case reflect.Map:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				childPath := joinPath(path, node.Content[i].Value)
+				issues = append(issues, checkKnownFields(node.Content[i+1], t.Elem(), childPath)...)
+			}
+		}
+	case yaml.SequenceNode:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, child := range node.Content {
+				childPath := path + "[" + strconv.Itoa(i) + "]"
+				issues = append(issues, checkKnownFields(child, t.Elem(), childPath)...)
+			}
+		}
+	}
+	return issues
+}
+
+// yamlFields maps the YAML key of each exported struct field to its type.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownFieldMessage describes an unknown key, suggesting the closest known one.
+func unknownFieldMessage(path, key string, fields map[string]reflect.Type) string {
+	msg := fmt.Sprintf("%s: unknown field %q", path, key)
+	if suggestion := closestField(key, fields); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+	return msg
+}
+
+// closestField returns the known field with the smallest edit distance to key,
+// or "" if none is close enough to be a plausible typo.
+func closestField(key string, fields map[string]reflect.Type) string {
+	best := ""
+	bestDist := len(key)/3 + 1 // Allow roughly one edit per three characters
+	for name := range fields {
+		d := editDistance(key, name)
+		if d < bestDist || (d == bestDist && best != "" && name < best) {
+			best = name
+			bestDist = d
+		}
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and adjacent transpositions each cost 1.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_RejectsUnknownFields(t *testing.T) {
+	yaml := `agent:
+  name: test-agent
+  model: gpt-4
+  topics:
+    subscibe: [input]
+    publish: [output]
+  completion:
+    max_token: 100
+`
+	_, err := Load([]byte(yaml))
+	if err == nil {
+		t.Fatal("Load() expected error for unknown fields")
+	}
+
+	for _, want := range []string{
+		`line 5, column 5: agent.topics.subscibe: unknown field "subscibe" (did you mean "subscribe"?)`,
+		`line 8, column 5: agent.completion.max_token: unknown field "max_token" (did you mean "max_tokens"?)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want to contain %q", err, want)
+		}
+	}
+}
+
+func TestLoad_UnknownFieldWithoutSuggestion(t *testing.T) {
+	_, err := Load([]byte(`
+agent:
+  name: test-agent
+  completely_unrelated: true
+`))
+	if err == nil {
+		t.Fatal("Load() expected error for unknown field")
+	}
+	if !strings.Contains(err.Error(), `unknown field "completely_unrelated"`) {
+		t.Errorf("error = %v, want unknown field error", err)
+	}
+	if strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("error = %v, want no suggestion for unrelated key", err)
+	}
+}
+
+func TestLoad_AllowsArbitraryPluginConfigAndEnvKeys(t *testing.T) {
+	_, err := Load([]byte(`
+agent:
+  name: test-agent
+  plugins:
+    - name: webhook
+      file: ./webhook.lua
+      config:
+        anything: goes
+        nested:
+          also: fine
+  mcp:
+    servers:
+      - name: tools
+        command: ["tool"]
+        env:
+          ANY_VAR: value
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v, want free-form maps to accept any key", err)
+	}
+}
+
+func TestLoadWithOptions_LenientReportsWarnings(t *testing.T) {
+	cfg, err := LoadWithOptions([]byte(`
+agent:
+  name: test-agent
+  modle: gpt-4
+`), LoadOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions() error = %v", err)
+	}
+
+	warnings := cfg.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Warnings() = %v, want 1", warnings)
+	}
+	if !strings.Contains(warnings[0].Error(), `agent.modle: unknown field "modle" (did you mean "model"?)`) {
+		t.Errorf("warning = %v, want unknown field with suggestion", warnings[0])
+	}
+}
+
+func TestValidate_ErrorsIncludePosition(t *testing.T) {
+	cfg, err := Load([]byte(`agent:
+  name: test-agent
+  model: gpt-4
+  topics:
+    subscribe: [input]
+    publish: [output]
+  mcp:
+    servers:
+      - name: local
+        command: ["tool"]
+      - name: remote
+        command: ["tool"]
+        url: https://mcp.example.com
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	cfg.file = "agent.yaml"
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error")
+	}
+	want := "agent.yaml:13:9: agent.mcp.servers[1] must specify either command or url, not both"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestValidate_MissingFieldPointsAtParent(t *testing.T) {
+	cfg, err := Load([]byte(`agent:
+  name: test-agent
+  topics:
+    subscribe: [input]
+    publish: [output]
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error for missing model")
+	}
+	if err.Error() != "line 1, column 1: agent.model is required" {
+		t.Errorf("error = %q, want position of enclosing agent key", err.Error())
+	}
+}
+
+func TestClosestField(t *testing.T) {
+	fields := yamlFields(reflect.TypeOf(TopicsConfig{}))
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"subscibe", "subscribe"},
+		{"publsh", "publish"},
+		{"route", "routes"},
+		{"xyz", ""},
+	}
+	for _, tt := range tests {
+		if got := closestField(tt.key, fields); got != tt.want {
+			t.Errorf("closestField(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
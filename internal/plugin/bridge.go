@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"os"
@@ -127,11 +128,27 @@ func (sb *Sandbox) luaJSONDecode(L *lua.LState) int {
 func registerSleep(sb *Sandbox) {
 	sb.L.SetGlobal("sleep", sb.L.NewFunction(func(L *lua.LState) int {
 		seconds := L.CheckNumber(1)
-		time.Sleep(time.Duration(float64(seconds) * float64(time.Second)))
+		timer := time.NewTimer(time.Duration(float64(seconds) * float64(time.Second)))
+		defer timer.Stop()
+
+		// Wake early when the plugin is being stopped
+		select {
+		case <-timer.C:
+		case <-luaContext(L).Done():
+		}
 		return 0
 	}))
 }
 
+// luaContext returns the context attached to L, or context.Background if none.
+// Blocking bridge calls use it so stopping a plugin interrupts them.
+func luaContext(L *lua.LState) context.Context {
+	if ctx := L.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
 // --- log global ---
 
 func registerLog(sb *Sandbox) {
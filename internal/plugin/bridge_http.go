@@ -30,7 +30,13 @@ func (sb *Sandbox) luaHTTPGet(L *lua.LState) int {
 	}
 	url := L.CheckString(1)
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(luaContext(L), http.MethodGet, url, nil)
+	if err != nil {
+		L.ArgError(1, err.Error())
+		return 0
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		L.ArgError(1, err.Error())
 		return 0
@@ -59,7 +65,7 @@ func (sb *Sandbox) luaHTTPPost(L *lua.LState) int {
 	bodyStr := L.CheckString(2)
 	headers := L.OptTable(3, nil)
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(bodyStr))
+	req, err := http.NewRequestWithContext(luaContext(L), "POST", url, strings.NewReader(bodyStr))
 	if err != nil {
 		L.ArgError(1, err.Error())
 		return 0
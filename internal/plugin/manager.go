@@ -1,9 +1,12 @@
 package plugin
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/athyr-tech/athyr-agent/internal/config"
 
@@ -14,6 +17,10 @@ import (
 type pluginState struct {
 	sandbox *Sandbox
 	config  map[string]any
+
+	// Set while the plugin's subscribe function is running
+	stopSubscribe context.CancelFunc
+	subscribeDone chan struct{}
 }
 
 // Manager manages Lua plugin loading, subscribe, and publish lifecycle.
@@ -93,22 +100,37 @@ func (m *Manager) StartSubscribe(name string, callback func(string)) error {
 	// Build config table
 	configTbl := goMapToLuaTable(L, ps.config)
 
+	// The context interrupts the Lua code (and blocking bridge calls) when stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	L.SetContext(ctx)
+
 	// Build callback function
 	cbFn := L.NewFunction(func(L *lua.LState) int {
 		data := L.CheckString(1)
+		if ctx.Err() != nil {
+			return 0 // Stopped: drop data produced after shutdown began
+		}
 		callback(data)
 		return 0
 	})
 
-	// Run subscribe in a goroutine (it may loop forever).
-	// When Close() is called, L.Close() frees the Lua state, causing a panic
-	// in the running goroutine. We recover from it gracefully.
+	done := make(chan struct{})
+	m.mu.Lock()
+	ps.stopSubscribe = cancel
+	ps.subscribeDone = done
+	m.mu.Unlock()
+
+	// Run subscribe in a goroutine (it may loop forever) until StopSubscriptions
+	// cancels its context. If Close() frees the Lua state while it is still
+	// running, the goroutine panics; we recover from it gracefully.
 	go func() {
+		defer close(done)
 		defer func() {
 			if r := recover(); r != nil {
 				m.logger.Debug("subscribe goroutine recovered", "plugin", name, "panic", r)
 			}
 		}()
+		defer L.RemoveContext()
 		if err := L.CallByParam(lua.P{
 			Fn:      fn,
 			NRet:    0,
@@ -152,8 +174,42 @@ func (m *Manager) Publish(name string, data string) error {
 	return nil
 }
 
-// Close shuts down all plugin Lua states.
+// StopSubscriptions stops all running subscribe functions so plugins stop
+// producing messages, waiting until they exit or ctx ends.
+func (m *Manager) StopSubscriptions(ctx context.Context) error {
+	m.mu.Lock()
+	running := make(map[string]chan struct{})
+	for name, ps := range m.plugins {
+		if ps.stopSubscribe == nil {
+			continue
+		}
+		ps.stopSubscribe()
+		running[name] = ps.subscribeDone
+		ps.stopSubscribe = nil
+		ps.subscribeDone = nil
+	}
+	m.mu.Unlock()
+
+	var errs []error
+	for name, done := range running {
+		select {
+		case <-done:
+			m.logger.Debug("stopped subscribe", "plugin", name)
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("plugin %s did not stop: %w", name, ctx.Err()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops any running subscribe functions and shuts down all plugin Lua states.
 func (m *Manager) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.StopSubscriptions(ctx); err != nil {
+		m.logger.Warn("closing plugins with subscribe still running", "error", err)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
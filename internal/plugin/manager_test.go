@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -163,3 +164,56 @@ end
 		t.Errorf("Close() error = %v", err)
 	}
 }
+
+func TestManager_StopSubscriptions(t *testing.T) {
+	dir := t.TempDir()
+
+	// Long sleeps between events: stopping must not wait for them
+	luaCode := `
+function subscribe(config, callback)
+	while true do
+		callback("tick")
+		sleep(10)
+	end
+end
+`
+	luaPath := filepath.Join(dir, "ticker.lua")
+	os.WriteFile(luaPath, []byte(luaCode), 0644)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	mgr := NewManager(logger)
+	defer mgr.Close()
+
+	mgr.LoadPlugin(config.PluginConfig{Name: "ticker", File: luaPath})
+
+	received := make(chan string, 10)
+	if err := mgr.StartSubscribe("ticker", func(data string) {
+		received <- data
+	}); err != nil {
+		t.Fatalf("StartSubscribe() error = %v", err)
+	}
+
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for subscribe callback")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := mgr.StopSubscriptions(ctx); err != nil {
+		t.Fatalf("StopSubscriptions() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("StopSubscriptions() took %v, want it to interrupt sleep", elapsed)
+	}
+
+	// Stopping again is a no-op
+	if err := mgr.StopSubscriptions(ctx); err != nil {
+		t.Errorf("second StopSubscriptions() error = %v", err)
+	}
+	if len(received) != 0 {
+		t.Errorf("received %d callbacks after stop, want 0", len(received))
+	}
+}
@@ -0,0 +1,188 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+	"github.com/google/uuid"
+)
+
+// ApprovalRequest is sent to tools.approval.topic, as a request/reply
+// message, when the LLM calls a tool listed in tools.require_approval.
+// The reply is an ApprovalDecision.
+type ApprovalRequest struct {
+	ID        string          `json:"id"`
+	TraceID   string          `json:"trace_id"`
+	Agent     string          `json:"agent"`
+	Tool      string          `json:"tool"`
+	Server    string          `json:"server,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	ExpiresAt time.Time       `json:"expires_at"` // The call is denied if no decision arrives by then
+}
+
+// ApprovalDecision approves or denies a tool call.
+type ApprovalDecision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"` // Passed to the LLM when the call is denied
+	By       string `json:"by,omitempty"`     // Who decided, for the audit log
+}
+
+// String describes the decision for logs and the TUI.
+func (d ApprovalDecision) String() string {
+	s := "denied"
+	if d.Approved {
+		s = "approved"
+	}
+	if d.By != "" {
+		s += " by " + d.By
+	}
+	if d.Reason != "" {
+		s += ": " + d.Reason
+	}
+	return s
+}
+
+// approvalGate holds the tool calls waiting for a decision.
+type approvalGate struct {
+	mu      sync.Mutex
+	pending map[string]chan ApprovalDecision // approval ID → decision
+}
+
+func newApprovalGate() *approvalGate {
+	return &approvalGate{pending: make(map[string]chan ApprovalDecision)}
+}
+
+// add registers an approval and returns the channel its decision arrives on.
+func (g *approvalGate) add(id string) <-chan ApprovalDecision {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ch := make(chan ApprovalDecision, 1)
+	g.pending[id] = ch
+	return ch
+}
+
+// remove forgets an approval once it has been decided or has timed out.
+func (g *approvalGate) remove(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.pending, id)
+}
+
+// decide delivers a decision. Only the first decision for an approval counts.
+func (g *approvalGate) decide(id string, d ApprovalDecision) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ch, ok := g.pending[id]
+	if !ok {
+		return fmt.Errorf("no pending approval %q", id)
+	}
+	delete(g.pending, id)
+	ch <- d
+	return nil
+}
+
+// DecideApproval approves or denies a tool call waiting for approval, as
+// announced by a ToolEvent with status ToolAwaitingApproval.
+func (h *MessageHandler) DecideApproval(id string, d ApprovalDecision) error {
+	return h.approvals.decide(id, d)
+}
+
+// approvalMargin is the part of a message's remaining budget kept back from
+// an approval wait, so the LLM can still react to a denial. Short budgets
+// keep half instead.
+const approvalMargin = 10 * time.Second
+
+// awaitApproval asks for approval of a tool call and waits for the decision,
+// from the approval topic or DecideApproval, for up to tools.approval.timeout.
+// The wait ends early enough to leave the message approvalMargin of its budget.
+// Without a decision in time the call is denied.
+func (h *MessageHandler) awaitApproval(ctx context.Context, traceID string, call athyr.ToolCall) ApprovalDecision {
+	wait := h.cfg.Agent.Tools.GetApprovalTimeout()
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		wait = min(wait, remaining-min(approvalMargin, remaining/2))
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, max(wait, 0))
+	defer cancel()
+
+	start := time.Now()
+	req := ApprovalRequest{
+		ID:        uuid.New().String(),
+		TraceID:   traceID,
+		Agent:     h.cfg.Agent.Name,
+		Tool:      call.Name,
+		Arguments: call.Arguments,
+		ExpiresAt: start.Add(wait),
+	}
+	if h.mcp != nil {
+		req.Server = h.mcp.GetServerForTool(call.Name)
+	}
+	decisions := h.approvals.add(req.ID)
+	defer h.approvals.remove(req.ID)
+
+	h.logger.Info("tool awaiting approval",
+		"trace_id", traceID,
+		"tool", call.Name,
+		"approval_id", req.ID,
+	)
+	h.emitEvent(ToolEvent{
+		Time:       time.Now(),
+		Status:     ToolAwaitingApproval,
+		ID:         call.ID,
+		Name:       call.Name,
+		Args:       string(call.Arguments),
+		ApprovalID: req.ID,
+	})
+
+	if topic := h.cfg.Agent.Tools.Approval.Topic; topic != "" {
+		go h.requestApproval(waitCtx, topic, req)
+	}
+
+	select {
+	case d := <-decisions:
+		return d
+	case <-waitCtx.Done():
+		if ctx.Err() != nil {
+			waited := time.Since(start).Round(time.Millisecond)
+			return ApprovalDecision{Reason: fmt.Sprintf("message ended after waiting %s for a decision: %v", waited, ctx.Err())}
+		}
+		return ApprovalDecision{Reason: fmt.Sprintf("no decision within %s", wait.Round(time.Millisecond))}
+	}
+}
+
+// requestApproval sends an approval request to topic and delivers the reply.
+func (h *MessageHandler) requestApproval(ctx context.Context, topic string, req ApprovalRequest) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		h.logger.Error("failed to marshal approval request", "trace_id", req.TraceID, "error", err)
+		return
+	}
+
+	reply, err := h.agent.Request(ctx, topic, data)
+	if err != nil {
+		if ctx.Err() == nil {
+			h.logger.Warn("approval request failed",
+				"trace_id", req.TraceID,
+				"topic", topic,
+				"error", err.Error(),
+			)
+		}
+		return
+	}
+
+	var d ApprovalDecision
+	if err := json.Unmarshal(reply, &d); err != nil {
+		h.logger.Warn("invalid approval reply",
+			"trace_id", req.TraceID,
+			"topic", topic,
+			"error", err.Error(),
+		)
+		return
+	}
+	// The TUI may have decided first
+	_ = h.approvals.decide(req.ID, d)
+}
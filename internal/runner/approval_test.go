@@ -0,0 +1,235 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// approvalAgent is a mockAgent that calls delete_record once and answers
+// approval requests with reply.
+type approvalAgent struct {
+	mockAgent
+	reply    func(req ApprovalRequest) ([]byte, error)
+	requests []ApprovalRequest
+	topic    string
+	result   string // Tool result sent back to the LLM
+}
+
+func newApprovalAgent(reply func(req ApprovalRequest) ([]byte, error)) *approvalAgent {
+	a := &approvalAgent{reply: reply}
+	a.completeFunc = func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+		last := req.Messages[len(req.Messages)-1]
+		if last.Role == "tool" {
+			a.result = last.Content
+			return &athyr.CompletionResponse{Content: "done"}, nil
+		}
+		return &athyr.CompletionResponse{
+			ToolCalls: []athyr.ToolCall{{ID: "call_1", Name: "delete_record", Arguments: json.RawMessage(`{"id": 42}`)}},
+		}, nil
+	}
+	return a
+}
+
+func (a *approvalAgent) Request(ctx context.Context, subject string, data []byte) ([]byte, error) {
+	var req ApprovalRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+	a.topic = subject
+	a.requests = append(a.requests, req)
+	return a.reply(req)
+}
+
+func approvalConfig(topic, timeout string) *config.Config {
+	cfg := toolConfig(0)
+	cfg.Agent.Tools.RequireApproval = []string{"delete_*"}
+	cfg.Agent.Tools.Approval = config.ApprovalConfig{Topic: topic, Timeout: timeout}
+	return cfg
+}
+
+// recordManager returns an MCP manager with delete_record and counts its calls.
+func recordManager(calls *int) *MCPManager {
+	mgr := NewMCPManager(nil)
+	mgr.RegisterTool("records", athyr.Tool{Name: "delete_record"})
+	mgr.SetToolExecutor(func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		*calls++
+		return "deleted", nil
+	})
+	return mgr
+}
+
+// toolEvents drains the tool events from bus.
+func toolEvents(bus EventBus) []ToolEvent {
+	var events []ToolEvent
+	for len(bus.Events()) > 0 {
+		if e, ok := (<-bus.Events()).(ToolEvent); ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestHandler_ToolApprovedViaTopic(t *testing.T) {
+	agent := newApprovalAgent(func(req ApprovalRequest) ([]byte, error) {
+		return []byte(`{"approved": true, "by": "ops"}`), nil
+	})
+	var calls int
+	bus := NewEventBus(20)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(approvalConfig("approvals", "1s"), agent, logger, recordManager(&calls), nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("delete record 42")})
+
+	if calls != 1 || agent.result != "deleted" {
+		t.Errorf("tool calls = %d, result = %q, want the tool executed", calls, agent.result)
+	}
+	if agent.topic != "approvals" || len(agent.requests) != 1 {
+		t.Fatalf("approval requests = %+v on %q, want one on approvals", agent.requests, agent.topic)
+	}
+	req := agent.requests[0]
+	if req.Tool != "delete_record" || req.Server != "records" || req.Agent != "test" || string(req.Arguments) != `{"id":42}` || req.ID == "" {
+		t.Errorf("approval request = %+v, want the call's details", req)
+	}
+
+	events := toolEvents(bus)
+	if len(events) != 3 {
+		t.Fatalf("got %d tool events, want 3", len(events))
+	}
+	if events[0].Status != ToolAwaitingApproval || events[0].ApprovalID != req.ID {
+		t.Errorf("first event = %+v, want awaiting approval %s", events[0], req.ID)
+	}
+	for _, e := range events[1:] {
+		if e.Decision != "approved by ops" {
+			t.Errorf("%v event Decision = %q, want %q", e.Status, e.Decision, "approved by ops")
+		}
+	}
+}
+
+func TestHandler_ToolDeniedViaTopic(t *testing.T) {
+	agent := newApprovalAgent(func(req ApprovalRequest) ([]byte, error) {
+		return []byte(`{"approved": false, "by": "ops", "reason": "record is still referenced"}`), nil
+	})
+	var calls int
+	bus := NewEventBus(20)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(approvalConfig("approvals", "1s"), agent, logger, recordManager(&calls), nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("delete record 42")})
+
+	if calls != 0 {
+		t.Errorf("tool executed %d times, want 0", calls)
+	}
+	if want := "tool call denied by ops: record is still referenced"; !strings.Contains(agent.result, want) {
+		t.Errorf("tool result = %q, want to contain %q", agent.result, want)
+	}
+	events := toolEvents(bus)
+	if last := events[len(events)-1]; last.Status != ToolDenied || last.Error == nil {
+		t.Errorf("last event = %+v, want denied with an error", last)
+	}
+}
+
+func TestHandler_ToolApprovalTimesOut(t *testing.T) {
+	agent := newApprovalAgent(nil)
+	var calls int
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(approvalConfig("", "20ms"), agent, logger, recordManager(&calls), nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("delete record 42")})
+
+	if calls != 0 {
+		t.Errorf("tool executed %d times, want 0", calls)
+	}
+	if want := "no decision within 20ms"; !strings.Contains(agent.result, want) {
+		t.Errorf("tool result = %q, want to contain %q", agent.result, want)
+	}
+}
+
+func TestHandler_ToolApprovalLeavesMessageBudget(t *testing.T) {
+	agent := newApprovalAgent(nil)
+	var calls int
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := approvalConfig("", "5s")
+	cfg.Agent.Processing.Timeout = "200ms"
+	handler := newMessageHandler(cfg, agent, logger, recordManager(&calls), nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("delete record 42")})
+
+	// The wait gives up before the message times out, so the LLM sees the denial
+	if calls != 0 {
+		t.Errorf("tool executed %d times, want 0", calls)
+	}
+	if !strings.Contains(agent.result, "no decision within") || strings.Contains(agent.result, "5s") {
+		t.Errorf("tool result = %q, want a denial after the capped wait", agent.result)
+	}
+	if len(agent.published) != 1 {
+		t.Errorf("published %d messages, want the response", len(agent.published))
+	}
+}
+
+func TestHandler_ToolApprovalMessageCancelled(t *testing.T) {
+	agent := newApprovalAgent(nil)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(approvalConfig("", "5s"), agent, logger, nil, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := handler.awaitApproval(ctx, "trace", athyr.ToolCall{ID: "call_1", Name: "delete_record"})
+	if d.Approved || !strings.Contains(d.Reason, "message ended") || !strings.Contains(d.Reason, "context canceled") {
+		t.Errorf("decision = %+v, want a denial for the cancelled message", d)
+	}
+}
+
+func TestHandler_DecideApproval(t *testing.T) {
+	agent := newApprovalAgent(nil)
+	var calls int
+	bus := NewEventBus(20)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(approvalConfig("", "5s"), agent, logger, recordManager(&calls), nil, bus)
+
+	done := make(chan struct{})
+	go func() {
+		handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("delete record 42")})
+		close(done)
+	}()
+
+	// Approve as the TUI would once the prompt appears
+	for e := range bus.Events() {
+		if e, ok := e.(ToolEvent); ok && e.Status == ToolAwaitingApproval {
+			if err := handler.DecideApproval(e.ApprovalID, ApprovalDecision{Approved: true, By: "tui"}); err != nil {
+				t.Fatalf("DecideApproval failed: %v", err)
+			}
+			if err := handler.DecideApproval(e.ApprovalID, ApprovalDecision{}); err == nil {
+				t.Error("second DecideApproval succeeded, want only the first decision to count")
+			}
+			break
+		}
+	}
+	<-done
+
+	if calls != 1 {
+		t.Errorf("tool executed %d times, want 1", calls)
+	}
+}
+
+func TestHandler_ToolsWithoutApprovalRunDirectly(t *testing.T) {
+	agent := newApprovalAgent(nil)
+	var calls int
+	cfg := approvalConfig("approvals", "1s")
+	cfg.Agent.Tools.RequireApproval = []string{"send_*"}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, recordManager(&calls), nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("delete record 42")})
+
+	if calls != 1 || len(agent.requests) != 0 {
+		t.Errorf("tool calls = %d, approval requests = %d, want 1 and 0", calls, len(agent.requests))
+	}
+}
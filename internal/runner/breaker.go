@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen is returned for a model whose circuit breaker is open.
+var errCircuitOpen = errors.New("circuit open")
+
+// circuitState is the state of a circuitBreaker.
+type circuitState int
+
+const (
+	circuitClosed   circuitState = iota // Requests flow normally
+	circuitOpen                         // Requests are rejected until the cooldown passes
+	circuitHalfOpen                     // One probe request is in flight
+)
+
+// circuitBreaker stops sending requests to a model after consecutive
+// failures. Once the cooldown has passed, a single probe request is let
+// through: success closes the circuit, failure opens it again.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time // Replaced in tests
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// newCircuitBreaker creates a closed circuit breaker.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a request may be sent. When the cooldown of an open
+// circuit has passed, the caller becomes the half-open probe.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		return false // Only one probe at a time
+	default:
+		return true
+	}
+}
+
+// success records a request the model answered. It reports whether this
+// closed a previously open circuit.
+func (b *circuitBreaker) success() (closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	closed = b.state != circuitClosed
+	b.state = circuitClosed
+	b.failures = 0
+	return closed
+}
+
+// failure records a failed request. It reports whether this opened the circuit.
+func (b *circuitBreaker) failure() (opened bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == circuitOpen || (b.state == circuitClosed && b.failures < b.threshold) {
+		return false
+	}
+	b.state = circuitOpen
+	b.openedAt = b.now()
+	return true
+}
+
+// release ends a request that neither succeeded nor failed (e.g. cancelled),
+// so a half-open circuit can send another probe.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+		b.openedAt = time.Time{} // Cooldown already passed; allow the next probe
+	}
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b := newCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if opened := b.failure(); opened {
+			t.Fatalf("failure %d opened the circuit, want it to stay closed below the threshold", i+1)
+		}
+		if !b.allow() {
+			t.Fatalf("allow() = false after %d failures, want true", i+1)
+		}
+	}
+	if opened := b.failure(); !opened {
+		t.Fatal("third failure did not open the circuit")
+	}
+	if b.allow() {
+		t.Error("allow() = true with an open circuit, want false")
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b := newCircuitBreaker(2, time.Minute)
+
+	b.failure()
+	b.success()
+	if opened := b.failure(); opened {
+		t.Error("failure after a success opened the circuit, want consecutive failures only")
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(1, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	if b.allow() {
+		t.Fatal("allow() = true during cooldown, want false")
+	}
+
+	// After the cooldown, exactly one probe is let through
+	now = now.Add(30 * time.Second)
+	if !b.allow() {
+		t.Fatal("allow() = false after cooldown, want a probe")
+	}
+	if b.allow() {
+		t.Fatal("allow() = true while a probe is in flight, want false")
+	}
+
+	// A failed probe opens the circuit for another cooldown
+	if opened := b.failure(); !opened {
+		t.Fatal("failed probe did not reopen the circuit")
+	}
+	if b.allow() {
+		t.Fatal("allow() = true right after a failed probe, want false")
+	}
+
+	// A successful probe closes it
+	now = now.Add(30 * time.Second)
+	if !b.allow() {
+		t.Fatal("allow() = false after second cooldown, want a probe")
+	}
+	if closed := b.success(); !closed {
+		t.Error("successful probe did not close the circuit")
+	}
+	if !b.allow() || !b.allow() {
+		t.Error("allow() = false with a closed circuit, want true")
+	}
+}
+
+func TestCircuitBreaker_ReleaseAllowsNextProbe(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(1, time.Second)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	now = now.Add(time.Second)
+	if !b.allow() {
+		t.Fatal("allow() = false after cooldown, want a probe")
+	}
+
+	// A cancelled probe says nothing about the model
+	b.release()
+	if !b.allow() {
+		t.Error("allow() = false after a released probe, want another probe")
+	}
+}
@@ -0,0 +1,200 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+	"github.com/google/uuid"
+)
+
+// chatState is the conversation held by the TUI Chat tab.
+type chatState struct {
+	mu      sync.Mutex // Held for a whole exchange, so turns stay in order
+	system  string     // Overrides agent.instructions when set
+	model   string     // Overrides the configured models when set
+	history []ChatTurn
+
+	// session is the conversation's memory session when memory is enabled.
+	// It is kept out of the session store, so topic messages can't use it.
+	session string
+}
+
+// ChatTurn is one message of a Chat tab conversation.
+type ChatTurn struct {
+	Role    string    `json:"role"` // "user" or "assistant"
+	Content string    `json:"content"`
+	Model   string    `json:"model,omitempty"` // Model that answered, for assistant turns
+	Time    time.Time `json:"time"`
+}
+
+// ChatTranscript is a Chat tab conversation as written by SaveChat.
+type ChatTranscript struct {
+	Agent   string     `json:"agent"`
+	System  string     `json:"system,omitempty"`
+	Model   string     `json:"model,omitempty"` // Model override, if any
+	Turns   []ChatTurn `json:"turns"`
+	SavedAt time.Time  `json:"saved_at"`
+}
+
+// DirectChat sends a message to the LLM as the next turn of the Chat tab
+// conversation and returns the response. It goes through the same
+// tool-calling loop as topic messages but doesn't route or publish.
+//
+// Earlier turns are replayed from the conversation, or come from a dedicated
+// memory session when memory is enabled. That session isn't tracked with
+// the topic sessions, so it isn't persisted or listed. The caller's exchange number is set
+// on the ChatChunkEvents of a streamed response.
+func (h *MessageHandler) DirectChat(exchange int, content string) (response string, model string, tokens int, err error) {
+	h.chat.mu.Lock()
+	defer h.chat.mu.Unlock()
+
+	traceID := uuid.New().String()[:8]
+	ctx, cancel := withBudget(context.Background(), h.processing.Timeout)
+	defer cancel()
+
+	// Build messages for completion
+	messages := []athyr.Message{}
+
+	// Add system instructions if configured or overridden with /system
+	if system := h.chatSystemLocked(); system != "" {
+		messages = append(messages, athyr.Message{
+			Role:    "system",
+			Content: system,
+		})
+	}
+
+	// Add earlier turns, from the memory session if there is one
+	var serverSessionID, memorySessionID string
+	if h.cfg.Agent.Memory.Enabled {
+		serverSessionID = h.chatSessionLocked(ctx, traceID)
+	}
+	switch {
+	case serverSessionID == "":
+		for _, t := range h.chat.history {
+			messages = append(messages, athyr.Message{Role: t.Role, Content: t.Content})
+		}
+	case h.memory != nil:
+		messages = append(messages, h.memory.history(serverSessionID)...)
+	default:
+		memorySessionID = serverSessionID
+	}
+
+	// Add the user message
+	userMessage := athyr.Message{
+		Role:    "user",
+		Content: content,
+	}
+	messages = append(messages, userMessage)
+
+	// Get available tools from MCP manager
+	var tools []athyr.Tool
+	if h.mcp != nil {
+		tools = h.mcp.GetAthyrTools()
+	}
+
+	t := turn{
+		traceID:   traceID,
+		messages:  messages,
+		tools:     tools,
+		sessionID: memorySessionID,
+		model:     h.chat.model,
+	}
+	if h.cfg.Agent.Streaming.Enabled {
+		t.emit = func(content string) { h.chatStream(exchange, content) }
+	}
+
+	resp, _, err := h.runTurn(ctx, t)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("completion failed: %w", err)
+	}
+
+	now := time.Now()
+	h.chat.history = append(h.chat.history,
+		ChatTurn{Role: "user", Content: content, Time: now},
+		ChatTurn{Role: "assistant", Content: resp.Content, Model: resp.Model, Time: now},
+	)
+	if h.memory != nil && serverSessionID != "" {
+		h.remember(ctx, traceID, serverSessionID, userMessage, athyr.Message{
+			Role:    "assistant",
+			Content: resp.Content,
+		})
+	}
+
+	return resp.Content, resp.Model, resp.Usage.TotalTokens, nil
+}
+
+// ResetChat starts the Chat tab conversation over. The memory session, if
+// any, is deleted; the next message starts a new one.
+func (h *MessageHandler) ResetChat() error {
+	h.chat.mu.Lock()
+	defer h.chat.mu.Unlock()
+
+	h.chat.history = nil
+	if h.chat.session == "" {
+		return nil
+	}
+
+	ctx, cancel := withBudget(context.Background(), h.processing.Timeout)
+	defer cancel()
+	h.deleteHistory(ctx, h.chat.session)
+	h.logger.Info("chat session reset", "server_session_id", h.chat.session)
+	h.chat.session = ""
+	return nil
+}
+
+// chatSessionLocked returns the Chat tab's memory session, creating it for
+// the first message. It returns "" if the session can't be created.
+func (h *MessageHandler) chatSessionLocked(ctx context.Context, traceID string) string {
+	if h.chat.session != "" {
+		return h.chat.session
+	}
+	serverID, err := h.createSession(ctx)
+	if err != nil {
+		h.logger.Error("failed to create chat session", "trace_id", traceID, "error", err)
+		return ""
+	}
+	h.logger.Info("chat session created", "trace_id", traceID, "server_session_id", serverID)
+	h.chat.session = serverID
+	return serverID
+}
+
+// SetChatSystemPrompt replaces agent.instructions for the Chat tab. An empty
+// prompt restores the configured instructions.
+func (h *MessageHandler) SetChatSystemPrompt(prompt string) {
+	h.chat.mu.Lock()
+	defer h.chat.mu.Unlock()
+	h.chat.system = prompt
+}
+
+// SetChatModel sends Chat tab messages to model instead of the configured
+// models, without fallback. An empty model restores the configured ones.
+func (h *MessageHandler) SetChatModel(model string) {
+	h.chat.mu.Lock()
+	defer h.chat.mu.Unlock()
+	h.chat.model = model
+}
+
+// SaveChat writes the Chat tab conversation to path as JSON.
+func (h *MessageHandler) SaveChat(path string) error {
+	h.chat.mu.Lock()
+	defer h.chat.mu.Unlock()
+
+	return writeFileAtomic(path, ChatTranscript{
+		Agent:   h.cfg.Agent.Name,
+		System:  h.chatSystemLocked(),
+		Model:   h.chat.model,
+		Turns:   append([]ChatTurn{}, h.chat.history...),
+		SavedAt: time.Now(),
+	})
+}
+
+// chatSystemLocked returns the Chat tab's system prompt.
+func (h *MessageHandler) chatSystemLocked() string {
+	if h.chat.system != "" {
+		return h.chat.system
+	}
+	return h.cfg.Agent.Instructions
+}
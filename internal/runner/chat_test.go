@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+func chatConfig() *config.Config {
+	return &config.Config{
+		Agent: config.AgentConfig{
+			Name:         "test",
+			Model:        "gpt-4",
+			Instructions: "You are helpful.",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+		},
+	}
+}
+
+func TestDirectChat_ReplaysHistory(t *testing.T) {
+	var requests []athyr.CompletionRequest
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			requests = append(requests, req)
+			return &athyr.CompletionResponse{Content: "answer"}, nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(chatConfig(), agent, logger, nil, nil, nil)
+
+	if _, _, _, err := handler.DirectChat(1, "first"); err != nil {
+		t.Fatalf("DirectChat failed: %v", err)
+	}
+	if _, _, _, err := handler.DirectChat(2, "second"); err != nil {
+		t.Fatalf("DirectChat failed: %v", err)
+	}
+
+	msgs := requests[1].Messages
+	want := []string{"system:You are helpful.", "user:first", "assistant:answer", "user:second"}
+	if len(msgs) != len(want) {
+		t.Fatalf("second request has %d messages, want %d", len(msgs), len(want))
+	}
+	for i, w := range want {
+		if got := msgs[i].Role + ":" + msgs[i].Content; got != w {
+			t.Errorf("message %d = %q, want %q", i, got, w)
+		}
+	}
+
+	if err := handler.ResetChat(); err != nil {
+		t.Fatalf("ResetChat failed: %v", err)
+	}
+	handler.DirectChat(3, "third")
+	if n := len(requests[2].Messages); n != 2 {
+		t.Errorf("after reset request has %d messages, want system and user", n)
+	}
+}
+
+func TestDirectChat_Overrides(t *testing.T) {
+	var last athyr.CompletionRequest
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			last = req
+			return &athyr.CompletionResponse{Content: "ok"}, nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(chatConfig(), agent, logger, nil, nil, nil)
+
+	handler.SetChatSystemPrompt("Be terse.")
+	handler.SetChatModel("llama3")
+	_, model, _, err := handler.DirectChat(1, "hi")
+	if err != nil {
+		t.Fatalf("DirectChat failed: %v", err)
+	}
+	if last.Model != "llama3" || model != "llama3" {
+		t.Errorf("model = %q (reported %q), want llama3", last.Model, model)
+	}
+	if last.Messages[0].Content != "Be terse." {
+		t.Errorf("system prompt = %q, want the override", last.Messages[0].Content)
+	}
+
+	handler.SetChatSystemPrompt("")
+	handler.SetChatModel("")
+	handler.DirectChat(2, "hi")
+	if last.Model != "gpt-4" || last.Messages[0].Content != "You are helpful." {
+		t.Errorf("after clearing overrides model = %q, system = %q", last.Model, last.Messages[0].Content)
+	}
+}
+
+func TestDirectChat_UsesMemorySession(t *testing.T) {
+	cfg := chatConfig()
+	cfg.Agent.Memory.Enabled = true
+
+	var deleted []string
+	created := 0
+	agent := &sessionAgent{
+		create: func() string {
+			created++
+			return []string{"srv-1", "srv-2"}[created-1]
+		},
+		delete: func(id string) { deleted = append(deleted, id) },
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	handler.DirectChat(1, "first")
+	handler.DirectChat(2, "second")
+	if created != 1 || agent.lastSessionID != "srv-1" {
+		t.Errorf("created %d sessions, last session %q, want one session srv-1", created, agent.lastSessionID)
+	}
+
+	if err := handler.ResetChat(); err != nil {
+		t.Fatalf("ResetChat failed: %v", err)
+	}
+	handler.DirectChat(3, "third")
+	if len(deleted) != 1 || deleted[0] != "srv-1" || agent.lastSessionID != "srv-2" {
+		t.Errorf("deleted = %v, last session %q, want srv-1 replaced by srv-2", deleted, agent.lastSessionID)
+	}
+}
+
+func TestDirectChat_SessionIsPrivate(t *testing.T) {
+	cfg := chatConfig()
+	cfg.Agent.Memory.Enabled = true
+
+	created := 0
+	agent := &sessionAgent{
+		create: func() string {
+			created++
+			return []string{"srv-1", "srv-2"}[created-1]
+		},
+		delete: func(id string) {},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	handler.DirectChat(1, "hi")
+
+	// The chat session isn't a topic session, whatever session_id is sent
+	if sessions := handler.sessions.list(); len(sessions) != 0 {
+		t.Errorf("sessions = %+v, want the chat session untracked", sessions)
+	}
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "tui-chat", "content": "hi"}`)})
+	if agent.lastSessionID != "srv-2" {
+		t.Errorf("topic message used session %q, want its own srv-2", agent.lastSessionID)
+	}
+}
+
+func TestSaveChat(t *testing.T) {
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			return &athyr.CompletionResponse{Content: "hello", Model: "gpt-4"}, nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(chatConfig(), agent, logger, nil, nil, nil)
+	handler.DirectChat(1, "hi")
+
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := handler.SaveChat(path); err != nil {
+		t.Fatalf("SaveChat failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var transcript ChatTranscript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		t.Fatalf("transcript is not JSON: %v", err)
+	}
+	if transcript.Agent != "test" || transcript.System != "You are helpful." || len(transcript.Turns) != 2 {
+		t.Errorf("transcript = %+v, want agent, system prompt and two turns", transcript)
+	}
+	if turn := transcript.Turns[1]; turn.Role != "assistant" || turn.Content != "hello" || turn.Model != "gpt-4" {
+		t.Errorf("assistant turn = %+v", turn)
+	}
+}
@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// Session control actions.
+const (
+	SessionControlList   = "list"   // All tracked sessions
+	SessionControlShow   = "show"   // One session
+	SessionControlDelete = "delete" // Forget a session and delete its history
+	SessionControlReset  = "reset"  // Keep a session but start its history over
+)
+
+// SessionControlActions lists the recognized session control actions.
+var SessionControlActions = []string{
+	SessionControlList,
+	SessionControlShow,
+	SessionControlDelete,
+	SessionControlReset,
+}
+
+// SessionControl is a message that manages memory sessions instead of being
+// sent to the LLM, e.g. {"session_id": "user-123", "action": "reset"}.
+type SessionControl struct {
+	SessionID string `json:"session_id,omitempty"` // Required except for list
+	Action    string `json:"action"`
+}
+
+// SessionControlReply answers a SessionControl request.
+type SessionControlReply struct {
+	Action   string          `json:"action"`
+	Sessions []SessionRecord `json:"sessions"`        // Affected sessions, as they were before delete or reset
+	Error    string          `json:"error,omitempty"` // Set if the action failed
+}
+
+// parseSessionControl reports whether data is a valid session control
+// message: a JSON object with a recognized action and no content.
+func parseSessionControl(data []byte) (SessionControl, bool) {
+	var msg struct {
+		SessionControl
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Content != "" {
+		return SessionControl{}, false
+	}
+	return msg.SessionControl, slices.Contains(SessionControlActions, msg.Action)
+}
+
+// handleSessionControl applies a message from memory.control_topic and
+// answers the reply subject, if any. The LLM is not involved.
+func (h *MessageHandler) handleSessionControl(ctx context.Context, traceID string, msg athyr.SubscribeMessage) {
+	var records []SessionRecord
+	var err error
+	ctl, ok := parseSessionControl(msg.Data)
+	if ok {
+		records, err = h.controlSession(ctx, ctl)
+	} else {
+		err = fmt.Errorf("not a session control message: action must be one of %s", strings.Join(SessionControlActions, ", "))
+	}
+
+	reply := SessionControlReply{Action: ctl.Action, Sessions: records}
+	if err != nil {
+		reply.Error = err.Error()
+		h.logger.Warn("session control failed",
+			"trace_id", traceID,
+			"action", ctl.Action,
+			"user_session_id", ctl.SessionID,
+			"error", err.Error(),
+		)
+	} else {
+		h.logger.Info("session control",
+			"trace_id", traceID,
+			"action", ctl.Action,
+			"user_session_id", ctl.SessionID,
+			"sessions", len(records),
+		)
+	}
+
+	if msg.Reply == "" {
+		return
+	}
+	data, err := json.Marshal(reply)
+	if err != nil {
+		h.logger.Error("failed to marshal session control reply", "trace_id", traceID, "error", err)
+		return
+	}
+	if err := h.publish(ctx, msg.Reply, data); err != nil {
+		h.logger.Error("reply failed",
+			"trace_id", traceID,
+			"reply", msg.Reply,
+			"error", err.Error(),
+		)
+	}
+}
+
+// controlSession performs a session control action and returns the sessions
+// it concerns.
+func (h *MessageHandler) controlSession(ctx context.Context, ctl SessionControl) ([]SessionRecord, error) {
+	if !h.cfg.Agent.Memory.Enabled {
+		return nil, errors.New("memory is not enabled for this agent")
+	}
+	if ctl.Action == SessionControlList {
+		return h.sessions.list(), nil
+	}
+	if ctl.SessionID == "" {
+		return nil, fmt.Errorf("%s requires a session_id", ctl.Action)
+	}
+
+	switch ctl.Action {
+	case SessionControlShow:
+		rec, ok := h.sessions.get(ctl.SessionID)
+		if !ok {
+			return nil, fmt.Errorf("unknown session %q", ctl.SessionID)
+		}
+		return []SessionRecord{rec}, nil
+
+	case SessionControlDelete:
+		rec, ok := h.sessions.remove(ctl.SessionID)
+		if !ok {
+			return nil, fmt.Errorf("unknown session %q", ctl.SessionID)
+		}
+		h.dropSession(ctx, SessionDeleted, rec)
+		return []SessionRecord{rec}, nil
+
+	default: // SessionControlReset
+		if _, ok := h.sessions.get(ctl.SessionID); !ok {
+			return nil, fmt.Errorf("unknown session %q", ctl.SessionID)
+		}
+		serverID, err := h.createSession(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("create session: %w", err)
+		}
+		rec, ok := h.sessions.reset(ctl.SessionID, serverID)
+		if !ok {
+			// Expired or evicted meanwhile; the next message starts fresh anyway
+			h.deleteHistory(ctx, serverID)
+			return nil, fmt.Errorf("unknown session %q", ctl.SessionID)
+		}
+		h.logger.Info("session reset",
+			"user_session_id", rec.UserSessionID,
+			"old_server_session_id", rec.ServerSessionID,
+			"server_session_id", serverID,
+		)
+		h.dropSession(ctx, SessionReset, rec)
+		return []SessionRecord{rec}, nil
+	}
+}
+
+// sessionControlTimeout bounds a session control request from the CLI.
+const sessionControlTimeout = 10 * time.Second
+
+// RequestSessionControl sends a session control message to a running agent
+// on topic and returns its reply.
+func RequestSessionControl(ctx context.Context, agent athyr.Agent, topic string, ctl SessionControl) (*SessionControlReply, error) {
+	data, err := json.Marshal(ctl)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sessionControlTimeout)
+	defer cancel()
+
+	resp, err := agent.Request(ctx, topic, data)
+	if err != nil {
+		return nil, err
+	}
+	var reply SessionControlReply
+	if err := json.Unmarshal(resp, &reply); err != nil || reply.Action == "" {
+		return nil, fmt.Errorf("unexpected reply from %s: %s", topic, resp)
+	}
+	return &reply, nil
+}
@@ -0,0 +1,174 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+func TestParseSessionControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   SessionControl
+		wantOK bool
+	}{
+		{"reset", `{"session_id": "u1", "action": "reset"}`, SessionControl{SessionID: "u1", Action: "reset"}, true},
+		{"list", `{"action": "list"}`, SessionControl{Action: "list"}, true},
+		{"unknown action", `{"session_id": "u1", "action": "buy"}`, SessionControl{}, false},
+		{"with content", `{"session_id": "u1", "action": "reset", "content": "hi"}`, SessionControl{}, false},
+		{"regular message", `{"session_id": "u1", "content": "hi"}`, SessionControl{}, false},
+		{"plain text", `reset`, SessionControl{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseSessionControl([]byte(tt.data))
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("parseSessionControl(%s) = %+v, %v, want %+v, %v", tt.data, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// controlHandler returns a handler with memory enabled whose server sessions
+// are named srv-1, srv-2, ... and records deleted server sessions.
+func controlHandler(t *testing.T, deleted *[]string) (*MessageHandler, *sessionAgent) {
+	t.Helper()
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Memory: config.MemoryConfig{Enabled: true, ControlTopic: "sessions"},
+		},
+	}
+
+	created := 0
+	agent := &sessionAgent{
+		create: func() string {
+			created++
+			return []string{"srv-1", "srv-2", "srv-3"}[created-1]
+		},
+		delete: func(id string) { *deleted = append(*deleted, id) },
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	return newMessageHandler(cfg, agent, logger, nil, nil, nil), agent
+}
+
+// sendControl sends a session control message with a reply subject and
+// returns the decoded reply.
+func sendControl(t *testing.T, h *MessageHandler, agent *sessionAgent, data string) SessionControlReply {
+	t.Helper()
+	agent.published = nil
+	h.Handle(athyr.SubscribeMessage{Subject: "sessions", Reply: "reply", Data: []byte(data)})
+
+	if len(agent.published) != 1 || agent.published[0].Subject != "reply" {
+		t.Fatalf("published = %+v, want one reply", agent.published)
+	}
+	var reply SessionControlReply
+	if err := json.Unmarshal(agent.published[0].Data, &reply); err != nil {
+		t.Fatalf("reply is not JSON: %v", err)
+	}
+	return reply
+}
+
+func TestHandler_SessionControl(t *testing.T) {
+	var deleted []string
+	handler, agent := controlHandler(t, &deleted)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "content": "hi"}`)})
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "content": "again"}`)})
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u2", "content": "hi"}`)})
+
+	reply := sendControl(t, handler, agent, `{"action": "list"}`)
+	if reply.Error != "" || len(reply.Sessions) != 2 {
+		t.Fatalf("list = %+v, want two sessions", reply)
+	}
+
+	reply = sendControl(t, handler, agent, `{"session_id": "u1", "action": "show"}`)
+	if len(reply.Sessions) != 1 || reply.Sessions[0].ServerSessionID != "srv-1" || reply.Sessions[0].Turns != 2 {
+		t.Errorf("show = %+v, want u1 on srv-1 with 2 turns", reply)
+	}
+
+	reply = sendControl(t, handler, agent, `{"session_id": "u1", "action": "reset"}`)
+	if reply.Error != "" || len(deleted) != 1 || deleted[0] != "srv-1" {
+		t.Errorf("reset = %+v, deleted = %v, want srv-1 deleted", reply, deleted)
+	}
+	if rec, _ := handler.sessions.get("u1"); rec.ServerSessionID != "srv-3" || rec.Turns != 0 {
+		t.Errorf("after reset u1 = %+v, want srv-3 with 0 turns", rec)
+	}
+
+	reply = sendControl(t, handler, agent, `{"session_id": "u2", "action": "delete"}`)
+	if reply.Error != "" || len(deleted) != 2 || deleted[1] != "srv-2" {
+		t.Errorf("delete = %+v, deleted = %v, want srv-2 deleted", reply, deleted)
+	}
+	if _, ok := handler.sessions.get("u2"); ok {
+		t.Error("u2 still tracked after delete")
+	}
+
+	reply = sendControl(t, handler, agent, `{"session_id": "u2", "action": "show"}`)
+	if reply.Error != `unknown session "u2"` {
+		t.Errorf("Error = %q, want unknown session", reply.Error)
+	}
+}
+
+func TestHandler_SessionControlRequiresMemory(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Memory: config.MemoryConfig{ControlTopic: "sessions"},
+		},
+	}
+	completed := false
+	agent := &mockAgent{
+		completeFunc: func(_ context.Context, _ athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			completed = true
+			return &athyr.CompletionResponse{Content: "ok"}, nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "sessions", Reply: "reply", Data: []byte(`{"action": "list"}`)})
+
+	if completed {
+		t.Error("session control message was sent to the LLM")
+	}
+	var reply SessionControlReply
+	if len(agent.published) != 1 || json.Unmarshal(agent.published[0].Data, &reply) != nil || reply.Error == "" {
+		t.Errorf("published = %+v, want an error reply", agent.published)
+	}
+}
+
+func TestHandler_SessionControlOnlyOnControlTopic(t *testing.T) {
+	var deleted []string
+	handler, agent := controlHandler(t, &deleted)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "content": "hi"}`)})
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "action": "delete"}`)})
+
+	// On a data topic it is just another message for the LLM
+	if _, ok := handler.sessions.get("u1"); !ok || len(deleted) != 0 || len(agent.published) != 2 {
+		t.Errorf("deleted = %v, published = %+v, want the control message answered as a regular message", deleted, agent.published)
+	}
+
+	reply := sendControl(t, handler, agent, `{"session_id": "u1", "content": "hi"}`)
+	if reply.Error == "" {
+		t.Errorf("reply = %+v, want a regular message rejected on the control topic", reply)
+	}
+}
@@ -0,0 +1,271 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// handleFunc processes a single message.
+type handleFunc func(ctx context.Context, msg athyr.SubscribeMessage)
+
+// dispatcher runs message handlers on a bounded worker pool.
+//
+// Messages that share a session ID, including session control messages for
+// that session, are processed one at a time in arrival order, while different
+// sessions (and messages without a session) run in parallel. When the queue
+// is full, submit blocks, which pauses consumption from the subscription
+// until a worker frees up.
+type dispatcher struct {
+	handle   handleFunc
+	logger   *slog.Logger
+	eventBus EventBus
+	workers  int
+	capacity int
+
+	slots   chan struct{} // one per queued or running message
+	ready   chan *lane    // lanes with a message waiting for a worker
+	stopped chan struct{} // closed by stop; workers exit once ready is empty
+
+	mu     sync.Mutex
+	lanes  map[string]*lane // session ID → lane, while it has work
+	queued int
+	active int
+	full   bool // queue is full; logged once per episode
+	closed bool // no longer accepting messages
+
+	wg sync.WaitGroup
+}
+
+// lane holds the pending messages for one session key.
+// At most one worker processes a lane at a time.
+type lane struct {
+	key     string
+	pending []athyr.SubscribeMessage
+}
+
+// newDispatcher creates a dispatcher with the given number of workers and
+// queue capacity for messages waiting on a worker.
+func newDispatcher(workers, queueSize int, handle handleFunc, logger *slog.Logger, eventBus EventBus) *dispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	// Slots cover waiting and running messages. The number of lanes never
+	// exceeds the number of slots, so sends on ready never block.
+	capacity := queueSize + workers
+	return &dispatcher{
+		handle:   handle,
+		logger:   logger,
+		eventBus: eventBus,
+		workers:  workers,
+		capacity: queueSize,
+		slots:    make(chan struct{}, capacity),
+		ready:    make(chan *lane, capacity),
+		stopped:  make(chan struct{}),
+		lanes:    make(map[string]*lane),
+	}
+}
+
+// start launches the workers. They exit when ctx is cancelled, or once the
+// queue is empty after stop.
+func (d *dispatcher) start(ctx context.Context) {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.work(ctx)
+	}
+}
+
+// wait blocks until all workers have stopped.
+func (d *dispatcher) wait() {
+	d.wg.Wait()
+}
+
+// submit queues a message for processing, blocking while the queue is full.
+// It returns false if ctx ends before the message could be queued or the
+// dispatcher has been stopped.
+func (d *dispatcher) submit(ctx context.Context, msg athyr.SubscribeMessage) bool {
+	select {
+	case d.slots <- struct{}{}:
+	case <-d.stopped:
+		return false
+	default:
+		d.mu.Lock()
+		if !d.full {
+			d.full = true
+			d.logger.Warn("message queue full, pausing consumption",
+				"queued", d.queued,
+				"capacity", d.capacity,
+			)
+		}
+		d.mu.Unlock()
+
+		select {
+		case d.slots <- struct{}{}:
+		case <-d.stopped:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	sessionID := laneKey(msg.Data)
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		<-d.slots
+		return false
+	}
+	l, busy := d.lanes[sessionID]
+	if sessionID == "" || !busy {
+		l = &lane{key: sessionID}
+		if sessionID != "" {
+			d.lanes[sessionID] = l
+		}
+	}
+	l.pending = append(l.pending, msg)
+	d.queued++
+
+	// A busy lane is already queued or held by a worker, which picks up the message in order.
+	// Enqueue under the lock so stop never misses an accepted message.
+	if sessionID == "" || !busy {
+		d.ready <- l
+	}
+	d.mu.Unlock()
+
+	d.emitDepth()
+	return true
+}
+
+// laneKey returns the session a message belongs to: its session_id, or for a
+// session control message the session it acts on, so that a reset or delete
+// waits for that session's messages ahead of it.
+func laneKey(data []byte) string {
+	if ctl, ok := parseSessionControl(data); ok {
+		return ctl.SessionID
+	}
+	sessionID, _ := parseMessage(data)
+	return sessionID
+}
+
+// work processes one message at a time from ready lanes.
+func (d *dispatcher) work(ctx context.Context) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case l := <-d.ready:
+			d.process(ctx, l)
+		case <-d.stopped:
+			select {
+			case l := <-d.ready:
+				d.process(ctx, l)
+			default:
+				return
+			}
+		}
+	}
+}
+
+// process handles the next message in a lane.
+func (d *dispatcher) process(ctx context.Context, l *lane) {
+	d.mu.Lock()
+	msg := l.pending[0]
+	l.pending = l.pending[1:]
+	d.queued--
+	d.active++
+	d.mu.Unlock()
+	d.emitDepth()
+
+	d.handle(ctx, msg)
+
+	d.mu.Lock()
+	d.active--
+	more := len(l.pending) > 0
+	if !more && l.key != "" {
+		delete(d.lanes, l.key)
+	}
+	d.mu.Unlock()
+
+	<-d.slots
+	d.mu.Lock()
+	if d.full {
+		d.full = false
+		d.logger.Info("message queue has capacity, resuming consumption")
+	}
+	d.mu.Unlock()
+
+	// Requeue behind other sessions so a busy session can't starve them
+	if more {
+		d.ready <- l
+	}
+	d.emitDepth()
+}
+
+// stop stops accepting messages. Workers finish the queued messages and exit.
+func (d *dispatcher) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		close(d.stopped)
+	}
+}
+
+// drain stops accepting messages and waits up to gracePeriod for queued and
+// in-flight messages to finish. It returns the number of messages still
+// pending when the grace period ran out.
+func (d *dispatcher) drain(gracePeriod time.Duration) int {
+	d.stop()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return 0
+	case <-timer.C:
+		queued, active := d.depth()
+		return queued + active
+	}
+}
+
+// depth returns the number of queued and running messages.
+func (d *dispatcher) depth() (queued, active int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.queued, d.active
+}
+
+// emitDepth sends the current queue depth to the EventBus if one is configured.
+func (d *dispatcher) emitDepth() {
+	if d.eventBus == nil {
+		return
+	}
+	queued, active := d.depth()
+	d.eventBus.Send(QueueEvent{
+		Time:     time.Now(),
+		Queued:   queued,
+		Active:   active,
+		Capacity: d.capacity,
+		Workers:  d.workers,
+	})
+}
@@ -0,0 +1,297 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+func sessionMessage(sessionID, content string) athyr.SubscribeMessage {
+	return athyr.SubscribeMessage{
+		Subject: "input",
+		Data:    []byte(fmt.Sprintf(`{"session_id": %q, "content": %q}`, sessionID, content)),
+	}
+}
+
+func TestDispatcher_OrdersMessagesPerSession(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[string][]string)
+	var wg sync.WaitGroup
+
+	d := newDispatcher(4, 100, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		defer wg.Done()
+		sessionID, content := parseMessage(msg.Data)
+		time.Sleep(time.Millisecond) // Give other workers a chance to overtake
+		mu.Lock()
+		got[sessionID] = append(got[sessionID], content)
+		mu.Unlock()
+	}, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.start(ctx)
+
+	const perSession = 20
+	sessions := []string{"a", "b", "c"}
+	wg.Add(perSession * len(sessions))
+	for i := 0; i < perSession; i++ {
+		for _, s := range sessions {
+			if !d.submit(ctx, sessionMessage(s, fmt.Sprint(i))) {
+				t.Fatal("submit() = false, want true")
+			}
+		}
+	}
+	wg.Wait()
+
+	for _, s := range sessions {
+		if len(got[s]) != perSession {
+			t.Fatalf("session %s processed %d messages, want %d", s, len(got[s]), perSession)
+		}
+		for i, content := range got[s] {
+			if content != fmt.Sprint(i) {
+				t.Errorf("session %s message %d = %s, want %d (order %v)", s, i, content, i, got[s])
+				break
+			}
+		}
+	}
+}
+
+func TestDispatcher_RunsSessionsInParallel(t *testing.T) {
+	started := make(chan string, 2)
+	release := make(chan struct{})
+
+	d := newDispatcher(2, 10, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		sessionID, _ := parseMessage(msg.Data)
+		started <- sessionID
+		<-release
+	}, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.start(ctx)
+
+	d.submit(ctx, sessionMessage("a", "1"))
+	d.submit(ctx, sessionMessage("b", "1"))
+
+	// Both sessions must be in flight at the same time
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("sessions did not run in parallel")
+		}
+	}
+	close(release)
+}
+
+func TestDispatcher_SessionControlWaitsForSession(t *testing.T) {
+	started := make(chan string, 3)
+	release := make(chan struct{})
+
+	d := newDispatcher(4, 10, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		if ctl, ok := parseSessionControl(msg.Data); ok {
+			started <- ctl.Action
+			return
+		}
+		started <- "message"
+		<-release
+	}, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.start(ctx)
+
+	d.submit(ctx, sessionMessage("a", "1"))
+	if got := <-started; got != "message" {
+		t.Fatalf("first handled = %s, want the message", got)
+	}
+	d.submit(ctx, athyr.SubscribeMessage{Subject: "control", Data: []byte(`{"session_id": "a", "action": "reset"}`)})
+	d.submit(ctx, athyr.SubscribeMessage{Subject: "control", Data: []byte(`{"action": "list"}`)})
+
+	// A list isn't tied to a session, but the reset waits for session a
+	if got := <-started; got != SessionControlList {
+		t.Fatalf("second handled = %s, want list", got)
+	}
+	select {
+	case got := <-started:
+		t.Fatalf("%s handled while session a's message was in flight", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case got := <-started:
+		if got != SessionControlReset {
+			t.Errorf("third handled = %s, want reset", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reset never ran")
+	}
+}
+
+func TestDispatcher_LimitsConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	var wg sync.WaitGroup
+
+	d := newDispatcher(2, 100, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		defer wg.Done()
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+	}, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.start(ctx)
+
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		d.submit(ctx, athyr.SubscribeMessage{Subject: "input", Data: []byte("plain text")})
+	}
+	wg.Wait()
+
+	if got := maxRunning.Load(); got != 2 {
+		t.Errorf("max concurrent handlers = %d, want 2", got)
+	}
+}
+
+func TestDispatcher_BlocksWhenQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	d := newDispatcher(1, 1, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		<-release
+	}, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.start(ctx)
+
+	// One message running, one waiting: the queue is full
+	if !d.submit(ctx, sessionMessage("a", "1")) || !d.submit(ctx, sessionMessage("b", "1")) {
+		t.Fatal("submit() = false, want true while queue has capacity")
+	}
+
+	submitCtx, submitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer submitCancel()
+	if d.submit(submitCtx, sessionMessage("c", "1")) {
+		t.Error("submit() = true, want it to block until ctx ends while queue is full")
+	}
+
+	// Freeing a worker lets consumption resume
+	close(release)
+	resumeCtx, resumeCancel := context.WithTimeout(ctx, time.Second)
+	defer resumeCancel()
+	if !d.submit(resumeCtx, sessionMessage("c", "1")) {
+		t.Error("submit() = false, want true once a worker is free")
+	}
+}
+
+func TestDispatcher_EmitsQueueDepth(t *testing.T) {
+	release := make(chan struct{})
+	bus := NewEventBus(100)
+	d := newDispatcher(1, 5, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		<-release
+	}, nil, bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.start(ctx)
+
+	for i := 0; i < 3; i++ {
+		d.submit(ctx, sessionMessage("a", fmt.Sprint(i)))
+	}
+
+	// Wait for the first message to be picked up
+	deadline := time.After(time.Second)
+	for {
+		if _, active := d.depth(); active == 1 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("message was not picked up by a worker")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	var last QueueEvent
+	for len(bus.Events()) > 0 {
+		if e, ok := (<-bus.Events()).(QueueEvent); ok {
+			last = e
+		}
+	}
+	if last.Queued != 2 || last.Active != 1 {
+		t.Errorf("last QueueEvent = %+v, want 2 queued and 1 active", last)
+	}
+	if last.Capacity != 5 || last.Workers != 1 {
+		t.Errorf("last QueueEvent = %+v, want capacity 5 and 1 worker", last)
+	}
+	close(release)
+}
+
+func TestDispatcher_DrainFinishesQueuedMessages(t *testing.T) {
+	var processed atomic.Int32
+	d := newDispatcher(1, 10, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		time.Sleep(10 * time.Millisecond)
+		processed.Add(1)
+	}, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.start(ctx)
+
+	for i := 0; i < 3; i++ {
+		d.submit(ctx, sessionMessage("a", fmt.Sprint(i)))
+	}
+
+	if abandoned := d.drain(time.Second); abandoned != 0 {
+		t.Errorf("drain() abandoned %d messages, want 0", abandoned)
+	}
+	if got := processed.Load(); got != 3 {
+		t.Errorf("processed %d messages, want 3", got)
+	}
+	if d.submit(ctx, sessionMessage("a", "late")) {
+		t.Error("submit() = true after drain, want false")
+	}
+}
+
+func TestDispatcher_DrainReportsAbandoned(t *testing.T) {
+	d := newDispatcher(1, 10, func(ctx context.Context, msg athyr.SubscribeMessage) {
+		<-ctx.Done()
+	}, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.start(ctx)
+
+	for i := 0; i < 3; i++ {
+		d.submit(ctx, sessionMessage("a", fmt.Sprint(i)))
+	}
+
+	// One message in flight and two queued when the grace period runs out
+	if abandoned := d.drain(50 * time.Millisecond); abandoned != 3 {
+		t.Errorf("drain() abandoned %d messages, want 3", abandoned)
+	}
+
+	// Cancelling the work context releases the workers
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		d.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("workers did not stop after cancellation")
+	}
+}
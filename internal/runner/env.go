@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+)
+
+// serverEnv builds the environment of an MCP server subprocess: the variables
+// of environ that inherit_env lets through, then those of env_file, then env.
+// Later values override earlier ones.
+func serverEnv(srv config.MCPServerConfig, environ []string) ([]string, error) {
+	// An empty, non-nil slice keeps the subprocess from inheriting everything
+	env := []string{}
+	for _, kv := range environ {
+		if key, _, _ := strings.Cut(kv, "="); srv.InheritsEnv(key) {
+			env = append(env, kv)
+		}
+	}
+
+	if srv.EnvFile != "" {
+		vars, err := readEnvFile(srv.EnvFile)
+		if err != nil {
+			return nil, fmt.Errorf("env_file: %w", err)
+		}
+		env = append(env, vars...)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(srv.Env)) {
+		env = append(env, key+"="+srv.Env[key])
+	}
+	return dedupEnv(env), nil
+}
+
+// dedupEnv keeps the last value of each variable, in order of first appearance.
+func dedupEnv(env []string) []string {
+	index := make(map[string]int, len(env))
+	out := env[:0:0]
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if i, ok := index[key]; ok {
+			out[i] = kv
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, kv)
+	}
+	return out
+}
+
+// readEnvFile reads KEY=VALUE lines from a file. Blank lines and lines
+// starting with # are skipped, an "export " prefix is allowed, and values may
+// be quoted: escapes are interpreted in double quotes, not in single quotes.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var vars []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 {
+			switch {
+			case value[0] == '"' && value[len(value)-1] == '"':
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: invalid quoted value for %s", path, n, key)
+				}
+				value = unquoted
+			case value[0] == '\'' && value[len(value)-1] == '\'':
+				value = value[1 : len(value)-1]
+			}
+		}
+		vars = append(vars, key+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
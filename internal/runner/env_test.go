@@ -0,0 +1,233 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// stubServerVar makes the test binary run as a stub MCP server over stdio
+// instead of running the tests. Its value selects the behavior: "env" offers
+// an env tool reporting the server's environment and working directory, and
+// "hang" never answers.
+const stubServerVar = "ATHYR_TEST_MCP_STUB"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(stubServerVar); mode != "" {
+		runStubServer(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// stubEnv is the env tool's result.
+type stubEnv struct {
+	Env []string `json:"env"`
+	Cwd string   `json:"cwd"`
+}
+
+func runStubServer(mode string) {
+	if mode == "hang" {
+		// Exit once the client gives up and closes stdin
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		return
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "stub", Version: "1.0.0"}, nil)
+	server.AddTool(&mcp.Tool{Name: "env", InputSchema: map[string]any{"type": "object"}},
+		func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			cwd, _ := os.Getwd()
+			data, _ := json.Marshal(stubEnv{Env: os.Environ(), Cwd: cwd})
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(data)}}}, nil
+		})
+	_ = server.Run(context.Background(), &mcp.StdioTransport{})
+}
+
+// stubServer configures a server that runs the test binary as a stub MCP server.
+func stubServer(t *testing.T, mode string) config.MCPServerConfig {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("os.Executable failed: %v", err)
+	}
+	return config.MCPServerConfig{
+		Name:    "stub",
+		Command: []string{exe},
+		Env:     map[string]string{stubServerVar: mode},
+	}
+}
+
+// stubServerEnv starts the stub server and returns what its env tool reports.
+func stubServerEnv(t *testing.T, srv config.MCPServerConfig) stubEnv {
+	t.Helper()
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+	if err := mgr.Start(context.Background(), []config.MCPServerConfig{srv}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	result, err := mgr.CallTool(context.Background(), "env", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	var env stubEnv
+	if err := json.Unmarshal([]byte(result.Text), &env); err != nil {
+		t.Fatalf("invalid env result %q: %v", result.Text, err)
+	}
+	return env
+}
+
+func TestMCPManager_StubServerInheritsEnv(t *testing.T) {
+	t.Setenv("ATHYR_TEST_INHERITED", "yes")
+
+	env := stubServerEnv(t, stubServer(t, "env"))
+
+	// Setting env doesn't wipe the rest of the environment
+	if !slices.Contains(env.Env, "ATHYR_TEST_INHERITED=yes") || !slices.Contains(env.Env, "PATH="+os.Getenv("PATH")) {
+		t.Errorf("server env = %v, want the agent's environment", env.Env)
+	}
+}
+
+func TestMCPManager_StubServerEnvironment(t *testing.T) {
+	t.Setenv("ATHYR_TEST_INHERITED", "yes")
+	t.Setenv("LC_ATHYR_TEST", "C")
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "server.env")
+	if err := os.WriteFile(envFile, []byte("# credentials\nAPI_TOKEN=from-file\nexport REGION='eu west'\nLEVEL=file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := stubServer(t, "env")
+	srv.InheritEnv = config.InheritEnvAllowlist
+	srv.EnvAllowlist = []string{"LC_*"}
+	srv.EnvFile = envFile
+	srv.Env["LEVEL"] = "env"
+	srv.Cwd = dir
+
+	env := stubServerEnv(t, srv)
+
+	want := []string{"API_TOKEN=from-file", "LC_ATHYR_TEST=C", "LEVEL=env", "REGION=eu west", stubServerVar + "=env"}
+	slices.Sort(want)
+	slices.Sort(env.Env)
+	if !slices.Equal(env.Env, want) {
+		t.Errorf("server env = %v, want %v", env.Env, want)
+	}
+	if resolved, _ := filepath.EvalSymlinks(dir); env.Cwd != dir && env.Cwd != resolved {
+		t.Errorf("server cwd = %q, want %q", env.Cwd, dir)
+	}
+}
+
+func TestMCPManager_StubServerEnvFileMissing(t *testing.T) {
+	srv := stubServer(t, "env")
+	srv.EnvFile = filepath.Join(t.TempDir(), "missing.env")
+
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+	err := mgr.Start(context.Background(), []config.MCPServerConfig{srv})
+	if err == nil || !strings.Contains(err.Error(), "env_file") {
+		t.Errorf("Start() error = %v, want an env_file error", err)
+	}
+}
+
+func TestMCPManager_StartTimeout(t *testing.T) {
+	srv := stubServer(t, "hang")
+	srv.StartTimeout = "100ms"
+
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+	err := mgr.Start(context.Background(), []config.MCPServerConfig{srv})
+	if err == nil || !strings.Contains(err.Error(), "not started within 100ms") {
+		t.Errorf("Start() error = %v, want a start timeout", err)
+	}
+}
+
+func TestServerEnv(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "HOME=/home/agent", "SECRET=hunter2"}
+	tests := []struct {
+		name string
+		srv  config.MCPServerConfig
+		want []string
+	}{
+		{
+			name: "all",
+			srv:  config.MCPServerConfig{Env: map[string]string{"HOME": "/srv", "DEBUG": "1"}},
+			want: []string{"PATH=/usr/bin", "HOME=/srv", "SECRET=hunter2", "DEBUG=1"},
+		},
+		{
+			name: "none",
+			srv:  config.MCPServerConfig{InheritEnv: config.InheritEnvNone, Env: map[string]string{"DEBUG": "1"}},
+			want: []string{"DEBUG=1"},
+		},
+		{
+			name: "allowlist",
+			srv:  config.MCPServerConfig{InheritEnv: config.InheritEnvAllowlist, EnvAllowlist: []string{"PATH", "HOME"}},
+			want: []string{"PATH=/usr/bin", "HOME=/home/agent"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := serverEnv(tt.srv, environ)
+		if err != nil {
+			t.Fatalf("%s: serverEnv failed: %v", tt.name, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: serverEnv() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Nothing inherited still means an empty environment, not the agent's
+	got, err := serverEnv(config.MCPServerConfig{InheritEnv: config.InheritEnvNone}, environ)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("serverEnv() = %#v, %v, want an empty non-nil environment", got, err)
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `
+# comment
+PLAIN=value
+  SPACED = padded value
+export EXPORTED=1
+DOUBLE="line\nbreak"
+SINGLE='not\nescaped'
+EMPTY=
+URL=http://example.com/?a=b
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readEnvFile(path)
+	if err != nil {
+		t.Fatalf("readEnvFile failed: %v", err)
+	}
+	want := []string{
+		"PLAIN=value",
+		"SPACED=padded value",
+		"EXPORTED=1",
+		"DOUBLE=line\nbreak",
+		`SINGLE=not\nescaped`,
+		"EMPTY=",
+		"URL=http://example.com/?a=b",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("readEnvFile() = %q, want %q", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("OK=1\nnot a variable\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEnvFile(path); err == nil || !strings.Contains(err.Error(), ":2: expected KEY=VALUE") {
+		t.Errorf("readEnvFile() error = %v, want the bad line reported", err)
+	}
+}
@@ -10,6 +10,9 @@ const (
 	EventTypeMessage
 	EventTypeTool
 	EventTypeLog
+	EventTypeQueue
+	EventTypeSession
+	EventTypeChatChunk
 )
 
 // Event is the base interface for all events emitted by the runner.
@@ -51,6 +54,109 @@ type MessageEvent struct {
 func (e MessageEvent) Type() EventType      { return EventTypeMessage }
 func (e MessageEvent) Timestamp() time.Time { return e.Time }
 
+// MessageOutcome indicates how processing of an incoming message ended.
+type MessageOutcome int
+
+const (
+	OutcomeCompleted MessageOutcome = iota
+	OutcomeFailed
+	OutcomeTimedOut
+	OutcomeCancelled
+)
+
+// String returns the outcome as used in logs.
+func (o MessageOutcome) String() string {
+	switch o {
+	case OutcomeCompleted:
+		return "completed"
+	case OutcomeFailed:
+		return "failed"
+	case OutcomeTimedOut:
+		return "timed_out"
+	case OutcomeCancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// MessageProcessedEvent is emitted when handling of an incoming message ends.
+type MessageProcessedEvent struct {
+	Time     time.Time
+	TraceID  string
+	Topic    string
+	Outcome  MessageOutcome
+	Phase    string // Phase that failed ("llm", "tool", "publish"), empty on success
+	Error    error
+	Duration time.Duration
+}
+
+func (e MessageProcessedEvent) Type() EventType      { return EventTypeMessage }
+func (e MessageProcessedEvent) Timestamp() time.Time { return e.Time }
+
+// QueueEvent reports the depth of the message processing queue.
+type QueueEvent struct {
+	Time     time.Time
+	Queued   int // Messages waiting for a worker
+	Active   int // Messages being processed
+	Capacity int // Maximum queued messages before consumption pauses
+	Workers  int // Maximum concurrent messages
+}
+
+func (e QueueEvent) Type() EventType      { return EventTypeQueue }
+func (e QueueEvent) Timestamp() time.Time { return e.Time }
+
+// SessionAction indicates what happened to a memory session.
+type SessionAction int
+
+const (
+	SessionCreated SessionAction = iota
+	SessionExpired               // Idle longer than memory.ttl
+	SessionEvicted               // Least recently used beyond memory.max_sessions
+	SessionDeleted               // Removed by a session_control message
+	SessionReset                 // History cleared by a session_control message
+)
+
+// String returns the action as used in logs.
+func (a SessionAction) String() string {
+	switch a {
+	case SessionCreated:
+		return "created"
+	case SessionExpired:
+		return "expired"
+	case SessionEvicted:
+		return "evicted"
+	case SessionDeleted:
+		return "deleted"
+	case SessionReset:
+		return "reset"
+	default:
+		return "unknown"
+	}
+}
+
+// SessionEvent reports a memory session being created or removed.
+type SessionEvent struct {
+	Time            time.Time
+	Action          SessionAction
+	UserSessionID   string
+	ServerSessionID string
+	Active          int // Sessions tracked after the change
+}
+
+func (e SessionEvent) Type() EventType      { return EventTypeSession }
+func (e SessionEvent) Timestamp() time.Time { return e.Time }
+
+// ChatChunkEvent carries a piece of a streamed TUI Chat tab response.
+type ChatChunkEvent struct {
+	Time     time.Time
+	Exchange int // As passed to DirectChat
+	Content  string
+}
+
+func (e ChatChunkEvent) Type() EventType      { return EventTypeChatChunk }
+func (e ChatChunkEvent) Timestamp() time.Time { return e.Time }
+
 // ToolStatus indicates the state of a tool execution.
 type ToolStatus int
 
@@ -58,17 +164,24 @@ const (
 	ToolStarted ToolStatus = iota
 	ToolCompleted
 	ToolFailed
+	ToolAwaitingApproval // Listed in tools.require_approval and waiting for a decision
+	ToolDenied           // Not approved; the LLM is told the call was denied
 )
 
 // ToolEvent represents a tool execution.
 type ToolEvent struct {
-	Time     time.Time
-	Status   ToolStatus
-	Name     string
-	Args     string
-	Result   string
-	Error    error
-	Duration time.Duration
+	Time       time.Time
+	Status     ToolStatus
+	ID         string // Tool call ID, shared by the events of one call
+	Name       string
+	Args       string
+	Result     string
+	Content    []ToolContent // Blocks of Result, by content type
+	Error      error
+	Duration   time.Duration
+	ApprovalID string // Set with ToolAwaitingApproval; pass to DecideApproval
+	Decision   string // Approval decision, for tools that need one
+	Shortened  string // "truncated" or "summarized" when the LLM got less than Result
 }
 
 func (e ToolEvent) Type() EventType      { return EventTypeTool }
@@ -76,20 +189,36 @@ func (e ToolEvent) Timestamp() time.Time { return e.Time }
 
 // ToolInfo describes an available tool.
 type ToolInfo struct {
-	Name        string
+	Name        string // Name exposed to the LLM
+	Original    string // Name on the MCP server
 	Description string
 	Server      string // MCP server name
 }
 
 // ToolsAvailableEvent is emitted when MCP tools are discovered.
 type ToolsAvailableEvent struct {
-	Time  time.Time
-	Tools []ToolInfo
+	Time             time.Time
+	Tools            []ToolInfo
+	Resources        []ResourceInfo
+	InstructionsFrom string // "server/prompt" when the instructions come from an MCP prompt
 }
 
 func (e ToolsAvailableEvent) Type() EventType      { return EventTypeTool }
 func (e ToolsAvailableEvent) Timestamp() time.Time { return e.Time }
 
+// MCPServerEvent is emitted when a supervised MCP server goes up or down,
+// or is being restarted.
+type MCPServerEvent struct {
+	Time     time.Time
+	Server   string
+	Status   MCPServerStatus
+	Restarts int   // Restart attempts since the agent started
+	Error    error // Why the server went down
+}
+
+func (e MCPServerEvent) Type() EventType      { return EventTypeTool }
+func (e MCPServerEvent) Timestamp() time.Time { return e.Time }
+
 // LogLevel mirrors slog levels for the TUI.
 type LogLevel int
 
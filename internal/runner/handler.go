@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -15,20 +16,26 @@ import (
 	"github.com/google/uuid"
 )
 
-const maxToolIterations = 10
-
 // WatchCallback is called when a message is received on a watched topic.
 type WatchCallback func(timestamp time.Time, content string)
 
 // MessageHandler processes incoming messages through the LLM.
 type MessageHandler struct {
-	cfg      *config.Config
-	agent    athyr.Agent
-	logger   *slog.Logger
-	mcp      *MCPManager
-	plugins  *plugin.Manager
-	eventBus EventBus
-	sessions map[string]string // user session ID -> server session ID
+	cfg        *config.Config
+	agent      athyr.Agent
+	logger     *slog.Logger
+	mcp        *MCPManager
+	plugins    *plugin.Manager
+	eventBus   EventBus
+	processing config.ProcessingOptions
+	errorOpts  config.ErrorOptions
+	models     []string                   // primary model, then fallbacks
+	breakers   map[string]*circuitBreaker // model → circuit breaker
+	memory     *localMemory               // nil unless memory.backend is local
+
+	sessions  *sessionStore // user session ID -> server session ID
+	chat      *chatState    // TUI Chat tab conversation
+	approvals *approvalGate // Tool calls waiting for approval
 
 	// Watch subscription state
 	watchSub   athyr.Subscription
@@ -36,14 +43,38 @@ type MessageHandler struct {
 }
 
 func newMessageHandler(cfg *config.Config, agent athyr.Agent, logger *slog.Logger, mcp *MCPManager, plugins *plugin.Manager, eventBus EventBus) *MessageHandler {
+	// Invalid durations are rejected by Validate and Runner.Run; fall back to defaults here
+	processing, _ := cfg.Agent.Processing.GetOptions()
+	errorOpts, _ := cfg.Agent.Errors.GetOptions()
+
+	ttl, _ := cfg.Agent.Memory.GetTTL()
+
+	models := cfg.Agent.GetModels()
+	breakers := make(map[string]*circuitBreaker, len(models))
+	for _, model := range models {
+		breakers[model] = newCircuitBreaker(errorOpts.FailureThreshold, errorOpts.Cooldown)
+	}
+
+	var memory *localMemory
+	if cfg.Agent.Memory.GetBackend() == config.MemoryBackendLocal {
+		memory = newLocalMemory(cfg.Agent.Memory.GetProfile())
+	}
+
 	return &MessageHandler{
-		cfg:      cfg,
-		agent:    agent,
-		logger:   logger,
-		mcp:      mcp,
-		plugins:  plugins,
-		eventBus: eventBus,
-		sessions: make(map[string]string),
+		cfg:        cfg,
+		agent:      agent,
+		logger:     logger,
+		mcp:        mcp,
+		plugins:    plugins,
+		eventBus:   eventBus,
+		processing: processing,
+		errorOpts:  errorOpts,
+		models:     models,
+		breakers:   breakers,
+		memory:     memory,
+		sessions:   newSessionStore(ttl, cfg.Agent.Memory.GetMaxSessions(), logger),
+		chat:       &chatState{},
+		approvals:  newApprovalGate(),
 	}
 }
 
@@ -71,8 +102,55 @@ func parseMessage(data []byte) (sessionID, content string) {
 	return "", string(data)
 }
 
-// Handle processes a single incoming message.
+// withBudget derives a context limited to d, or a cancellable one if d is zero.
+func withBudget(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// contextError reports the context's error alongside err once ctx has ended.
+// Transports may wrap deadlines in their own error types, so this keeps
+// timeouts and cancellations recognizable with errors.Is.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && err != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
+
+// outcomeOf classifies the error that ended message processing.
+func outcomeOf(err error) MessageOutcome {
+	switch {
+	case err == nil:
+		return OutcomeCompleted
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimedOut
+	case errors.Is(err, context.Canceled):
+		return OutcomeCancelled
+	default:
+		return OutcomeFailed
+	}
+}
+
+// Handle processes a single incoming message with no parent context.
 func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
+	h.HandleContext(context.Background(), msg)
+}
+
+// HandleContext processes a single incoming message. The message is bounded by
+// processing.timeout, and cancelling ctx (e.g. on shutdown) aborts in-flight
+// LLM, tool and publish calls.
+func (h *MessageHandler) HandleContext(ctx context.Context, msg athyr.SubscribeMessage) {
+	h.handle(ctx, msg, nil)
+}
+
+// handle processes a message and returns the error that ended it, if any.
+// With a preview, it runs the same steps but records the would-be publishes
+// in the preview instead of sending them, leaves memory sessions alone, and
+// doesn't reply, dead-letter or report the message.
+func (h *MessageHandler) handle(ctx context.Context, msg athyr.SubscribeMessage, preview *PipelinePreview) error {
 	// Generate trace_id for correlating all logs for this request
 	traceID := uuid.New().String()[:8] // Short ID for readability
 	startTime := time.Now()
@@ -81,25 +159,44 @@ func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
 		"trace_id", traceID,
 		"topic", msg.Subject,
 		"size_bytes", len(msg.Data),
+		"preview", preview != nil,
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := withBudget(ctx, h.processing.Timeout)
 	defer cancel()
 
+	fail := func(phase string, err error) error {
+		if preview == nil {
+			h.fail(ctx, traceID, msg, phase, err, startTime)
+		}
+		return err
+	}
+
+	// Messages on memory.control_topic manage sessions and never reach the LLM
+	if topic := h.cfg.Agent.Memory.ControlTopic; topic != "" && msg.Subject == topic {
+		if preview != nil {
+			return errors.New("session control messages can't be previewed")
+		}
+		h.handleSessionControl(ctx, traceID, msg)
+		return nil
+	}
+
 	// Parse message to extract session ID and content
 	userSessionID, content := parseMessage(msg.Data)
 
 	// Emit incoming message event
-	h.emitEvent(MessageEvent{
-		Time:      time.Now(),
-		Direction: MessageIncoming,
-		Topic:     msg.Subject,
-		Content:   content,
-	})
+	if preview == nil {
+		h.emitEvent(MessageEvent{
+			Time:      time.Now(),
+			Direction: MessageIncoming,
+			Topic:     msg.Subject,
+			Content:   content,
+		})
+	}
 
 	// Resolve session ID - create session if needed and get server-side ID
 	var serverSessionID string
-	if h.cfg.Agent.Memory.Enabled && userSessionID != "" {
+	if h.cfg.Agent.Memory.Enabled && userSessionID != "" && preview == nil {
 		serverSessionID = h.ensureSession(ctx, userSessionID)
 	}
 
@@ -121,11 +218,17 @@ func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
 		})
 	}
 
+	// Add conversation history kept by the local memory backend
+	if h.memory != nil && serverSessionID != "" {
+		messages = append(messages, h.memory.history(serverSessionID)...)
+	}
+
 	// Add the incoming message as user content
-	messages = append(messages, athyr.Message{
+	userMessage := athyr.Message{
 		Role:    "user",
 		Content: content,
-	})
+	}
+	messages = append(messages, userMessage)
 
 	// Get available tools from MCP manager
 	var tools []athyr.Tool
@@ -137,28 +240,199 @@ func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
 		)
 	}
 
-	// Tool-calling loop
-	var resp *athyr.CompletionResponse
-	for i := 0; i < maxToolIterations; i++ {
-		// Create completion request
-		req := athyr.CompletionRequest{
-			Model:    h.cfg.Agent.Model,
-			Messages: messages,
-			Tools:    tools,
-			Config: athyr.CompletionConfig{
-				Temperature: 0.7,
-				MaxTokens:   2048,
-			},
+	// Add session context if server memory is enabled and session ID is provided
+	var memorySessionID string
+	if h.cfg.Agent.Memory.Enabled && h.memory == nil && serverSessionID != "" {
+		memorySessionID = serverSessionID
+		h.logger.Info("using session memory", "user_session_id", userSessionID, "server_session_id", serverSessionID)
+	}
+
+	// Stream the response as it is generated, if enabled
+	var stream *responseStream
+	if preview == nil {
+		stream = h.newResponseStream(ctx, traceID, userSessionID, msg)
+	}
+	t := turn{
+		traceID:   traceID,
+		messages:  messages,
+		tools:     tools,
+		sessionID: memorySessionID,
+		preview:   preview,
+	}
+	if stream != nil {
+		t.emit = stream.send
+	}
+
+	resp, phase, err := h.runTurn(ctx, t)
+
+	// Check for dynamic routing in LLM response
+	var routeTo string
+	if err == nil {
+		routeTo = extractRouteFrom(resp.Content)
+		if preview != nil {
+			preview.Route = routeTo
+			preview.RouteValid = routeTo != "" && h.cfg.Agent.Topics.IsValidRoute(routeTo)
+		}
+		if routeTo != "" && h.cfg.Agent.Topics.IsValidRoute(routeTo) {
+			h.logger.Debug("routing response",
+				"trace_id", traceID,
+				"route_to", routeTo,
+			)
+		} else if routeTo != "" {
+			h.logger.Warn("invalid route_to, using default publish",
+				"trace_id", traceID,
+				"route_to", routeTo,
+			)
+			routeTo = "" // Reset to use default
+		}
+	}
+
+	// Determine target topics
+	var targetTopics []string
+	if routeTo != "" {
+		// Dynamic routing - publish to specified route only
+		targetTopics = []string{routeTo}
+	} else {
+		// Default - publish to all configured output topics
+		targetTopics = h.cfg.Agent.Topics.Publish
+	}
+
+	// The stream ends on the streams of the topics the response goes to
+	if stream != nil {
+		stream.done(targetTopics, resp, err)
+	}
+	if err != nil {
+		return fail(phase, err)
+	}
+
+	// Publish response to configured output topics
+	response := Response{
+		Content:      resp.Content,
+		Model:        resp.Model,
+		SourceTopic:  msg.Subject,
+		Tokens:       resp.Usage.TotalTokens,
+		FinishReason: resp.FinishReason,
+	}
+
+	responseData, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error("failed to marshal response", "error", err)
+		return fail("publish", err)
+	}
+	if preview != nil {
+		preview.Response = response
+	}
+
+	var publishErr error
+	for _, topic := range targetTopics {
+		isPlugin := h.plugins != nil && h.plugins.IsPlugin(topic)
+		if preview != nil {
+			data := string(responseData)
+			if isPlugin {
+				data = resp.Content
+			}
+			preview.Publications = append(preview.Publications, Publication{Topic: topic, Plugin: isPlugin, Data: data})
+			continue
+		}
+
+		var pubErr error
+		if isPlugin {
+			// Plugin destination: publish via plugin manager
+			pubErr = h.plugins.Publish(topic, resp.Content)
+		} else {
+			// Athyr topic: publish via SDK agent
+			pubErr = h.publish(ctx, topic, responseData)
+		}
+
+		if pubErr != nil {
+			publishErr = fmt.Errorf("publish to %s: %w", topic, pubErr)
+			h.logger.Error("message send failed",
+				"trace_id", traceID,
+				"topic", topic,
+				"error", pubErr.Error(),
+			)
+		} else {
+			h.logger.Info("message sent",
+				"trace_id", traceID,
+				"topic", topic,
+				"size_bytes", len(responseData),
+			)
+			// Emit outgoing message event
+			h.emitEvent(MessageEvent{
+				Time:      time.Now(),
+				Direction: MessageOutgoing,
+				Topic:     topic,
+				Content:   resp.Content,
+				Model:     resp.Model,
+				Tokens:    resp.Usage.TotalTokens,
+			})
 		}
-		if len(tools) > 0 {
-			req.ToolChoice = "auto"
+	}
+
+	// If there's a reply subject (request/reply pattern), respond directly
+	if msg.Reply != "" && preview == nil {
+		if err := h.publish(ctx, msg.Reply, responseData); err != nil {
+			publishErr = fmt.Errorf("reply: %w", err)
+			h.logger.Error("reply failed",
+				"trace_id", traceID,
+				"reply", msg.Reply,
+				"error", err.Error(),
+			)
 		}
+	}
 
-		// Add session context if memory is enabled and session ID is provided
-		if h.cfg.Agent.Memory.Enabled && serverSessionID != "" {
-			req.SessionID = serverSessionID
+	if preview != nil {
+		return nil
+	}
+
+	phase = ""
+	if publishErr != nil {
+		phase = "publish"
+	} else if h.memory != nil && serverSessionID != "" {
+		// Record the exchange for the next message in this session
+		h.remember(ctx, traceID, serverSessionID, userMessage, athyr.Message{
+			Role:    "assistant",
+			Content: resp.Content,
+		})
+	}
+	h.finish(ctx, traceID, msg, phase, publishErr, startTime)
+	return publishErr
+}
+
+// turn is one run of the tool-calling loop.
+type turn struct {
+	traceID   string
+	messages  []athyr.Message
+	tools     []athyr.Tool
+	sessionID string               // Server session whose memory is included, if any
+	model     string               // Overrides the configured models (without fallback), if set
+	emit      func(content string) // Receives the response content as it streams, if set
+	preview   *PipelinePreview     // Records tool calls instead of executing them, if set
+}
+
+// runTurn runs the tool-calling loop: it completes the messages, executes the
+// requested tools and feeds their results back until the LLM answers without
+// tool calls. When tools.max_iterations is reached or the LLM repeats a call,
+// it asks for a final answer without tools and reports why in FinishReason.
+// On failure it returns the phase that failed ("llm" or "tool").
+func (h *MessageHandler) runTurn(ctx context.Context, t turn) (*athyr.CompletionResponse, string, error) {
+	messages := t.messages
+	traceID := t.traceID
+	maxIterations := h.cfg.Agent.Tools.GetMaxIterations()
+	seen := make(map[string]bool) // Tool calls made so far, by toolCallKey
+	forced := ""                  // Finish reason once tools are withdrawn
+
+	var resp *athyr.CompletionResponse
+	for i := 0; ; i++ {
+		// Create completion request
+		tools := t.tools
+		if forced != "" {
+			tools = nil
+		}
+		req := h.newCompletionRequest(messages, tools)
+		if t.sessionID != "" {
+			req.SessionID = t.sessionID
 			req.IncludeMemory = true
-			h.logger.Info("using session memory", "user_session_id", userSessionID, "server_session_id", serverSessionID)
 		}
 
 		// Execute LLM completion
@@ -170,7 +444,14 @@ func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
 		)
 
 		var err error
-		resp, err = h.agent.Complete(ctx, req)
+		if t.model != "" {
+			req.Model = t.model
+			if resp, err = h.completeWith(ctx, req, t.emit); err == nil && resp.Model == "" {
+				resp.Model = t.model
+			}
+		} else {
+			resp, err = h.complete(ctx, req, t.emit)
+		}
 		llmLatency := time.Since(llmStart)
 
 		if err != nil {
@@ -180,7 +461,7 @@ func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
 				"model", req.Model,
 				"latency_ms", llmLatency.Milliseconds(),
 			)
-			return
+			return nil, "llm", err
 		}
 
 		h.logger.Info("llm completed",
@@ -191,11 +472,38 @@ func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
 			"latency_ms", llmLatency.Milliseconds(),
 		)
 
+		// The forced answer is final, even if it asks for tools again
+		if forced != "" {
+			resp.ToolCalls = nil
+			resp.FinishReason = forced
+			break
+		}
+
 		// If no tool calls, we're done
 		if len(resp.ToolCalls) == 0 {
 			break
 		}
 
+		// Withdraw the tools once the limit is reached or the LLM goes in circles
+		switch {
+		case i >= maxIterations:
+			forced = FinishReasonMaxIterations
+		case repeatsToolCall(seen, resp.ToolCalls):
+			forced = FinishReasonToolLoop
+		}
+		if forced != "" {
+			h.logger.Warn("tool loop stopped",
+				"trace_id", traceID,
+				"reason", forced,
+				"iterations", i,
+			)
+			messages = append(messages, athyr.Message{
+				Role:    "user",
+				Content: finalAnswerPrompt,
+			})
+			continue
+		}
+
 		h.logger.Debug("executing tool calls",
 			"trace_id", traceID,
 			"count", len(resp.ToolCalls),
@@ -207,322 +515,396 @@ func (h *MessageHandler) Handle(msg athyr.SubscribeMessage) {
 			ToolCalls: resp.ToolCalls,
 		})
 
-		// Execute each tool call
-		for _, call := range resp.ToolCalls {
-			// Emit tool started event
-			toolStart := time.Now()
-			argsStr := string(call.Arguments)
-			h.emitEvent(ToolEvent{
-				Time:   toolStart,
-				Status: ToolStarted,
-				Name:   call.Name,
-				Args:   argsStr,
-			})
-
-			result, err := h.executeToolCall(ctx, call)
-			toolDuration := time.Since(toolStart)
-
-			if err != nil {
-				h.logger.Error("tool failed",
-					"trace_id", traceID,
-					"tool", call.Name,
-					"error", err.Error(),
-					"latency_ms", toolDuration.Milliseconds(),
-				)
-				h.emitEvent(ToolEvent{
-					Time:     time.Now(),
-					Status:   ToolFailed,
-					Name:     call.Name,
-					Args:     argsStr,
-					Error:    err,
-					Duration: toolDuration,
-				})
-				result = fmt.Sprintf(`{"error": "%s"}`, err.Error())
-			} else {
-				h.logger.Info("tool executed",
-					"trace_id", traceID,
-					"tool", call.Name,
-					"server", h.mcp.GetServerForTool(call.Name),
-					"latency_ms", toolDuration.Milliseconds(),
-					"success", true,
-				)
-				h.emitEvent(ToolEvent{
-					Time:     time.Now(),
-					Status:   ToolCompleted,
-					Name:     call.Name,
-					Args:     argsStr,
-					Result:   result,
-					Duration: toolDuration,
-				})
-			}
+		// Execute the tool calls and add their results in call order
+		var results []string
+		if t.preview != nil {
+			results = t.preview.recordToolCalls(h.cfg.Agent.Tools, resp.ToolCalls)
+		} else {
+			results = h.executeToolCalls(ctx, traceID, resp.ToolCalls)
+		}
 
-			// Add tool result to messages
+		// Stop if the message ran out of time or was cancelled during the calls
+		if ctx.Err() != nil {
+			return nil, "tool", ctx.Err()
+		}
+		for j, call := range resp.ToolCalls {
 			messages = append(messages, athyr.Message{
 				Role:       "tool",
 				ToolCallID: call.ID,
-				Content:    result,
+				Content:    results[j],
 			})
 		}
 	}
 
 	if resp == nil {
-		h.logger.Error("no response after tool loop",
-			"trace_id", traceID,
-			"topic", msg.Subject,
-		)
-		return
+		h.logger.Error("no response after tool loop", "trace_id", traceID)
+		return nil, "llm", errors.New("no response after tool loop")
 	}
+	return resp, "", nil
+}
 
-	// Check for dynamic routing in LLM response
-	routeTo := extractRouteFrom(resp.Content)
-	if routeTo != "" && h.cfg.Agent.Topics.IsValidRoute(routeTo) {
-		h.logger.Debug("routing response",
-			"trace_id", traceID,
-			"route_to", routeTo,
-		)
-	} else if routeTo != "" {
-		h.logger.Warn("invalid route_to, using default publish",
+// fail answers the reply subject with an error response, if the message has
+// one, and then finishes the message with err.
+func (h *MessageHandler) fail(ctx context.Context, traceID string, msg athyr.SubscribeMessage, phase string, err error, startTime time.Time) {
+	if msg.Reply != "" {
+		h.replyError(ctx, traceID, msg.Reply, phase, err)
+	}
+	h.finish(ctx, traceID, msg, phase, err, startTime)
+}
+
+// afterMessageTimeout bounds publishes made once a message has ended, such as
+// error replies and dead letters, including their retries.
+const afterMessageTimeout = 10 * time.Second
+
+// afterMessage returns a context for publishing once the message's ctx may
+// have ended. It keeps ctx's values but not its deadline or cancellation,
+// and is bounded by afterMessageTimeout so it can't outlive a shutdown.
+func afterMessage(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), afterMessageTimeout)
+}
+
+// replyError sends an error Response to a request/reply caller. The message's
+// own budget may have expired, so it publishes with an afterMessage context.
+func (h *MessageHandler) replyError(ctx context.Context, traceID, reply, phase string, err error) {
+	data, marshalErr := json.Marshal(Response{Error: newResponseError(traceID, phase, err)})
+	if marshalErr != nil {
+		h.logger.Error("failed to marshal error reply", "trace_id", traceID, "error", marshalErr)
+		return
+	}
+	ctx, cancel := afterMessage(ctx)
+	defer cancel()
+	if pubErr := h.publish(ctx, reply, data); pubErr != nil {
+		h.logger.Error("error reply failed",
 			"trace_id", traceID,
-			"route_to", routeTo,
+			"reply", reply,
+			"error", pubErr.Error(),
 		)
-		routeTo = "" // Reset to use default
 	}
+}
 
-	// Publish response to configured output topics
-	response := Response{
-		Content:      resp.Content,
-		Model:        resp.Model,
-		SourceTopic:  msg.Subject,
-		Tokens:       resp.Usage.TotalTokens,
-		FinishReason: resp.FinishReason,
-	}
+// finish logs the outcome of processing a message and emits a MessageProcessedEvent.
+// phase names the step that failed and is ignored on success. Failed and timed
+// out messages are sent to the dead-letter destination.
+func (h *MessageHandler) finish(ctx context.Context, traceID string, msg athyr.SubscribeMessage, phase string, err error, startTime time.Time) {
+	outcome := outcomeOf(err)
+	duration := time.Since(startTime)
 
-	responseData, err := json.Marshal(response)
+	attrs := []any{
+		"trace_id", traceID,
+		"topic", msg.Subject,
+		"outcome", outcome.String(),
+		"total_ms", duration.Milliseconds(),
+	}
 	if err != nil {
-		h.logger.Error("failed to marshal response", "error", err)
-		return
+		attrs = append(attrs, "phase", phase, "error", err.Error())
+	} else {
+		phase = ""
 	}
 
-	// Determine target topics
-	var targetTopics []string
-	if routeTo != "" {
-		// Dynamic routing - publish to specified route only
-		targetTopics = []string{routeTo}
-	} else {
-		// Default - publish to all configured output topics
-		targetTopics = h.cfg.Agent.Topics.Publish
+	switch outcome {
+	case OutcomeCompleted:
+		h.logger.Debug("request completed", attrs...)
+	case OutcomeTimedOut:
+		h.logger.Warn("request timed out", attrs...)
+	case OutcomeCancelled:
+		h.logger.Warn("request cancelled", attrs...)
+	default:
+		h.logger.Error("request failed", attrs...)
 	}
 
-	for _, topic := range targetTopics {
-		var pubErr error
-		if h.plugins != nil && h.plugins.IsPlugin(topic) {
-			// Plugin destination: publish via plugin manager
-			pubErr = h.plugins.Publish(topic, resp.Content)
-		} else {
-			// Athyr topic: publish via SDK agent
-			pubErr = h.agent.Publish(ctx, topic, responseData)
+	if outcome == OutcomeFailed || outcome == OutcomeTimedOut {
+		h.deadLetter(ctx, traceID, msg, phase, err)
+	}
+
+	h.emitEvent(MessageProcessedEvent{
+		Time:     time.Now(),
+		TraceID:  traceID,
+		Topic:    msg.Subject,
+		Outcome:  outcome,
+		Phase:    phase,
+		Error:    err,
+		Duration: duration,
+	})
+}
+
+// complete executes an LLM completion, trying the primary model and then each
+// fallback model in order. Models whose circuit breaker is open are skipped.
+// The returned response's Model names the model that answered. A non-nil
+// emit streams the response content (see completeStream).
+func (h *MessageHandler) complete(ctx context.Context, req athyr.CompletionRequest, emit func(string)) (*athyr.CompletionResponse, error) {
+	var errs []error
+	for i, model := range h.models {
+		breaker := h.breakers[model]
+		if !breaker.allow() {
+			h.logger.Debug("skipping model with open circuit", "model", model)
+			errs = append(errs, fmt.Errorf("%s: %w", model, errCircuitOpen))
+			continue
 		}
 
-		if pubErr != nil {
-			h.logger.Error("message send failed",
-				"trace_id", traceID,
-				"topic", topic,
-				"error", pubErr.Error(),
-			)
-		} else {
-			h.logger.Info("message sent",
-				"trace_id", traceID,
-				"topic", topic,
-				"size_bytes", len(responseData),
-			)
-			// Emit outgoing message event
-			h.emitEvent(MessageEvent{
-				Time:      time.Now(),
-				Direction: MessageOutgoing,
-				Topic:     topic,
-				Content:   resp.Content,
-				Model:     resp.Model,
-				Tokens:    resp.Usage.TotalTokens,
-			})
+		req.Model = model
+		resp, err := h.completeWith(ctx, req, emit)
+		switch {
+		case err == nil:
+			if breaker.success() {
+				h.logger.Info("circuit closed", "model", model)
+			}
+			if resp.Model == "" {
+				resp.Model = model
+			}
+			return resp, nil
+		case errors.Is(err, context.Canceled), errors.Is(err, errStreamInterrupted):
+			breaker.release()
+			return nil, err
+		case classifyError(err) != "":
+			if breaker.failure() {
+				h.logger.Warn("circuit opened",
+					"model", model,
+					"cooldown", h.errorOpts.Cooldown.String(),
+				)
+			}
+		default:
+			// The model answered, so the route is healthy even though the request failed
+			breaker.success()
 		}
-	}
 
-	// If there's a reply subject (request/reply pattern), respond directly
-	if msg.Reply != "" {
-		if err := h.agent.Publish(ctx, msg.Reply, responseData); err != nil {
-			h.logger.Error("reply failed",
-				"trace_id", traceID,
-				"reply", msg.Reply,
+		// Without time left there's no point in trying the next model
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if len(h.models) == 1 {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", model, err))
+		if i < len(h.models)-1 {
+			h.logger.Warn("model failed, trying fallback",
+				"model", model,
+				"fallback", h.models[i+1],
 				"error", err.Error(),
 			)
 		}
 	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("all models failed: %w", errors.Join(errs...))
+}
 
-	// Log request completion with total duration
-	h.logger.Debug("request completed",
-		"trace_id", traceID,
-		"total_ms", time.Since(startTime).Milliseconds(),
-	)
+// completeWith executes an LLM completion with req.Model, allowing
+// processing.llm_timeout per attempt and retrying transient failures per the
+// errors config. A non-nil emit streams the response content.
+func (h *MessageHandler) completeWith(ctx context.Context, req athyr.CompletionRequest, emit func(string)) (*athyr.CompletionResponse, error) {
+	if emit != nil {
+		return h.completeStream(ctx, req, emit)
+	}
+
+	var resp *athyr.CompletionResponse
+	err := h.retry(ctx, "llm", func() error {
+		llmCtx, cancel := withBudget(ctx, h.processing.LLMTimeout)
+		defer cancel()
+
+		var err error
+		resp, err = h.agent.Complete(llmCtx, req)
+		return contextError(llmCtx, err)
+	})
+	return resp, err
+}
+
+// publish sends data to an Athyr topic, allowing processing.publish_timeout
+// per attempt and retrying transient failures per the errors config.
+func (h *MessageHandler) publish(ctx context.Context, topic string, data []byte) error {
+	return h.retry(ctx, "publish", func() error {
+		pubCtx, cancel := withBudget(ctx, h.processing.PublishTimeout)
+		defer cancel()
+
+		return contextError(pubCtx, h.agent.Publish(pubCtx, topic, data))
+	})
+}
+
+// newCompletionRequest builds a completion request using the agent's completion parameters.
+func (h *MessageHandler) newCompletionRequest(messages []athyr.Message, tools []athyr.Tool) athyr.CompletionRequest {
+	completion := h.cfg.Agent.GetCompletion()
+
+	req := athyr.CompletionRequest{
+		Model:    h.cfg.Agent.Model,
+		Messages: messages,
+		Tools:    tools,
+		Config: athyr.CompletionConfig{
+			Temperature: *completion.Temperature,
+			MaxTokens:   completion.MaxTokens,
+			Stop:        completion.Stop,
+			Seed:        completion.Seed,
+		},
+	}
+	if completion.TopP != nil {
+		req.Config.TopP = *completion.TopP
+	}
+	if len(tools) > 0 {
+		req.ToolChoice = completion.ToolChoice
+	}
+	return req
 }
 
-// executeToolCall executes a single tool call via the MCP manager.
-func (h *MessageHandler) executeToolCall(ctx context.Context, call athyr.ToolCall) (string, error) {
+// executeToolCall executes a single tool call via the MCP manager within the tool's timeout.
+func (h *MessageHandler) executeToolCall(ctx context.Context, call athyr.ToolCall) (*ToolResult, error) {
 	if h.mcp == nil {
-		return "", fmt.Errorf("no MCP manager configured")
+		return nil, fmt.Errorf("no MCP manager configured")
 	}
-	return h.mcp.CallTool(ctx, call.Name, call.Arguments)
+
+	timeout := h.toolTimeout(call.Name)
+	toolCtx, cancel := withBudget(ctx, timeout)
+	defer cancel()
+
+	result, err := h.mcp.CallTool(toolCtx, call.Name, call.Arguments)
+	if err != nil && ctx.Err() == nil && errors.Is(toolCtx.Err(), context.DeadlineExceeded) {
+		// Only this call ran out of time; the LLM can still react to the error
+		return nil, fmt.Errorf("tool %s timed out after %s: %w", call.Name, timeout, context.DeadlineExceeded)
+	}
+	return result, contextError(toolCtx, err)
 }
 
-// ensureSession creates a session if it doesn't exist and returns the server session ID.
+// ensureSession returns the server session for a user session, creating one
+// if it doesn't exist or has expired. Returns "" if no session could be created.
 func (h *MessageHandler) ensureSession(ctx context.Context, userSessionID string) string {
+	for _, rec := range h.sessions.expire() {
+		h.logger.Info("session expired",
+			"user_session_id", rec.UserSessionID,
+			"server_session_id", rec.ServerSessionID,
+			"idle", time.Since(rec.LastUsed).Round(time.Second).String(),
+		)
+		h.dropSession(ctx, SessionExpired, rec)
+	}
+
 	// Check if we already have a mapping
-	if serverID, ok := h.sessions[userSessionID]; ok {
+	if serverID, ok := h.sessions.touch(userSessionID); ok {
 		return serverID
 	}
 
-	// Create new session on the server
 	h.logger.Info("creating session", "user_session_id", userSessionID)
 
-	profile := h.cfg.Agent.Memory.GetProfile()
-	session, err := h.agent.CreateSession(ctx, athyr.SessionProfile{
-		Type:                   profile.Type,
-		MaxTokens:              profile.MaxTokens,
-		SummarizationThreshold: profile.SummarizationThreshold,
-	}, h.cfg.Agent.Instructions)
-
+	serverID, err := h.createSession(ctx)
 	if err != nil {
 		h.logger.Error("failed to create session", "user_session_id", userSessionID, "error", err)
 		return ""
 	}
 
 	// Store the mapping
-	h.sessions[userSessionID] = session.ID
-	h.logger.Info("session created", "user_session_id", userSessionID, "server_session_id", session.ID)
+	evicted := h.sessions.add(userSessionID, serverID)
+	h.logger.Info("session created", "user_session_id", userSessionID, "server_session_id", serverID)
+	h.emitEvent(SessionEvent{
+		Time:            time.Now(),
+		Action:          SessionCreated,
+		UserSessionID:   userSessionID,
+		ServerSessionID: serverID,
+		Active:          h.sessions.len(),
+	})
 
-	return session.ID
-}
+	for _, rec := range evicted {
+		h.logger.Info("session evicted",
+			"user_session_id", rec.UserSessionID,
+			"server_session_id", rec.ServerSessionID,
+			"max_sessions", h.cfg.Agent.Memory.GetMaxSessions(),
+		)
+		h.dropSession(ctx, SessionEvicted, rec)
+	}
 
-// DirectChat sends a message directly to the LLM and returns the response.
-// This is used by the TUI for interactive chat without going through pub/sub.
-func (h *MessageHandler) DirectChat(content string) (response string, model string, tokens int, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	return serverID
+}
 
-	// Build messages for completion
-	messages := []athyr.Message{}
+// createSession starts a new conversation and returns its ID: a server
+// session, or a local history for memory.backend: local.
+func (h *MessageHandler) createSession(ctx context.Context) (string, error) {
+	if h.memory != nil {
+		return "local-" + uuid.New().String()[:8], nil
+	}
 
-	// Add system instructions if configured
-	if h.cfg.Agent.Instructions != "" {
-		messages = append(messages, athyr.Message{
-			Role:    "system",
-			Content: h.cfg.Agent.Instructions,
-		})
+	profile := h.cfg.Agent.Memory.GetProfile()
+	session, err := h.agent.CreateSession(ctx, athyr.SessionProfile{
+		Type:                   profile.Type,
+		MaxTokens:              profile.MaxTokens,
+		SummarizationThreshold: profile.SummarizationThreshold,
+	}, h.cfg.Agent.Instructions)
+	if err != nil {
+		return "", err
 	}
+	return session.ID, nil
+}
 
-	// Add the user message
-	messages = append(messages, athyr.Message{
-		Role:    "user",
-		Content: content,
+// dropSession deletes a session that is no longer tracked, from local memory
+// or the server, and reports it. Server deletion is best effort; the server
+// may have expired the session already.
+func (h *MessageHandler) dropSession(ctx context.Context, action SessionAction, rec SessionRecord) {
+	h.deleteHistory(ctx, rec.ServerSessionID)
+	h.emitEvent(SessionEvent{
+		Time:            time.Now(),
+		Action:          action,
+		UserSessionID:   rec.UserSessionID,
+		ServerSessionID: rec.ServerSessionID,
+		Active:          h.sessions.len(),
 	})
+}
 
-	// Get available tools from MCP manager
-	var tools []athyr.Tool
-	if h.mcp != nil {
-		tools = h.mcp.GetAthyrTools()
+// deleteHistory deletes a session's conversation history, from local memory
+// or the server.
+func (h *MessageHandler) deleteHistory(ctx context.Context, serverSessionID string) {
+	if h.memory != nil {
+		h.memory.delete(serverSessionID)
+	} else if err := h.agent.DeleteSession(ctx, serverSessionID); err != nil {
+		h.logger.Debug("failed to delete server session",
+			"server_session_id", serverSessionID,
+			"error", err,
+		)
 	}
+}
 
-	// Tool-calling loop
-	var resp *athyr.CompletionResponse
-	for i := 0; i < maxToolIterations; i++ {
-		// Create completion request
-		req := athyr.CompletionRequest{
-			Model:    h.cfg.Agent.Model,
-			Messages: messages,
-			Tools:    tools,
-			Config: athyr.CompletionConfig{
-				Temperature: 0.7,
-				MaxTokens:   2048,
-			},
-		}
-		if len(tools) > 0 {
-			req.ToolChoice = "auto"
-		}
-
-		// Execute LLM completion
-		resp, err = h.agent.Complete(ctx, req)
-		if err != nil {
-			return "", "", 0, fmt.Errorf("completion failed: %w", err)
-		}
-
-		// If no tool calls, we're done
-		if len(resp.ToolCalls) == 0 {
-			break
-		}
-
-		// Add assistant's tool request to history
-		messages = append(messages, athyr.Message{
-			Role:      "assistant",
-			ToolCalls: resp.ToolCalls,
-		})
+// Response is the structure published to output topics.
+// Replies to failed requests carry only Error.
+type Response struct {
+	Content      string         `json:"content"`
+	Model        string         `json:"model"`
+	SourceTopic  string         `json:"source_topic"`
+	Tokens       int            `json:"tokens"`
+	FinishReason string         `json:"finish_reason"`
+	Error        *ResponseError `json:"error,omitempty"`
+}
 
-		// Execute each tool call
-		for _, call := range resp.ToolCalls {
-			toolStart := time.Now()
-			argsStr := string(call.Arguments)
-			h.emitEvent(ToolEvent{
-				Time:   toolStart,
-				Status: ToolStarted,
-				Name:   call.Name,
-				Args:   argsStr,
-			})
+// Error codes reported in ResponseError.Code.
+const (
+	ErrorCodeTimeout   = "timeout"   // processing.timeout ran out
+	ErrorCodeCancelled = "cancelled" // Agent shut down while processing
+	ErrorCodeLLM       = "llm_error" // LLM completion failed
+	ErrorCodeInternal  = "internal"  // Response could not be built
+)
 
-			result, execErr := h.executeToolCall(ctx, call)
-			toolDuration := time.Since(toolStart)
-
-			if execErr != nil {
-				h.emitEvent(ToolEvent{
-					Time:     time.Now(),
-					Status:   ToolFailed,
-					Name:     call.Name,
-					Args:     argsStr,
-					Error:    execErr,
-					Duration: toolDuration,
-				})
-				result = fmt.Sprintf(`{"error": "%s"}`, execErr.Error())
-			} else {
-				h.emitEvent(ToolEvent{
-					Time:     time.Now(),
-					Status:   ToolCompleted,
-					Name:     call.Name,
-					Args:     argsStr,
-					Result:   result,
-					Duration: toolDuration,
-				})
-			}
+// ResponseError describes why a request could not be answered.
+type ResponseError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	TraceID   string `json:"trace_id"`
+	Retryable bool   `json:"retryable"` // Sending the request again may succeed
+}
 
-			// Add tool result to messages
-			messages = append(messages, athyr.Message{
-				Role:       "tool",
-				ToolCallID: call.ID,
-				Content:    result,
-			})
-		}
+// newResponseError builds the error reply for a message that failed in phase.
+func newResponseError(traceID, phase string, err error) *ResponseError {
+	re := &ResponseError{
+		Message: err.Error(),
+		TraceID: traceID,
 	}
-
-	if resp == nil {
-		return "", "", 0, fmt.Errorf("no response from LLM")
+	switch outcomeOf(err) {
+	case OutcomeTimedOut:
+		re.Code = ErrorCodeTimeout
+		re.Retryable = true
+	case OutcomeCancelled:
+		// Another instance can pick up the request
+		re.Code = ErrorCodeCancelled
+		re.Retryable = true
+	default:
+		re.Code = ErrorCodeInternal
+		if phase == "llm" {
+			re.Code = ErrorCodeLLM
+		}
+		re.Retryable = classifyError(err) != ""
 	}
-
-	return resp.Content, resp.Model, resp.Usage.TotalTokens, nil
-}
-
-// Response is the structure published to output topics.
-type Response struct {
-	Content      string `json:"content"`
-	Model        string `json:"model"`
-	SourceTopic  string `json:"source_topic"`
-	Tokens       int    `json:"tokens"`
-	FinishReason string `json:"finish_reason"`
+	return re
 }
 
 // routeResponse is used to parse the route_to field from LLM JSON output.
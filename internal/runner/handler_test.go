@@ -3,9 +3,12 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/athyr-tech/athyr-agent/internal/config"
 
@@ -543,3 +546,458 @@ func TestHandler_InvalidRouteUsesDefault(t *testing.T) {
 		t.Errorf("Published to %v, want ticket.unknown (default)", publishedTopics[0])
 	}
 }
+
+func TestHandler_UsesCompletionConfig(t *testing.T) {
+	temperature := 0.0
+	seed := 7
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Completion: config.CompletionConfig{
+				Temperature: &temperature,
+				MaxTokens:   8192,
+				Stop:        []string{"END"},
+				Seed:        &seed,
+				ToolChoice:  config.ToolChoiceRequired,
+			},
+		},
+	}
+
+	var capturedReq athyr.CompletionRequest
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			capturedReq = req
+			return &athyr.CompletionResponse{Content: "done"}, nil
+		},
+	}
+
+	mcpMgr := NewMCPManager(nil)
+	mcpMgr.RegisterTool("test-server", athyr.Tool{Name: "test_tool"})
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, mcpMgr, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{
+		Subject: "input",
+		Data:    []byte("extract"),
+	})
+
+	if capturedReq.Config.Temperature != 0 {
+		t.Errorf("Config.Temperature = %v, want 0", capturedReq.Config.Temperature)
+	}
+	if capturedReq.Config.MaxTokens != 8192 {
+		t.Errorf("Config.MaxTokens = %v, want 8192", capturedReq.Config.MaxTokens)
+	}
+	if len(capturedReq.Config.Stop) != 1 || capturedReq.Config.Stop[0] != "END" {
+		t.Errorf("Config.Stop = %v, want [END]", capturedReq.Config.Stop)
+	}
+	if capturedReq.Config.Seed == nil || *capturedReq.Config.Seed != 7 {
+		t.Errorf("Config.Seed = %v, want 7", capturedReq.Config.Seed)
+	}
+	if capturedReq.ToolChoice != "required" {
+		t.Errorf("ToolChoice = %v, want required", capturedReq.ToolChoice)
+	}
+}
+
+// lastProcessedEvent returns the most recent MessageProcessedEvent sent to bus.
+func lastProcessedEvent(t *testing.T, bus *ChannelEventBus) MessageProcessedEvent {
+	t.Helper()
+
+	var last *MessageProcessedEvent
+	for {
+		select {
+		case event := <-bus.Events():
+			if e, ok := event.(MessageProcessedEvent); ok {
+				last = &e
+			}
+		default:
+			if last == nil {
+				t.Fatal("expected a MessageProcessedEvent")
+			}
+			return *last
+		}
+	}
+}
+
+func TestHandler_ProcessingTimeout(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Processing: config.ProcessingConfig{
+				Timeout: "50ms",
+			},
+		},
+	}
+
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			// Block until the handler's deadline fires
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	bus := NewEventBus(10)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, bus)
+
+	start := time.Now()
+	handler.Handle(athyr.SubscribeMessage{
+		Subject: "input",
+		Data:    []byte("hello"),
+	})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Handle took %v, want it bounded by processing.timeout", elapsed)
+	}
+	if len(agent.published) != 0 {
+		t.Errorf("published %d messages, want 0 after timeout", len(agent.published))
+	}
+
+	event := lastProcessedEvent(t, bus)
+	if event.Outcome != OutcomeTimedOut {
+		t.Errorf("Outcome = %v, want %v", event.Outcome, OutcomeTimedOut)
+	}
+	if event.Phase != "llm" {
+		t.Errorf("Phase = %q, want llm", event.Phase)
+	}
+}
+
+func TestHandler_CancelledByParentContext(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	agent := &mockAgent{
+		completeFunc: func(llmCtx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			// Simulate shutdown while the LLM call is in flight
+			cancel()
+			<-llmCtx.Done()
+			return nil, errors.New("rpc error: code = Canceled")
+		},
+	}
+
+	bus := NewEventBus(10)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, bus)
+
+	handler.HandleContext(ctx, athyr.SubscribeMessage{
+		Subject: "input",
+		Data:    []byte("hello"),
+	})
+
+	event := lastProcessedEvent(t, bus)
+	if event.Outcome != OutcomeCancelled {
+		t.Errorf("Outcome = %v, want %v", event.Outcome, OutcomeCancelled)
+	}
+}
+
+func TestHandler_ToolTimeoutReportedToLLM(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Processing: config.ProcessingConfig{
+				ToolTimeout: "20ms",
+			},
+		},
+	}
+
+	callCount := 0
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			callCount++
+			if callCount == 1 {
+				return &athyr.CompletionResponse{
+					ToolCalls: []athyr.ToolCall{{ID: "call_1", Name: "slow_tool"}},
+				}, nil
+			}
+			// The timed-out tool call is reported back as an error result
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role != "tool" || !strings.Contains(last.Content, "timed out") {
+				t.Errorf("last message = %+v, want tool timeout error", last)
+			}
+			return &athyr.CompletionResponse{Content: "done without the tool"}, nil
+		},
+	}
+
+	mcpMgr := NewMCPManager(nil)
+	mcpMgr.RegisterTool("test-server", athyr.Tool{Name: "slow_tool"})
+	mcpMgr.SetToolExecutor(func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	bus := NewEventBus(10)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, mcpMgr, nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{
+		Subject: "input",
+		Data:    []byte("use the slow tool"),
+	})
+
+	if callCount != 2 {
+		t.Errorf("Complete called %d times, want 2", callCount)
+	}
+	if len(agent.published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(agent.published))
+	}
+	if event := lastProcessedEvent(t, bus); event.Outcome != OutcomeCompleted {
+		t.Errorf("Outcome = %v, want %v", event.Outcome, OutcomeCompleted)
+	}
+}
+
+func TestHandler_RepliesWithErrorOnFailure(t *testing.T) {
+	tests := []struct {
+		name          string
+		timeout       string
+		err           error
+		wantCode      string
+		wantRetryable bool
+	}{
+		{"permanent llm error", "", errors.New("rpc error: code = InvalidArgument desc = unknown model"), ErrorCodeLLM, false},
+		{"transient llm error", "", errors.New("rpc error: code = Unavailable desc = connection lost"), ErrorCodeLLM, true},
+		{"timeout", "20ms", nil, ErrorCodeTimeout, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Agent: config.AgentConfig{
+					Name:  "test",
+					Model: "gpt-4",
+					Topics: config.TopicsConfig{
+						Subscribe: []string{"input"},
+						Publish:   []string{"output"},
+					},
+					Processing: config.ProcessingConfig{Timeout: tt.timeout},
+				},
+			}
+			agent := &mockAgent{
+				completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+			}
+			logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+			handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+			handler.Handle(athyr.SubscribeMessage{
+				Subject: "input",
+				Data:    []byte("hello"),
+				Reply:   "reply.1",
+			})
+
+			if len(agent.published) != 1 || agent.published[0].Subject != "reply.1" {
+				t.Fatalf("published = %+v, want one error reply on reply.1", agent.published)
+			}
+			var resp Response
+			if err := json.Unmarshal(agent.published[0].Data, &resp); err != nil {
+				t.Fatalf("reply is not JSON: %v", err)
+			}
+			if resp.Error == nil {
+				t.Fatalf("reply = %s, want an error object", agent.published[0].Data)
+			}
+			if resp.Error.Code != tt.wantCode {
+				t.Errorf("Error.Code = %q, want %q", resp.Error.Code, tt.wantCode)
+			}
+			if resp.Error.Retryable != tt.wantRetryable {
+				t.Errorf("Error.Retryable = %v, want %v", resp.Error.Retryable, tt.wantRetryable)
+			}
+			if resp.Error.TraceID == "" || resp.Error.Message == "" {
+				t.Errorf("Error = %+v, want trace ID and message", resp.Error)
+			}
+		})
+	}
+}
+
+func TestHandler_SuccessfulReplyHasNoError(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+		},
+	}
+	agent := &mockAgent{}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{
+		Subject: "input",
+		Data:    []byte("hello"),
+		Reply:   "reply.1",
+	})
+
+	if len(agent.published) != 2 {
+		t.Fatalf("published %d messages, want output and reply", len(agent.published))
+	}
+	reply := agent.published[1]
+	if reply.Subject != "reply.1" {
+		t.Fatalf("second publish = %s, want reply.1", reply.Subject)
+	}
+	if strings.Contains(string(reply.Data), `"error"`) {
+		t.Errorf("reply = %s, want no error object", reply.Data)
+	}
+}
+
+func TestHandler_FallsBackToNextModel(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:           "test",
+			Model:          "primary",
+			FallbackModels: []string{"secondary", "tertiary"},
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+		},
+	}
+
+	var tried []string
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			tried = append(tried, req.Model)
+			if req.Model == "primary" {
+				return nil, errors.New("rpc error: code = Unavailable desc = provider down")
+			}
+			return &athyr.CompletionResponse{Content: "from " + req.Model}, nil
+		},
+	}
+
+	bus := NewEventBus(20)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("hello")})
+
+	if strings.Join(tried, ",") != "primary,secondary" {
+		t.Errorf("tried models %v, want primary then secondary", tried)
+	}
+	if len(agent.published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(agent.published))
+	}
+	var resp Response
+	if err := json.Unmarshal(agent.published[0].Data, &resp); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if resp.Model != "secondary" {
+		t.Errorf("Response.Model = %q, want secondary", resp.Model)
+	}
+
+	var outgoing *MessageEvent
+	for len(bus.Events()) > 0 {
+		if e, ok := (<-bus.Events()).(MessageEvent); ok && e.Direction == MessageOutgoing {
+			outgoing = &e
+		}
+	}
+	if outgoing == nil || outgoing.Model != "secondary" {
+		t.Errorf("outgoing MessageEvent = %+v, want model secondary", outgoing)
+	}
+}
+
+func TestHandler_SkipsModelWithOpenCircuit(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:           "test",
+			Model:          "primary",
+			FallbackModels: []string{"secondary"},
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Errors: config.ErrorsConfig{
+				CircuitBreaker: config.CircuitBreakerConfig{FailureThreshold: 2, Cooldown: "1h"},
+			},
+		},
+	}
+
+	primaryCalls := 0
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			if req.Model == "primary" {
+				primaryCalls++
+				return nil, errors.New("rpc error: code = Unavailable desc = provider down")
+			}
+			return &athyr.CompletionResponse{Content: "ok"}, nil
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	for i := 0; i < 5; i++ {
+		handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("hello")})
+	}
+
+	if primaryCalls != 2 {
+		t.Errorf("primary called %d times, want 2 before its circuit opened", primaryCalls)
+	}
+	if len(agent.published) != 5 {
+		t.Errorf("published %d messages, want all 5 answered by the fallback", len(agent.published))
+	}
+}
+
+func TestHandler_PermanentErrorsDoNotOpenCircuit(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "primary",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Errors: config.ErrorsConfig{
+				CircuitBreaker: config.CircuitBreakerConfig{FailureThreshold: 1},
+			},
+		},
+	}
+
+	calls := 0
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			calls++
+			return nil, errors.New("rpc error: code = InvalidArgument desc = prompt too long")
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("hello")})
+	}
+
+	if calls != 3 {
+		t.Errorf("Complete called %d times, want 3 (invalid requests don't open the circuit)", calls)
+	}
+}
@@ -3,9 +3,13 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
+	"reflect"
+	"slices"
 	"sync"
 
 	"github.com/athyr-tech/athyr-agent/internal/config"
@@ -17,15 +21,47 @@ import (
 // ToolExecutor is a function that executes a tool call.
 type ToolExecutor func(ctx context.Context, name string, args json.RawMessage) (string, error)
 
+// Tool content types, as reported in ToolContent.Type.
+const (
+	ContentText         = "text"
+	ContentImage        = "image"
+	ContentAudio        = "audio"
+	ContentResourceLink = "resource_link"
+	ContentResource     = "resource"
+	ContentStructured   = "structured"
+)
+
+// ToolResult is the result of a tool call.
+type ToolResult struct {
+	Text    string        // The result as sent to the LLM: every block's Text, one after another
+	Content []ToolContent // The result's blocks, in order
+}
+
+// ToolContent is one block of a tool result. Text holds the block's text, or
+// for non-text content a description of it, since the LLM only reads text.
+// Structured output is included as JSON.
+type ToolContent struct {
+	Type string
+	Text string
+}
+
 // MCPManager manages MCP server connections and tool execution.
 type MCPManager struct {
 	logger       *slog.Logger
 	client       *mcp.Client
 	sessions     map[string]*mcp.ClientSession // server name → session
-	tools        map[string]athyr.Tool         // tool name → tool definition
-	toolSrc      map[string]string             // tool name → server name
+	tools        map[string]athyr.Tool         // exposed name → tool definition
+	toolSrc      map[string]string             // exposed name → server name
+	toolNames    map[string]string             // exposed name → name on its server
+	builtins     map[string]bool               // names of the built-in resource tools
+	resources    []ResourceInfo
+	states       map[string]*serverState // server name → supervision state
+	onStatus     func(MCPServerEvent)
+	stop         context.CancelFunc // stops the supervisors
+	supervisors  sync.WaitGroup
 	mu           sync.RWMutex
-	toolExecutor ToolExecutor // optional override for testing
+	toolExecutor ToolExecutor                                   // optional override for testing
+	transportFor func(srv config.MCPServerConfig) mcp.Transport // optional override for testing
 }
 
 // NewMCPManager creates a new MCP manager.
@@ -34,48 +70,97 @@ func NewMCPManager(logger *slog.Logger) *MCPManager {
 		logger = slog.Default()
 	}
 	return &MCPManager{
-		logger:   logger,
-		client:   mcp.NewClient(&mcp.Implementation{Name: "athyr-agent", Version: "1.0.0"}, nil),
-		sessions: make(map[string]*mcp.ClientSession),
-		tools:    make(map[string]athyr.Tool),
-		toolSrc:  make(map[string]string),
+		logger:    logger,
+		client:    mcp.NewClient(&mcp.Implementation{Name: "athyr-agent", Version: "1.0.0"}, nil),
+		sessions:  make(map[string]*mcp.ClientSession),
+		tools:     make(map[string]athyr.Tool),
+		toolSrc:   make(map[string]string),
+		toolNames: make(map[string]string),
+		builtins:  make(map[string]bool),
+		states:    make(map[string]*serverState),
 	}
 }
 
-// Start connects to all configured MCP servers and discovers their tools.
+// Start connects to all configured MCP servers and discovers their tools and
+// resources. The built-in resource tools are added if any resource is exposed.
+// Each server is then supervised until Close: it is restarted, and its tools
+// rediscovered, whenever it exits or fails a health check.
 func (m *MCPManager) Start(ctx context.Context, servers []config.MCPServerConfig) error {
 	for _, srv := range servers {
 		if err := m.connectServer(ctx, srv); err != nil {
 			return fmt.Errorf("failed to connect to MCP server %s: %w", srv.Name, err)
 		}
 	}
+	if err := m.registerResourceTools(); err != nil {
+		return err
+	}
+
+	// Supervise for as long as the sessions are open, past ctx if need be
+	superviseCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	m.mu.Lock()
+	m.stop = stop
+	m.mu.Unlock()
+	for _, srv := range servers {
+		m.setStatus(srv.Name, MCPServerUp, nil)
+		m.supervisors.Add(1)
+		go func() {
+			defer m.supervisors.Done()
+			m.supervise(superviseCtx, srv)
+		}()
+	}
 	return nil
 }
 
-// connectServer connects to a single MCP server and discovers its tools.
+// connectServer connects to a single MCP server and discovers its tools,
+// within the server's start_timeout. A server that fails to start is stopped.
 func (m *MCPManager) connectServer(ctx context.Context, srv config.MCPServerConfig) error {
-	var (
-		transport mcp.Transport
-		session   *mcp.ClientSession
-		err       error
-	)
+	transport, err := m.newTransport(srv)
+	if err != nil {
+		return err
+	}
+
+	timeout := srv.GetStartTimeout()
+	startCtx, cancel := withBudget(ctx, timeout)
+	defer cancel()
+	if err := m.connect(startCtx, srv, transport); err != nil {
+		m.dropServer(srv.Name)
+		if timeout > 0 && ctx.Err() == nil && startCtx.Err() != nil {
+			return fmt.Errorf("not started within %s: %w", timeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// newTransport returns a transport to the server: a subprocess for a
+// command, whose stderr goes to the log, or Streamable HTTP for a URL.
+func (m *MCPManager) newTransport(srv config.MCPServerConfig) (mcp.Transport, error) {
+	if m.transportFor != nil {
+		return m.transportFor(srv), nil
+	}
 
 	if srv.URL != "" {
 		m.logger.Info("connecting to MCP server via HTTP", "name", srv.Name, "url", srv.URL)
-		transport = &mcp.StreamableClientTransport{Endpoint: srv.URL}
-	} else {
-		m.logger.Info("connecting to MCP server via stdio", "name", srv.Name, "command", srv.Command)
-
-		cmd := exec.Command(srv.Command[0], srv.Command[1:]...)
-		if len(srv.Env) > 0 {
-			for k, v := range srv.Env {
-				cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
-			}
-		}
-		transport = &mcp.CommandTransport{Command: cmd}
+		return &mcp.StreamableClientTransport{Endpoint: srv.URL}, nil
+	}
+
+	env, err := serverEnv(srv, os.Environ())
+	if err != nil {
+		return nil, err
 	}
 
-	session, err = m.client.Connect(ctx, transport, nil)
+	m.logger.Info("connecting to MCP server via stdio", "name", srv.Name, "command", srv.Command, "cwd", srv.Cwd, "inherit_env", srv.GetInheritEnv())
+	cmd := exec.Command(srv.Command[0], srv.Command[1:]...)
+	cmd.Env = env
+	cmd.Dir = srv.Cwd
+	cmd.Stderr = &stderrLogger{logger: m.logger, server: srv.Name}
+	return &mcp.CommandTransport{Command: cmd}, nil
+}
+
+// connect starts a session with an MCP server over transport and discovers
+// its tools and resources.
+func (m *MCPManager) connect(ctx context.Context, srv config.MCPServerConfig, transport mcp.Transport) error {
+	session, err := m.client.Connect(ctx, transport, nil)
 	if err != nil {
 		return fmt.Errorf("connect failed: %w", err)
 	}
@@ -85,31 +170,65 @@ func (m *MCPManager) connectServer(ctx context.Context, srv config.MCPServerConf
 	m.mu.Unlock()
 
 	// Discover tools
-	if err := m.discoverTools(ctx, srv.Name, session); err != nil {
+	count, err := m.discoverTools(ctx, srv, session)
+	if err != nil {
 		return fmt.Errorf("tool discovery failed: %w", err)
 	}
 
-	m.logger.Info("connected to MCP server", "name", srv.Name, "tools", len(m.tools))
+	// Discover resources; the tools work without them
+	resources, err := m.discoverResources(ctx, srv, session)
+	if err != nil {
+		m.logger.Warn("resource discovery failed", "name", srv.Name, "error", err)
+	}
+
+	m.logger.Info("connected to MCP server", "name", srv.Name, "tools", count, "resources", resources)
 	return nil
 }
 
-// discoverTools queries tools from an MCP server and registers them.
-func (m *MCPManager) discoverTools(ctx context.Context, serverName string, session *mcp.ClientSession) error {
+// discoverTools queries tools from an MCP server and registers the ones its
+// include and exclude lists allow, under their exposed names. It fails if an
+// exposed name is already taken by another server's tool.
+func (m *MCPManager) discoverTools(ctx context.Context, srv config.MCPServerConfig, session *mcp.ClientSession) (int, error) {
+	if caps := session.InitializeResult().Capabilities; caps != nil && caps.Tools == nil {
+		return 0, nil
+	}
+
+	count := 0
 	for tool, err := range session.Tools(ctx, nil) {
 		if err != nil {
-			return err
+			return count, err
+		}
+		if !srv.ExposesTool(tool.Name) {
+			m.logger.Debug("skipping filtered tool", "name", tool.Name, "server", srv.Name)
+			continue
 		}
 
 		// Convert MCP tool to athyr.Tool
 		athyrTool := m.convertTool(tool)
+		athyrTool.Name = srv.ExposedToolName(tool.Name)
 
-		m.mu.Lock()
-		m.tools[tool.Name] = athyrTool
-		m.toolSrc[tool.Name] = serverName
-		m.mu.Unlock()
+		if err := m.registerTool(srv.Name, tool.Name, athyrTool); err != nil {
+			return count, err
+		}
+		count++
 
-		m.logger.Debug("discovered tool", "name", tool.Name, "server", serverName)
+		m.logger.Debug("discovered tool", "name", athyrTool.Name, "original", tool.Name, "server", srv.Name)
 	}
+	return count, nil
+}
+
+// registerTool registers a server's tool under its exposed name, unless
+// another server already exposes a tool with that name.
+func (m *MCPManager) registerTool(serverName, original string, tool athyr.Tool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if other, ok := m.toolSrc[tool.Name]; ok && other != serverName {
+		return fmt.Errorf("tool %q is also provided by MCP server %s; set a prefix or exclude it on one of them", tool.Name, other)
+	}
+	m.tools[tool.Name] = tool
+	m.toolSrc[tool.Name] = serverName
+	m.toolNames[tool.Name] = original
 	return nil
 }
 
@@ -137,6 +256,7 @@ func (m *MCPManager) RegisterTool(serverName string, tool athyr.Tool) {
 	defer m.mu.Unlock()
 	m.tools[tool.Name] = tool
 	m.toolSrc[tool.Name] = serverName
+	m.toolNames[tool.Name] = tool.Name
 }
 
 // SetToolExecutor sets a custom tool executor (useful for testing).
@@ -165,6 +285,14 @@ func (m *MCPManager) GetServerForTool(toolName string) string {
 	return m.toolSrc[toolName]
 }
 
+// GetOriginalToolName returns the name a tool has on its MCP server, which
+// differs from the exposed name when the server has a prefix.
+func (m *MCPManager) GetOriginalToolName(toolName string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.toolNames[toolName]
+}
+
 // GetToolsInfo returns tool information including which server they came from.
 func (m *MCPManager) GetToolsInfo() []ToolInfo {
 	m.mu.RLock()
@@ -174,6 +302,7 @@ func (m *MCPManager) GetToolsInfo() []ToolInfo {
 	for name, tool := range m.tools {
 		infos = append(infos, ToolInfo{
 			Name:        name,
+			Original:    m.toolNames[name],
 			Description: tool.Description,
 			Server:      m.toolSrc[name],
 		})
@@ -181,68 +310,156 @@ func (m *MCPManager) GetToolsInfo() []ToolInfo {
 	return infos
 }
 
-// CallTool executes a tool call and returns the result.
-func (m *MCPManager) CallTool(ctx context.Context, name string, args json.RawMessage) (string, error) {
+// CallTool executes a tool call and returns the result. A result the tool
+// flags as an error (IsError) is returned as an error carrying its text.
+func (m *MCPManager) CallTool(ctx context.Context, name string, args json.RawMessage) (*ToolResult, error) {
 	m.mu.RLock()
 	executor := m.toolExecutor
 	serverName, ok := m.toolSrc[name]
 	if !ok {
 		m.mu.RUnlock()
-		return "", fmt.Errorf("unknown tool: %s", name)
+		return nil, fmt.Errorf("unknown tool: %s", name)
 	}
+	original := m.toolNames[name]
 	session := m.sessions[serverName]
+	builtin := m.builtins[name]
 	m.mu.RUnlock()
 
+	if builtin {
+		return m.callBuiltin(ctx, name, args)
+	}
+
 	// Use custom executor if set (for testing)
 	if executor != nil {
-		return executor(ctx, name, args)
+		text, err := executor(ctx, name, args)
+		if err != nil {
+			return nil, err
+		}
+		return &ToolResult{Text: text, Content: []ToolContent{{Type: ContentText, Text: text}}}, nil
 	}
 
 	if session == nil {
-		return "", fmt.Errorf("no session for server: %s", serverName)
+		return nil, fmt.Errorf("no session for server: %s", serverName)
 	}
 
 	// Parse arguments
 	var arguments map[string]any
 	if len(args) > 0 {
 		if err := json.Unmarshal(args, &arguments); err != nil {
-			return "", fmt.Errorf("invalid arguments: %w", err)
+			return nil, fmt.Errorf("invalid arguments: %w", err)
 		}
 	}
 
 	// Call the tool
 	result, err := session.CallTool(ctx, &mcp.CallToolParams{
-		Name:      name,
+		Name:      original,
 		Arguments: arguments,
 	})
 	if err != nil {
-		return "", fmt.Errorf("tool call failed: %w", err)
+		return nil, fmt.Errorf("tool call failed: %w", err)
 	}
 
-	// Extract text content from result
-	return m.extractContent(result), nil
+	content := extractContent(result)
+	if result.IsError {
+		if content.Text == "" {
+			return nil, errors.New("tool reported an error")
+		}
+		return nil, fmt.Errorf("tool reported an error: %s", content.Text)
+	}
+	return content, nil
 }
 
-// extractContent extracts text from a CallToolResult.
-func (m *MCPManager) extractContent(result *mcp.CallToolResult) string {
-	if result == nil || len(result.Content) == 0 {
-		return ""
+// extractContent converts a CallToolResult into text for the LLM. Images,
+// audio and binary resources are described rather than included, and
+// structured output is added as JSON unless a text block already holds it.
+func extractContent(result *mcp.CallToolResult) *ToolResult {
+	r := &ToolResult{}
+	if result == nil {
+		return r
 	}
 
-	var text string
+	add := func(typ, text string) {
+		if r.Text != "" {
+			r.Text += "\n"
+		}
+		r.Text += text
+		r.Content = append(r.Content, ToolContent{Type: typ, Text: text})
+	}
+
+	var texts []string
 	for _, content := range result.Content {
-		if tc, ok := content.(*mcp.TextContent); ok {
-			if text != "" {
-				text += "\n"
-			}
-			text += tc.Text
+		switch c := content.(type) {
+		case *mcp.TextContent:
+			texts = append(texts, c.Text)
+			add(ContentText, c.Text)
+		case *mcp.ImageContent:
+			add(ContentImage, fmt.Sprintf("[image: %s, %d bytes]", c.MIMEType, len(c.Data)))
+		case *mcp.AudioContent:
+			add(ContentAudio, fmt.Sprintf("[audio: %s, %d bytes]", c.MIMEType, len(c.Data)))
+		case *mcp.ResourceLink:
+			add(ContentResourceLink, describeResourceLink(c))
+		case *mcp.EmbeddedResource:
+			add(ContentResource, describeResource(c.Resource))
 		}
 	}
-	return text
+
+	if result.StructuredContent != nil {
+		data, err := json.Marshal(result.StructuredContent)
+		if err == nil && !slices.ContainsFunc(texts, func(t string) bool { return sameJSON(t, data) }) {
+			add(ContentStructured, string(data))
+		}
+	}
+	return r
+}
+
+// describeResourceLink describes a link to a resource the LLM can't read directly.
+func describeResourceLink(l *mcp.ResourceLink) string {
+	s := "[resource link: " + l.URI
+	if l.Name != "" {
+		s += " (" + l.Name + ")"
+	}
+	if l.MIMEType != "" {
+		s += ", " + l.MIMEType
+	}
+	s += "]"
+	if l.Description != "" {
+		s += " " + l.Description
+	}
+	return s
+}
+
+// describeResource returns an embedded resource's text under a header naming
+// it, or a description of it if it is binary.
+func describeResource(r *mcp.ResourceContents) string {
+	if r == nil {
+		return "[resource]"
+	}
+	if r.Blob != nil {
+		return fmt.Sprintf("[resource: %s, %s, %d bytes]", r.URI, r.MIMEType, len(r.Blob))
+	}
+	return fmt.Sprintf("[resource: %s]\n%s", r.URI, r.Text)
+}
+
+// sameJSON reports whether text is JSON encoding the same value as data,
+// regardless of formatting and key order.
+func sameJSON(text string, data []byte) bool {
+	var a, b any
+	if json.Unmarshal([]byte(text), &a) != nil || json.Unmarshal(data, &b) != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
 }
 
-// Close shuts down all MCP server connections.
+// Close stops supervising the MCP servers and shuts down their connections.
 func (m *MCPManager) Close() error {
+	m.mu.RLock()
+	stop := m.stop
+	m.mu.RUnlock()
+	if stop != nil {
+		stop()
+	}
+	m.supervisors.Wait()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
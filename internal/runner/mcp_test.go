@@ -3,11 +3,14 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/athyr-tech/athyr-agent/internal/config"
 
 	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func TestMCPManager_GetAthyrTools_Empty(t *testing.T) {
@@ -67,3 +70,274 @@ func TestMCPManager_NoServersConfigured(t *testing.T) {
 		t.Errorf("GetAthyrTools() = %v tools, want 0", len(tools))
 	}
 }
+
+// serveTools runs an in-memory MCP server offering the named tools, each of
+// which answers with its own name, and returns the client end of the transport.
+func serveTools(t *testing.T, names ...string) mcp.Transport {
+	t.Helper()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	for _, name := range names {
+		server.AddTool(&mcp.Tool{Name: name, InputSchema: map[string]any{"type": "object"}},
+			func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: req.Params.Name}}}, nil
+			})
+	}
+
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	session, err := server.Connect(context.Background(), serverTransport, nil)
+	if err != nil {
+		t.Fatalf("server connect failed: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return clientTransport
+}
+
+func TestMCPManager_FiltersAndPrefixesTools(t *testing.T) {
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+
+	srv := config.MCPServerConfig{
+		Name:    "github",
+		Include: []string{"search", "create_*"},
+		Exclude: []string{"create_pr"},
+		Prefix:  "gh",
+	}
+	transport := serveTools(t, "search", "create_issue", "create_pr", "delete_repo")
+	if err := mgr.connect(context.Background(), srv, transport); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	infos := mgr.GetToolsInfo()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	want := []ToolInfo{
+		{Name: "gh__create_issue", Original: "create_issue", Server: "github"},
+		{Name: "gh__search", Original: "search", Server: "github"},
+	}
+	if len(infos) != len(want) {
+		t.Fatalf("GetToolsInfo() = %+v, want %+v", infos, want)
+	}
+	for i := range want {
+		if infos[i] != want[i] {
+			t.Errorf("GetToolsInfo()[%d] = %+v, want %+v", i, infos[i], want[i])
+		}
+	}
+
+	// Calls use the exposed name; the server sees its own
+	result, err := mgr.CallTool(context.Background(), "gh__search", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.Text != "search" {
+		t.Errorf("CallTool() = %q, want the server to receive %q", result.Text, "search")
+	}
+	if _, err := mgr.CallTool(context.Background(), "delete_repo", nil); err == nil {
+		t.Error("CallTool(delete_repo) succeeded, want unknown tool")
+	}
+}
+
+func TestMCPManager_ToolNameCollision(t *testing.T) {
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+
+	ctx := context.Background()
+	if err := mgr.connect(ctx, config.MCPServerConfig{Name: "docs"}, serveTools(t, "search")); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	err := mgr.connect(ctx, config.MCPServerConfig{Name: "web"}, serveTools(t, "search"))
+	if err == nil || !strings.Contains(err.Error(), `tool "search" is also provided by MCP server docs`) {
+		t.Errorf("connect error = %v, want a collision with docs", err)
+	}
+
+	// A prefix keeps both
+	if err := mgr.connect(ctx, config.MCPServerConfig{Name: "web2", Prefix: "web"}, serveTools(t, "search")); err != nil {
+		t.Fatalf("connect with prefix failed: %v", err)
+	}
+	if server := mgr.GetServerForTool("web__search"); server != "web2" {
+		t.Errorf("GetServerForTool(web__search) = %q, want web2", server)
+	}
+	if server := mgr.GetServerForTool("search"); server != "docs" {
+		t.Errorf("GetServerForTool(search) = %q, want docs", server)
+	}
+}
+
+func TestExtractContent(t *testing.T) {
+	result := extractContent(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: "chart attached"},
+			&mcp.ImageContent{MIMEType: "image/png", Data: make([]byte, 2048)},
+			&mcp.ResourceLink{URI: "file:///tmp/report.csv", Name: "report.csv", MIMEType: "text/csv"},
+			&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "file:///tmp/notes.txt", Text: "remember the milk"}},
+			&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "file:///tmp/data.bin", MIMEType: "application/octet-stream", Blob: []byte{1, 2, 3}}},
+		},
+		StructuredContent: map[string]any{"rows": 3},
+	})
+
+	want := []ToolContent{
+		{ContentText, "chart attached"},
+		{ContentImage, "[image: image/png, 2048 bytes]"},
+		{ContentResourceLink, "[resource link: file:///tmp/report.csv (report.csv), text/csv]"},
+		{ContentResource, "[resource: file:///tmp/notes.txt]\nremember the milk"},
+		{ContentResource, "[resource: file:///tmp/data.bin, application/octet-stream, 3 bytes]"},
+		{ContentStructured, `{"rows":3}`},
+	}
+	if len(result.Content) != len(want) {
+		t.Fatalf("Content = %+v, want %+v", result.Content, want)
+	}
+	var texts []string
+	for i, w := range want {
+		if result.Content[i] != w {
+			t.Errorf("Content[%d] = %+v, want %+v", i, result.Content[i], w)
+		}
+		texts = append(texts, w.Text)
+	}
+	if want := strings.Join(texts, "\n"); result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+}
+
+func TestExtractContent_StructuredAlreadyInText(t *testing.T) {
+	result := extractContent(&mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: `{"b": 2, "a": 1}`}},
+		StructuredContent: map[string]any{"a": 1, "b": 2},
+	})
+	if len(result.Content) != 1 || result.Text != `{"b": 2, "a": 1}` {
+		t.Errorf("result = %+v, want only the text block", result)
+	}
+}
+
+func TestMCPManager_CallTool_IsError(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	server.AddTool(&mcp.Tool{Name: "read_file", InputSchema: map[string]any{"type": "object"}},
+		func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{&mcp.TextContent{Text: "no such file: notes.txt"}},
+			}, nil
+		})
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	session, err := server.Connect(context.Background(), serverTransport, nil)
+	if err != nil {
+		t.Fatalf("server connect failed: %v", err)
+	}
+	defer session.Close()
+
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+	if err := mgr.connect(context.Background(), config.MCPServerConfig{Name: "files"}, clientTransport); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	_, err = mgr.CallTool(context.Background(), "read_file", nil)
+	if err == nil || !strings.Contains(err.Error(), "no such file: notes.txt") {
+		t.Errorf("CallTool() error = %v, want the tool's error text", err)
+	}
+}
+
+// serveDocs runs an in-memory MCP server offering two text resources and a
+// "persona" prompt, and returns the client end of the transport.
+func serveDocs(t *testing.T) mcp.Transport {
+	t.Helper()
+	server := mcp.NewServer(&mcp.Implementation{Name: "docs", Version: "1.0.0"}, nil)
+	for _, uri := range []string{"docs://handbook/refunds.md", "docs://internal/salaries.md"} {
+		server.AddResource(&mcp.Resource{URI: uri, Name: uri[strings.LastIndex(uri, "/")+1:], MIMEType: "text/markdown"},
+			func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+				return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: req.Params.URI, Text: "contents of " + req.Params.URI}}}, nil
+			})
+	}
+	server.AddPrompt(&mcp.Prompt{Name: "persona", Arguments: []*mcp.PromptArgument{{Name: "team"}}},
+		func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return &mcp.GetPromptResult{Messages: []*mcp.PromptMessage{
+				{Role: "user", Content: &mcp.TextContent{Text: "You support the " + req.Params.Arguments["team"] + " team."}},
+				{Role: "user", Content: &mcp.TextContent{Text: "Be brief."}},
+			}}, nil
+		})
+
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	session, err := server.Connect(context.Background(), serverTransport, nil)
+	if err != nil {
+		t.Fatalf("server connect failed: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return clientTransport
+}
+
+func TestMCPManager_Resources(t *testing.T) {
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+
+	srv := config.MCPServerConfig{Name: "docs", Resources: []string{"docs://handbook/*"}}
+	if err := mgr.connect(context.Background(), srv, serveDocs(t)); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	if err := mgr.registerResourceTools(); err != nil {
+		t.Fatalf("registerResourceTools failed: %v", err)
+	}
+
+	if got := len(mgr.GetResourcesInfo()); got != 2 {
+		t.Errorf("GetResourcesInfo() has %d resources, want 2", got)
+	}
+	if got := mgr.GetServerForTool(ReadResourceTool); got != builtinServer {
+		t.Errorf("read_resource server = %q, want %q", got, builtinServer)
+	}
+
+	// Only the allowed resource is listed
+	list, err := mgr.CallTool(context.Background(), ListResourcesTool, nil)
+	if err != nil {
+		t.Fatalf("list_resources failed: %v", err)
+	}
+	var listed []ResourceInfo
+	if err := json.Unmarshal([]byte(list.Text), &listed); err != nil {
+		t.Fatalf("list_resources result is not JSON: %v", err)
+	}
+	if len(listed) != 1 || listed[0].URI != "docs://handbook/refunds.md" || listed[0].Server != "docs" {
+		t.Errorf("list_resources = %+v, want only the handbook page", listed)
+	}
+
+	read, err := mgr.CallTool(context.Background(), ReadResourceTool, json.RawMessage(`{"uri": "docs://handbook/refunds.md"}`))
+	if err != nil {
+		t.Fatalf("read_resource failed: %v", err)
+	}
+	if !strings.Contains(read.Text, "contents of docs://handbook/refunds.md") {
+		t.Errorf("read_resource = %q, want the resource's contents", read.Text)
+	}
+
+	if _, err := mgr.CallTool(context.Background(), ReadResourceTool, json.RawMessage(`{"uri": "docs://internal/salaries.md"}`)); err == nil {
+		t.Error("read_resource of a resource outside the allowlist succeeded")
+	}
+}
+
+func TestMCPManager_NoResourceToolsWithoutAllowlist(t *testing.T) {
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+
+	if err := mgr.connect(context.Background(), config.MCPServerConfig{Name: "docs"}, serveDocs(t)); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	if err := mgr.registerResourceTools(); err != nil {
+		t.Fatalf("registerResourceTools failed: %v", err)
+	}
+	if tools := mgr.GetAthyrTools(); len(tools) != 0 {
+		t.Errorf("GetAthyrTools() = %+v, want no tools", tools)
+	}
+}
+
+func TestMCPManager_GetPrompt(t *testing.T) {
+	mgr := NewMCPManager(nil)
+	defer mgr.Close()
+
+	if err := mgr.connect(context.Background(), config.MCPServerConfig{Name: "docs"}, serveDocs(t)); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	got, err := mgr.GetPrompt(context.Background(), "docs", "persona", map[string]string{"team": "billing"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if want := "You support the billing team.\n\nBe brief."; got != want {
+		t.Errorf("GetPrompt() = %q, want %q", got, want)
+	}
+	if _, err := mgr.GetPrompt(context.Background(), "docs", "missing", nil); err == nil {
+		t.Error("GetPrompt of an unknown prompt succeeded")
+	}
+}
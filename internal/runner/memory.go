@@ -0,0 +1,230 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// summaryPrompt instructs the LLM to fold older messages into the running summary.
+const summaryPrompt = "You maintain a running summary of a conversation. " +
+	"Combine the existing summary with the new messages into one concise summary " +
+	"that keeps facts, decisions, names and open questions. Reply with the summary only."
+
+// estimateTokens approximates the token count of text at four characters per
+// token, which is close enough for budgeting without a model-specific tokenizer.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// messageTokens estimates the tokens a message occupies, including role overhead.
+func messageTokens(msg athyr.Message) int {
+	return estimateTokens(msg.Content) + 4
+}
+
+// localMemory keeps conversation history per session inside the agent, for
+// memory.backend: local. History is held in memory only and is lost on restart.
+type localMemory struct {
+	profile config.SessionProfileConfig
+
+	mu       sync.Mutex
+	sessions map[string]*localSession // session ID → history
+}
+
+// localSession is the history of one conversation: a summary of older turns
+// followed by the most recent messages.
+type localSession struct {
+	summary  string
+	messages []athyr.Message
+}
+
+// newLocalMemory creates an empty local memory with the given profile.
+func newLocalMemory(profile config.SessionProfileConfig) *localMemory {
+	return &localMemory{
+		profile:  profile,
+		sessions: make(map[string]*localSession),
+	}
+}
+
+// history returns the messages to send before a new user message: the
+// summary (as a system message) followed by the retained turns.
+func (m *localMemory) history(id string) []athyr.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil
+	}
+	var msgs []athyr.Message
+	if s.summary != "" {
+		msgs = append(msgs, athyr.Message{
+			Role:    "system",
+			Content: "Summary of the conversation so far:\n" + s.summary,
+		})
+	}
+	return append(msgs, s.messages...)
+}
+
+// append adds messages to a session's history.
+func (m *localMemory) append(id string, msgs ...athyr.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		s = &localSession{}
+		m.sessions[id] = s
+	}
+	s.messages = append(s.messages, msgs...)
+}
+
+// delete forgets a session.
+func (m *localMemory) delete(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
+
+// tokens estimates the tokens of a session's summary and messages.
+func (m *localMemory) tokens(id string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return 0
+	}
+	return s.tokens()
+}
+
+// pendingSummary returns the current summary and the oldest messages to fold
+// into it once the session has crossed summarization_threshold. The most
+// recent messages, up to half the threshold, are kept verbatim.
+func (m *localMemory) pendingSummary(id string) (summary string, older []athyr.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.tokens() <= m.profile.SummarizationThreshold {
+		return "", nil
+	}
+
+	keep, budget := 0, m.profile.SummarizationThreshold/2
+	for i := len(s.messages) - 1; i > 0; i-- {
+		budget -= messageTokens(s.messages[i])
+		if budget < 0 {
+			break
+		}
+		keep++
+	}
+	split := len(s.messages) - max(keep, 1)
+	return s.summary, append([]athyr.Message(nil), s.messages[:split]...)
+}
+
+// compact replaces the oldest n messages of a session with summary.
+func (m *localMemory) compact(id string, n int, summary string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok && n <= len(s.messages) {
+		s.summary = summary
+		s.messages = append([]athyr.Message(nil), s.messages[n:]...)
+	}
+}
+
+// trim drops the oldest messages until the session fits within max_tokens
+// (the rolling window). The latest message is always kept. It returns the
+// number of messages dropped.
+func (m *localMemory) trim(id string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return 0
+	}
+	dropped := 0
+	for len(s.messages) > 1 && s.tokens() > m.profile.MaxTokens {
+		s.messages = s.messages[1:]
+		dropped++
+	}
+	return dropped
+}
+
+// tokens estimates the tokens of the summary and messages.
+func (s *localSession) tokens() int {
+	n := estimateTokens(s.summary)
+	for _, msg := range s.messages {
+		n += messageTokens(msg)
+	}
+	return n
+}
+
+// remember records a completed exchange in local memory, summarizing older
+// messages once summarization_threshold is crossed and then trimming the
+// history to max_tokens. A failed summarization falls back to trimming.
+func (h *MessageHandler) remember(ctx context.Context, traceID, sessionID string, exchange ...athyr.Message) {
+	h.memory.append(sessionID, exchange...)
+
+	if summary, older := h.memory.pendingSummary(sessionID); len(older) > 0 {
+		before := h.memory.tokens(sessionID)
+		updated, err := h.summarize(ctx, summary, older)
+		if err != nil {
+			h.logger.Warn("memory summarization failed",
+				"trace_id", traceID,
+				"session_id", sessionID,
+				"error", err.Error(),
+			)
+		} else {
+			h.memory.compact(sessionID, len(older), updated)
+			h.logger.Info("memory summarized",
+				"trace_id", traceID,
+				"session_id", sessionID,
+				"messages", len(older),
+				"tokens_before", before,
+				"tokens_after", h.memory.tokens(sessionID),
+			)
+		}
+	}
+
+	if dropped := h.memory.trim(sessionID); dropped > 0 {
+		h.logger.Debug("memory trimmed",
+			"trace_id", traceID,
+			"session_id", sessionID,
+			"dropped", dropped,
+		)
+	}
+}
+
+// summarize asks the LLM to fold messages into the existing summary.
+func (h *MessageHandler) summarize(ctx context.Context, summary string, messages []athyr.Message) (string, error) {
+	var transcript strings.Builder
+	if summary != "" {
+		transcript.WriteString("Existing summary:\n")
+		transcript.WriteString(summary)
+		transcript.WriteString("\n\n")
+	}
+	transcript.WriteString("New messages:\n")
+	for _, msg := range messages {
+		transcript.WriteString(msg.Role)
+		transcript.WriteString(": ")
+		transcript.WriteString(msg.Content)
+		transcript.WriteString("\n")
+	}
+
+	req := h.newCompletionRequest([]athyr.Message{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: transcript.String()},
+	}, nil)
+	resp, err := h.complete(ctx, req, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
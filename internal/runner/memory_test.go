@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hi", 1},
+		{"four", 1},
+		{"hello world!", 3},
+		{"héllo", 2}, // Counts characters, not bytes
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.text); got != tt.want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLocalMemory_TrimsToMaxTokens(t *testing.T) {
+	m := newLocalMemory(config.SessionProfileConfig{MaxTokens: 30, SummarizationThreshold: 1000})
+
+	// Each message is 10 content tokens + 4 overhead = 14 tokens
+	for i := 0; i < 4; i++ {
+		m.append("s", athyr.Message{Role: "user", Content: strings.Repeat("x", 40)})
+	}
+	if dropped := m.trim("s"); dropped != 2 {
+		t.Errorf("trim() dropped %d messages, want 2", dropped)
+	}
+	if got := m.tokens("s"); got > 30 {
+		t.Errorf("tokens() = %d after trim, want at most 30", got)
+	}
+	if got := len(m.history("s")); got != 2 {
+		t.Errorf("history() has %d messages, want 2", got)
+	}
+}
+
+func TestLocalMemory_PendingSummaryKeepsRecentMessages(t *testing.T) {
+	m := newLocalMemory(config.SessionProfileConfig{MaxTokens: 1000, SummarizationThreshold: 50})
+
+	for i := 0; i < 3; i++ {
+		m.append("s", athyr.Message{Role: "user", Content: strings.Repeat("x", 40)}) // 14 tokens
+	}
+	if _, older := m.pendingSummary("s"); len(older) != 0 {
+		t.Fatalf("pendingSummary() = %d messages below threshold, want none", len(older))
+	}
+
+	m.append("s", athyr.Message{Role: "assistant", Content: strings.Repeat("y", 40)}) // 56 tokens total
+	_, older := m.pendingSummary("s")
+	// Half the threshold (25 tokens) keeps only the newest message
+	if len(older) != 3 {
+		t.Fatalf("pendingSummary() = %d messages, want the 3 oldest", len(older))
+	}
+
+	m.compact("s", len(older), "earlier chat")
+	history := m.history("s")
+	if len(history) != 2 || history[0].Role != "system" || !strings.Contains(history[0].Content, "earlier chat") {
+		t.Errorf("history() = %+v, want summary followed by the newest message", history)
+	}
+	if history[1].Role != "assistant" {
+		t.Errorf("history()[1].Role = %q, want assistant", history[1].Role)
+	}
+}
+
+func localMemoryConfig(profile config.SessionProfileConfig) *config.Config {
+	return &config.Config{
+		Agent: config.AgentConfig{
+			Name:         "test",
+			Model:        "gpt-4",
+			Instructions: "Be helpful.",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Memory: config.MemoryConfig{
+				Enabled: true,
+				Backend: config.MemoryBackendLocal,
+				Profile: profile,
+			},
+		},
+	}
+}
+
+func TestHandler_LocalMemoryIncludesHistory(t *testing.T) {
+	cfg := localMemoryConfig(config.SessionProfileConfig{})
+
+	var requests []athyr.CompletionRequest
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			requests = append(requests, req)
+			return &athyr.CompletionResponse{Content: "reply " + req.Messages[len(req.Messages)-1].Content}, nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "content": "first"}`)})
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "content": "second"}`)})
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u2", "content": "other"}`)})
+
+	if len(requests) != 3 {
+		t.Fatalf("got %d completion requests, want 3", len(requests))
+	}
+	for _, req := range requests {
+		if req.SessionID != "" || req.IncludeMemory {
+			t.Errorf("request uses server memory (SessionID %q), want local history only", req.SessionID)
+		}
+	}
+
+	var roles, contents []string
+	for _, msg := range requests[1].Messages {
+		roles = append(roles, msg.Role)
+		contents = append(contents, msg.Content)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant,user" {
+		t.Errorf("second request roles = %v, want system, user, assistant, user", roles)
+	}
+	if contents[1] != "first" || contents[2] != "reply first" || contents[3] != "second" {
+		t.Errorf("second request contents = %q, want the first exchange then the new message", contents)
+	}
+
+	// Sessions don't share history
+	if got := len(requests[2].Messages); got != 2 {
+		t.Errorf("other session request has %d messages, want system and user only", got)
+	}
+}
+
+func TestHandler_LocalMemorySummarizes(t *testing.T) {
+	cfg := localMemoryConfig(config.SessionProfileConfig{MaxTokens: 1000, SummarizationThreshold: 40})
+
+	var summaryRequests int
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			if req.Messages[0].Content == summaryPrompt {
+				summaryRequests++
+				return &athyr.CompletionResponse{Content: "the user said hello twice"}, nil
+			}
+			return &athyr.CompletionResponse{Content: strings.Repeat("r", 40)}, nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	msg := athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "content": "hello there, how are you doing"}`)}
+	handler.Handle(msg) // 2 messages, 26 tokens
+	if summaryRequests != 0 {
+		t.Fatal("summarized below the threshold")
+	}
+	handler.Handle(msg) // 4 messages, 52 tokens: crosses the threshold
+
+	if summaryRequests != 1 {
+		t.Fatalf("summary requests = %d, want 1", summaryRequests)
+	}
+
+	sessionID, _ := handler.sessions.touch("u1")
+	history := handler.memory.history(sessionID)
+	if len(history) == 0 || history[0].Role != "system" || !strings.Contains(history[0].Content, "hello twice") {
+		t.Errorf("history = %+v, want the summary first", history)
+	}
+	if got := handler.memory.tokens(sessionID); got > 40 {
+		t.Errorf("tokens after summary = %d, want at most the threshold", got)
+	}
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"context"
+	"errors"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// PipelinePreview is what the agent would do with a message, as captured by
+// PreviewPipeline.
+type PipelinePreview struct {
+	Topic        string            // Subscribe topic the message was treated as arriving on
+	Route        string            // route_to requested by the LLM, if any
+	RouteValid   bool              // Route is one of topics.routes; otherwise the publish topics are used
+	Response     Response          // Response that would be published
+	Publications []Publication     // Would-be publishes, in order
+	ToolCalls    []PreviewToolCall // Tool calls requested by the LLM, in order; none are executed
+}
+
+// PreviewToolCall is a tool call the agent would have made.
+type PreviewToolCall struct {
+	Name             string
+	Arguments        string
+	RequiresApproval bool // Would have waited for approval (tools.require_approval)
+}
+
+// Publication is a message the agent would have published.
+type Publication struct {
+	Topic  string
+	Plugin bool   // Sent to a Lua plugin rather than an Athyr topic
+	Data   string // Response JSON, or the response content for plugins
+}
+
+// PreviewPipeline runs content through the same steps as a message arriving
+// on the agent's first subscribe topic (routing instructions, tools, route
+// validation and target selection) but captures the publishes instead of
+// sending them. Tools aren't executed: the calls are recorded and the LLM
+// is told they didn't run, so no approval requests or side effects happen.
+func (h *MessageHandler) PreviewPipeline(content string) (*PipelinePreview, error) {
+	if len(h.cfg.Agent.Topics.Subscribe) == 0 {
+		return nil, errors.New("agent has no subscribe topics")
+	}
+
+	preview := &PipelinePreview{Topic: h.cfg.Agent.Topics.Subscribe[0]}
+	err := h.handle(context.Background(), athyr.SubscribeMessage{
+		Subject: preview.Topic,
+		Data:    []byte(content),
+	}, preview)
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// recordToolCalls records calls in the preview instead of executing them and
+// returns the results the LLM gets for them, in call order.
+func (p *PipelinePreview) recordToolCalls(tools config.ToolsConfig, calls []athyr.ToolCall) []string {
+	results := make([]string, len(calls))
+	for i, call := range calls {
+		p.ToolCalls = append(p.ToolCalls, PreviewToolCall{
+			Name:             call.Name,
+			Arguments:        string(call.Arguments),
+			RequiresApproval: tools.RequiresApproval(call.Name),
+		})
+		results[i] = toolError(errors.New("tool not executed: this is a pipeline preview"))
+	}
+	return results
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+func TestPreviewPipeline(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:         "classifier",
+			Model:        "gpt-4",
+			Instructions: "Classify tickets.",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"tickets"},
+				Publish:   []string{"tickets.unclassified"},
+				Routes: []config.RouteConfig{
+					{Topic: "tickets.billing", Description: "Billing questions"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		wantRoute  string
+		wantValid  bool
+		wantTopics []string
+	}{
+		{"valid route", `{"route_to": "tickets.billing"}`, "tickets.billing", true, []string{"tickets.billing"}},
+		{"invalid route", `{"route_to": "tickets.unknown"}`, "tickets.unknown", false, []string{"tickets.unclassified"}},
+		{"no route", "just text", "", false, []string{"tickets.unclassified"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var system string
+			agent := &mockAgent{
+				completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+					system = req.Messages[0].Content
+					return &athyr.CompletionResponse{Content: tt.content, Model: "gpt-4"}, nil
+				},
+			}
+			bus := NewEventBus(10)
+			logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+			handler := newMessageHandler(cfg, agent, logger, nil, nil, bus)
+
+			preview, err := handler.PreviewPipeline("My invoice is wrong")
+			if err != nil {
+				t.Fatalf("PreviewPipeline failed: %v", err)
+			}
+
+			if !strings.Contains(system, "tickets.billing") {
+				t.Errorf("system prompt %q lacks the routing instructions", system)
+			}
+			if preview.Topic != "tickets" || preview.Route != tt.wantRoute || preview.RouteValid != tt.wantValid {
+				t.Errorf("preview = %+v, want topic tickets, route %q (valid %v)", preview, tt.wantRoute, tt.wantValid)
+			}
+			if len(preview.Publications) != len(tt.wantTopics) || preview.Publications[0].Topic != tt.wantTopics[0] {
+				t.Fatalf("Publications = %+v, want %v", preview.Publications, tt.wantTopics)
+			}
+
+			var published Response
+			if err := json.Unmarshal([]byte(preview.Publications[0].Data), &published); err != nil {
+				t.Fatalf("publication is not a Response: %v", err)
+			}
+			if published != preview.Response || published.SourceTopic != "tickets" || published.Content != tt.content {
+				t.Errorf("published %+v, want %+v", published, preview.Response)
+			}
+
+			if len(agent.published) != 0 {
+				t.Errorf("published = %+v, want nothing sent", agent.published)
+			}
+			select {
+			case e := <-bus.Events():
+				t.Errorf("unexpected event %T", e)
+			default:
+			}
+		})
+	}
+}
+
+func TestPreviewPipeline_SkipsTools(t *testing.T) {
+	agent := newApprovalAgent(func(req ApprovalRequest) ([]byte, error) {
+		return json.Marshal(ApprovalDecision{Approved: true})
+	})
+	var calls int
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(approvalConfig("approvals", "5s"), agent, logger, recordManager(&calls), nil, nil)
+
+	preview, err := handler.PreviewPipeline("delete record 42")
+	if err != nil {
+		t.Fatalf("PreviewPipeline failed: %v", err)
+	}
+
+	if calls != 0 || len(agent.requests) != 0 {
+		t.Errorf("tool executed %d times with %d approval requests, want neither", calls, len(agent.requests))
+	}
+	want := PreviewToolCall{Name: "delete_record", Arguments: `{"id": 42}`, RequiresApproval: true}
+	if len(preview.ToolCalls) != 1 || preview.ToolCalls[0] != want {
+		t.Errorf("ToolCalls = %+v, want %+v", preview.ToolCalls, want)
+	}
+	if !strings.Contains(agent.result, "not executed") {
+		t.Errorf("tool result = %q, want the LLM told the tool didn't run", agent.result)
+	}
+	if preview.Response.Content != "done" {
+		t.Errorf("Response.Content = %q, want done", preview.Response.Content)
+	}
+}
+
+func TestPreviewPipeline_ReportsFailure(t *testing.T) {
+	cfg := retryConfig(config.ErrorsConfig{DeadLetter: "failed"})
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			return nil, context.DeadlineExceeded
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	if _, err := handler.PreviewPipeline("hello"); err == nil {
+		t.Error("PreviewPipeline succeeded, want the LLM error")
+	}
+	if len(agent.published) != 0 {
+		t.Errorf("published = %+v, want no dead letter for a preview", agent.published)
+	}
+}
@@ -0,0 +1,205 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Built-in tools that give the LLM access to MCP resources. They are offered
+// when at least one server's resources allowlist matches a resource.
+const (
+	ListResourcesTool = "list_resources"
+	ReadResourceTool  = "read_resource"
+)
+
+// builtinServer is reported as the server of the built-in tools.
+const builtinServer = "built-in"
+
+// ResourceInfo describes a resource offered by an MCP server.
+type ResourceInfo struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mime_type,omitempty"`
+	Server      string `json:"server"`
+	Exposed     bool   `json:"-"` // Matches the server's resources allowlist
+}
+
+// resourceTools defines the built-in resource tools.
+var resourceTools = []athyr.Tool{
+	{
+		Name: ListResourcesTool,
+		Description: "List the resources (files, documents, records) that can be read with read_resource. " +
+			"Returns each resource's URI, name, description, MIME type and server.",
+		Parameters: json.RawMessage(`{"type": "object", "properties": {}}`),
+	},
+	{
+		Name:        ReadResourceTool,
+		Description: "Read a resource by its URI, as returned by list_resources.",
+		Parameters:  json.RawMessage(`{"type": "object", "properties": {"uri": {"type": "string", "description": "URI of the resource"}}, "required": ["uri"]}`),
+	},
+}
+
+// discoverResources lists a server's resources, marking the ones its
+// resources allowlist exposes to the LLM. Servers without resources are skipped.
+func (m *MCPManager) discoverResources(ctx context.Context, srv config.MCPServerConfig, session *mcp.ClientSession) (int, error) {
+	if caps := session.InitializeResult().Capabilities; caps == nil || caps.Resources == nil {
+		return 0, nil
+	}
+
+	var found []ResourceInfo
+	for res, err := range session.Resources(ctx, nil) {
+		if err != nil {
+			return 0, err
+		}
+		found = append(found, ResourceInfo{
+			URI:         res.URI,
+			Name:        res.Name,
+			Description: res.Description,
+			MIMEType:    res.MIMEType,
+			Server:      srv.Name,
+			Exposed:     srv.ExposesResource(res.URI),
+		})
+	}
+
+	m.mu.Lock()
+	m.resources = append(m.resources, found...)
+	m.mu.Unlock()
+	return len(found), nil
+}
+
+// registerResourceTools registers the built-in resource tools if any resource
+// is exposed to the LLM. It fails if a server already has a tool by their name.
+func (m *MCPManager) registerResourceTools() error {
+	if len(m.GetExposedResources()) == 0 {
+		return nil
+	}
+	for _, tool := range resourceTools {
+		if err := m.registerTool(builtinServer, tool.Name, tool); err != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.builtins[tool.Name] = true
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// GetResourcesInfo returns every resource the MCP servers offer, exposed or not.
+func (m *MCPManager) GetResourcesInfo() []ResourceInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]ResourceInfo{}, m.resources...)
+}
+
+// GetExposedResources returns the resources the LLM may list and read.
+func (m *MCPManager) GetExposedResources() []ResourceInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var exposed []ResourceInfo
+	for _, res := range m.resources {
+		if res.Exposed {
+			exposed = append(exposed, res)
+		}
+	}
+	return exposed
+}
+
+// callBuiltin executes a built-in resource tool.
+func (m *MCPManager) callBuiltin(ctx context.Context, name string, args json.RawMessage) (*ToolResult, error) {
+	switch name {
+	case ListResourcesTool:
+		data, err := json.Marshal(m.GetExposedResources())
+		if err != nil {
+			return nil, err
+		}
+		return &ToolResult{Text: string(data), Content: []ToolContent{{Type: ContentStructured, Text: string(data)}}}, nil
+	case ReadResourceTool:
+		var in struct {
+			URI string `json:"uri"`
+		}
+		if len(args) > 0 {
+			if err := json.Unmarshal(args, &in); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		return m.readResource(ctx, in.URI)
+	}
+	return nil, fmt.Errorf("unknown tool: %s", name)
+}
+
+// readResource reads an exposed resource from its server.
+func (m *MCPManager) readResource(ctx context.Context, uri string) (*ToolResult, error) {
+	var server string
+	for _, res := range m.GetExposedResources() {
+		if res.URI == uri {
+			server = res.Server
+			break
+		}
+	}
+	if server == "" {
+		return nil, fmt.Errorf("resource %q is not available; list_resources shows the ones that are", uri)
+	}
+
+	m.mu.RLock()
+	session := m.sessions[server]
+	m.mu.RUnlock()
+	if session == nil {
+		return nil, fmt.Errorf("no session for server: %s", server)
+	}
+
+	result, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, fmt.Errorf("resource read failed: %w", err)
+	}
+
+	r := &ToolResult{}
+	var texts []string
+	for _, contents := range result.Contents {
+		text := describeResource(contents)
+		texts = append(texts, text)
+		r.Content = append(r.Content, ToolContent{Type: ContentResource, Text: text})
+	}
+	r.Text = strings.Join(texts, "\n")
+	return r, nil
+}
+
+// GetPrompt fetches a prompt from an MCP server and returns the text of its
+// messages, separated by blank lines.
+func (m *MCPManager) GetPrompt(ctx context.Context, server, name string, args map[string]string) (string, error) {
+	m.mu.RLock()
+	session := m.sessions[server]
+	m.mu.RUnlock()
+	if session == nil {
+		return "", fmt.Errorf("no session for server: %s", server)
+	}
+
+	result, err := session.GetPrompt(ctx, &mcp.GetPromptParams{Name: name, Arguments: args})
+	if err != nil {
+		return "", fmt.Errorf("prompt %s: %w", name, err)
+	}
+
+	var texts []string
+	for _, msg := range result.Messages {
+		switch c := msg.Content.(type) {
+		case *mcp.TextContent:
+			texts = append(texts, c.Text)
+		case *mcp.EmbeddedResource:
+			if c.Resource != nil && c.Resource.Text != "" {
+				texts = append(texts, c.Resource.Text)
+			}
+		}
+	}
+	if len(texts) == 0 {
+		return "", fmt.Errorf("prompt %s has no text", name)
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// errorPatterns maps error text to an error class. The SDK surfaces gRPC
+// status errors and LLM provider failures as text, so classification is
+// based on their message. Patterns are matched in order against the
+// lowercased error.
+var errorPatterns = []struct {
+	pattern string
+	class   string
+}{
+	{"code = deadlineexceeded", config.ErrorClassTimeout},
+	{"gateway timeout", config.ErrorClassTimeout},
+	{"timed out", config.ErrorClassTimeout},
+	{"code = resourceexhausted", config.ErrorClassRateLimited},
+	{"rate limit", config.ErrorClassRateLimited},
+	{"too many requests", config.ErrorClassRateLimited},
+	{"code = unavailable", config.ErrorClassUnavailable},
+	{"service unavailable", config.ErrorClassUnavailable},
+	{"bad gateway", config.ErrorClassUnavailable},
+	{"connection refused", config.ErrorClassUnavailable},
+	{"connection reset", config.ErrorClassUnavailable},
+	{"not connected", config.ErrorClassUnavailable},
+	{"code = internal", config.ErrorClassServer},
+	{"code = aborted", config.ErrorClassServer},
+	{"internal server error", config.ErrorClassServer},
+}
+
+// classifyError returns the error class of err (see config.ErrorClasses),
+// or "" if the error is permanent, such as an invalid request or a cancellation.
+func classifyError(err error) string {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+	if errors.Is(err, errStreamInterrupted) || errors.Is(err, errStreamUnsupported) {
+		return ""
+	}
+	if errors.Is(err, errCircuitOpen) {
+		return config.ErrorClassUnavailable
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return config.ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return config.ErrorClassTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, p := range errorPatterns {
+		if strings.Contains(msg, p.pattern) {
+			return p.class
+		}
+	}
+	return ""
+}
+
+// backoff returns the delay before the given retry (1-based): errors.base_backoff
+// doubled for each earlier retry, capped at errors.max_backoff.
+func (h *MessageHandler) backoff(retry int) time.Duration {
+	d := h.errorOpts.BaseBackoff
+	for i := 1; i < retry && d < h.errorOpts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, h.errorOpts.MaxBackoff)
+}
+
+// retry runs op until it succeeds, fails with an error class not listed in
+// errors.retry_on, or errors.max_retries is used up. It waits with exponential
+// backoff between attempts and stops early once ctx ends.
+func (h *MessageHandler) retry(ctx context.Context, stage string, op func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		class := classifyError(err)
+		if err == nil || !h.errorOpts.RetryOn[class] || ctx.Err() != nil {
+			return err
+		}
+		if attempt > h.errorOpts.MaxRetries {
+			if attempt > 1 {
+				return fmt.Errorf("%w (gave up after %d attempts)", err, attempt)
+			}
+			return err
+		}
+
+		delay := h.backoff(attempt)
+		h.logger.Warn("retrying after error",
+			"stage", stage,
+			"attempt", attempt,
+			"class", class,
+			"backoff_ms", delay.Milliseconds(),
+			"error", err.Error(),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return contextError(ctx, err)
+		case <-timer.C:
+		}
+	}
+}
+
+// DeadLetter is published to errors.dead_letter when a message fails processing.
+type DeadLetter struct {
+	TraceID     string    `json:"trace_id"`
+	SourceTopic string    `json:"source_topic"`
+	Stage       string    `json:"stage"` // "llm", "tool" or "publish"
+	Error       string    `json:"error"`
+	Payload     string    `json:"payload"` // Original message data
+	FailedAt    time.Time `json:"failed_at"`
+}
+
+// deadLetter sends a failed message to errors.dead_letter, if configured.
+// It runs after the message's own budget may have expired, so it publishes
+// with an afterMessage context.
+func (h *MessageHandler) deadLetter(ctx context.Context, traceID string, msg athyr.SubscribeMessage, stage string, failure error) {
+	topic := h.errorOpts.DeadLetter
+	if topic == "" {
+		return
+	}
+
+	data, err := json.Marshal(DeadLetter{
+		TraceID:     traceID,
+		SourceTopic: msg.Subject,
+		Stage:       stage,
+		Error:       failure.Error(),
+		Payload:     string(msg.Data),
+		FailedAt:    time.Now(),
+	})
+	if err != nil {
+		h.logger.Error("failed to marshal dead letter", "trace_id", traceID, "error", err)
+		return
+	}
+
+	if h.plugins != nil && h.plugins.IsPlugin(topic) {
+		err = h.plugins.Publish(topic, string(data))
+	} else {
+		ctx, cancel := afterMessage(ctx)
+		defer cancel()
+		err = h.publish(ctx, topic, data)
+	}
+	if err != nil {
+		h.logger.Error("dead letter failed",
+			"trace_id", traceID,
+			"dead_letter", topic,
+			"error", err.Error(),
+		)
+		return
+	}
+	h.logger.Warn("message dead-lettered",
+		"trace_id", traceID,
+		"dead_letter", topic,
+		"stage", stage,
+	)
+}
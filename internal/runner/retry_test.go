@@ -0,0 +1,243 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"deadline", fmt.Errorf("complete: %w", context.DeadlineExceeded), config.ErrorClassTimeout},
+		{"cancelled", context.Canceled, ""},
+		{"grpc deadline", errors.New("rpc error: code = DeadlineExceeded desc = slow"), config.ErrorClassTimeout},
+		{"grpc unavailable", errors.New("rpc error: code = Unavailable desc = connection lost"), config.ErrorClassUnavailable},
+		{"grpc exhausted", errors.New("rpc error: code = ResourceExhausted desc = quota"), config.ErrorClassRateLimited},
+		{"provider rate limit", errors.New("provider returned 429: Rate limit exceeded"), config.ErrorClassRateLimited},
+		{"grpc internal", errors.New("rpc error: code = Internal desc = provider error"), config.ErrorClassServer},
+		{"invalid request", errors.New("rpc error: code = InvalidArgument desc = unknown model"), ""},
+		{"plain", errors.New("something broke"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_Backoff(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Errors: config.ErrorsConfig{BaseBackoff: "100ms", MaxBackoff: "1s"},
+		},
+	}
+	handler := newMessageHandler(cfg, &mockAgent{}, slog.Default(), nil, nil, nil)
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := handler.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func retryConfig(errorsCfg config.ErrorsConfig) *config.Config {
+	return &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Errors: errorsCfg,
+		},
+	}
+}
+
+func TestHandler_RetriesTransientLLMErrors(t *testing.T) {
+	cfg := retryConfig(config.ErrorsConfig{MaxRetries: 2, BaseBackoff: "1ms"})
+
+	calls := 0
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			calls++
+			if calls < 3 {
+				return nil, errors.New("rpc error: code = Unavailable desc = connection lost")
+			}
+			return &athyr.CompletionResponse{Content: "recovered"}, nil
+		},
+	}
+
+	bus := NewEventBus(10)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("hello")})
+
+	if calls != 3 {
+		t.Errorf("Complete called %d times, want 3", calls)
+	}
+	if event := lastProcessedEvent(t, bus); event.Outcome != OutcomeCompleted {
+		t.Errorf("Outcome = %v, want %v", event.Outcome, OutcomeCompleted)
+	}
+	if len(agent.published) != 1 || agent.published[0].Subject != "output" {
+		t.Errorf("published = %+v, want one message on output", agent.published)
+	}
+}
+
+func TestHandler_DoesNotRetryPermanentErrors(t *testing.T) {
+	cfg := retryConfig(config.ErrorsConfig{
+		MaxRetries:  3,
+		BaseBackoff: "1ms",
+		RetryOn:     []string{config.ErrorClassRateLimited},
+	})
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"permanent", errors.New("rpc error: code = InvalidArgument desc = unknown model")},
+		{"class not in retry_on", errors.New("rpc error: code = Unavailable desc = connection lost")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			agent := &mockAgent{
+				completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+					calls++
+					return nil, tt.err
+				},
+			}
+			logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+			handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+			handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("hello")})
+
+			if calls != 1 {
+				t.Errorf("Complete called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestHandler_DeadLettersAfterRetriesExhausted(t *testing.T) {
+	cfg := retryConfig(config.ErrorsConfig{
+		MaxRetries:  1,
+		BaseBackoff: "1ms",
+		DeadLetter:  "failed",
+	})
+
+	calls := 0
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			calls++
+			return nil, errors.New("rpc error: code = ResourceExhausted desc = quota exceeded")
+		},
+	}
+
+	bus := NewEventBus(10)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{
+		Subject: "input",
+		Data:    []byte(`{"session_id": "s1", "content": "hello"}`),
+	})
+
+	if calls != 2 {
+		t.Errorf("Complete called %d times, want 2", calls)
+	}
+	if event := lastProcessedEvent(t, bus); event.Outcome != OutcomeFailed {
+		t.Errorf("Outcome = %v, want %v", event.Outcome, OutcomeFailed)
+	}
+
+	if len(agent.published) != 1 || agent.published[0].Subject != "failed" {
+		t.Fatalf("published = %+v, want one dead letter on failed", agent.published)
+	}
+	var dl DeadLetter
+	if err := json.Unmarshal(agent.published[0].Data, &dl); err != nil {
+		t.Fatalf("dead letter is not JSON: %v", err)
+	}
+	if dl.TraceID == "" || dl.SourceTopic != "input" || dl.Stage != "llm" {
+		t.Errorf("dead letter = %+v, want trace ID, source topic input and stage llm", dl)
+	}
+	if dl.Payload != `{"session_id": "s1", "content": "hello"}` {
+		t.Errorf("Payload = %q, want the original message data", dl.Payload)
+	}
+	if dl.Error != "rpc error: code = ResourceExhausted desc = quota exceeded (gave up after 2 attempts)" {
+		t.Errorf("Error = %q, want the final error with attempt count", dl.Error)
+	}
+}
+
+func TestHandler_AfterMessagePublishesAreBounded(t *testing.T) {
+	cfg := retryConfig(config.ErrorsConfig{DeadLetter: "failed"})
+	cfg.Agent.Processing.PublishTimeout = "0"
+
+	type ctxKey struct{}
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			return nil, errors.New("model exploded")
+		},
+	}
+	var bounded, carried []string
+	agent.publishFunc = func(ctx context.Context, subject string, data []byte) error {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= afterMessageTimeout {
+			bounded = append(bounded, subject)
+		}
+		if ctx.Value(ctxKey{}) != nil {
+			carried = append(carried, subject)
+		}
+		return nil
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	// Without publish_timeout, the error reply and dead letter still have a deadline
+	ctx := context.WithValue(context.Background(), ctxKey{}, true)
+	handler.HandleContext(ctx, athyr.SubscribeMessage{Subject: "input", Reply: "caller", Data: []byte("hello")})
+
+	want := []string{"caller", "failed"}
+	if !slices.Equal(bounded, want) || !slices.Equal(carried, want) {
+		t.Errorf("bounded = %v, carried = %v, want both %v", bounded, carried, want)
+	}
+}
+
+func TestHandler_NoDeadLetterOnCancel(t *testing.T) {
+	cfg := retryConfig(config.ErrorsConfig{DeadLetter: "failed"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	agent := &mockAgent{
+		completeFunc: func(llmCtx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			cancel()
+			return nil, llmCtx.Err()
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	handler.HandleContext(ctx, athyr.SubscribeMessage{Subject: "input", Data: []byte("hello")})
+
+	if len(agent.published) != 0 {
+		t.Errorf("published = %+v, want no dead letter for a cancelled message", agent.published)
+	}
+}
@@ -78,6 +78,20 @@ func (r *Runner) Run(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("invalid connection config: %w", err)
 	}
+	procOpts, err := r.cfg.Agent.Processing.GetOptions()
+	if err != nil {
+		return fmt.Errorf("invalid processing config: %w", err)
+	}
+	if _, err := r.cfg.Agent.Errors.GetOptions(); err != nil {
+		return fmt.Errorf("invalid errors config: %w", err)
+	}
+	if _, err := r.cfg.Agent.Memory.GetTTL(); err != nil {
+		return fmt.Errorf("invalid memory config: %w", err)
+	}
+	gracePeriod, err := r.cfg.Agent.Shutdown.GetGracePeriod()
+	if err != nil {
+		return fmt.Errorf("invalid shutdown config: %w", err)
+	}
 
 	// Create SDK agent with options
 	agentOpts := []athyr.AgentOption{
@@ -142,20 +156,47 @@ func (r *Runner) Run(ctx context.Context) error {
 	// Initialize MCP manager if servers are configured
 	var mcpMgr *MCPManager
 	if len(r.cfg.Agent.MCP.Servers) > 0 {
+		var instructionsFrom string
+		if from := r.cfg.Agent.InstructionsFrom; from.IsSet() {
+			instructionsFrom = from.Server + "/" + from.Prompt
+		}
+		toolsAvailable := func() ToolsAvailableEvent {
+			return ToolsAvailableEvent{
+				Time:             time.Now(),
+				Tools:            mcpMgr.GetToolsInfo(),
+				Resources:        mcpMgr.GetResourcesInfo(),
+				InstructionsFrom: instructionsFrom,
+			}
+		}
+
 		mcpMgr = NewMCPManager(r.logger)
+		mcpMgr.SetStatusHandler(func(e MCPServerEvent) {
+			r.emitEvent(e)
+			// A server's tools go away while it's down and may change on restart
+			if e.Status == MCPServerDown || e.Status == MCPServerUp && e.Restarts > 0 {
+				r.emitEvent(toolsAvailable())
+			}
+		})
 		if err := mcpMgr.Start(ctx, r.cfg.Agent.MCP.Servers); err != nil {
 			return fmt.Errorf("failed to start MCP manager: %w", err)
 		}
 		defer mcpMgr.Close()
 
 		tools := mcpMgr.GetAthyrTools()
-		r.logger.Info("MCP tools available", "count", len(tools))
+		r.logger.Info("MCP tools available", "count", len(tools), "resources", len(mcpMgr.GetExposedResources()))
+
+		// The prompt stands in for agent.instructions from here on
+		if from := r.cfg.Agent.InstructionsFrom; from.IsSet() {
+			instructions, err := mcpMgr.GetPrompt(ctx, from.Server, from.Prompt, from.Args)
+			if err != nil {
+				return fmt.Errorf("failed to load instructions from MCP server %s: %w", from.Server, err)
+			}
+			r.cfg.Agent.Instructions = instructions
+			r.logger.Info("instructions loaded from MCP prompt", "server", from.Server, "prompt", from.Prompt, "bytes", len(instructions))
+		}
 
 		// Emit tools available event for TUI
-		r.emitEvent(ToolsAvailableEvent{
-			Time:  time.Now(),
-			Tools: mcpMgr.GetToolsInfo(),
-		})
+		r.emitEvent(toolsAvailable())
 	}
 	r.mcp = mcpMgr
 
@@ -176,14 +217,57 @@ func (r *Runner) Run(ctx context.Context) error {
 	handler := newMessageHandler(r.cfg, agent, r.logger, mcpMgr, pluginMgr, r.eventBus)
 	r.handler = handler
 
+	// Resume the server sessions of the previous run
+	if r.cfg.Agent.Memory.Enabled {
+		if path, err := r.cfg.Agent.Memory.GetStorePath(r.cfg.Agent.Name); err != nil {
+			r.logger.Warn("session persistence disabled", "error", err)
+		} else if expired, evicted, err := handler.sessions.open(path); err != nil {
+			r.logger.Warn("failed to load sessions, keeping them in memory only", "path", path, "error", err)
+		} else {
+			r.logger.Info("session store opened", "path", path, "sessions", handler.sessions.len())
+
+			// Sessions that expired or no longer fit while the agent was stopped
+			for _, rec := range expired {
+				r.logger.Info("session expired", "user_session_id", rec.UserSessionID, "server_session_id", rec.ServerSessionID)
+				handler.dropSession(ctx, SessionExpired, rec)
+			}
+			for _, rec := range evicted {
+				r.logger.Info("session evicted", "user_session_id", rec.UserSessionID, "server_session_id", rec.ServerSessionID)
+				handler.dropSession(ctx, SessionEvicted, rec)
+			}
+
+			// Turns are saved in the background and once more after draining
+			go handler.sessions.flushEvery(ctx, sessionFlushInterval)
+			defer handler.sessions.flush()
+		}
+	}
+
+	// Process messages on a bounded worker pool, in order per session.
+	// Handlers run on a work context that outlives ctx by the shutdown grace
+	// period, so in-flight messages can finish before their calls are cancelled.
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	dispatcher := newDispatcher(procOpts.MaxConcurrency, procOpts.QueueSize, handler.HandleContext, r.logger, r.eventBus)
+	dispatcher.start(workCtx)
+	defer func() {
+		cancelWork()
+		dispatcher.wait()
+	}()
+
+	handle := func(msg athyr.SubscribeMessage) {
+		if !dispatcher.submit(ctx, msg) {
+			r.logger.Warn("message dropped during shutdown", "topic", msg.Subject)
+		}
+	}
+
 	// Subscribe to configured topics
+	var subs []athyr.Subscription
 	for _, topic := range r.cfg.Agent.Topics.Subscribe {
 		if pluginMgr != nil && pluginMgr.IsPlugin(topic) {
 			// Plugin source: start the plugin's subscribe function
 			topicCopy := topic
 			r.logger.Info("starting plugin subscribe", "plugin", topicCopy)
 			if err := pluginMgr.StartSubscribe(topicCopy, func(data string) {
-				handler.Handle(athyr.SubscribeMessage{
+				handle(athyr.SubscribeMessage{
 					Subject: topicCopy,
 					Data:    []byte(data),
 				})
@@ -193,13 +277,25 @@ func (r *Runner) Run(ctx context.Context) error {
 		} else {
 			// Athyr topic: subscribe via SDK agent
 			r.logger.Info("subscribing to topic", "topic", topic)
-			_, err := agent.Subscribe(ctx, topic, handler.Handle)
+			sub, err := agent.Subscribe(ctx, topic, handle)
 			if err != nil {
 				return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
 			}
+			subs = append(subs, sub)
 		}
 	}
 
+	// Session control messages share the worker pool, so they wait for the
+	// messages of the session they act on
+	if topic := r.cfg.Agent.Memory.ControlTopic; topic != "" {
+		r.logger.Info("subscribing to session control topic", "topic", topic)
+		sub, err := agent.Subscribe(ctx, topic, handle)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+		subs = append(subs, sub)
+	}
+
 	r.logger.Info("agent running",
 		"name", r.cfg.Agent.Name,
 		"subscriptions", r.cfg.Agent.Topics.Subscribe,
@@ -208,9 +304,47 @@ func (r *Runner) Run(ctx context.Context) error {
 	// Wait for shutdown signal
 	<-ctx.Done()
 
+	r.drain(subs, pluginMgr, dispatcher, gracePeriod)
+	cancelWork()
 	return nil
 }
 
+// drain stops message intake and gives in-flight messages up to gracePeriod
+// to finish before their work is cancelled. MCP sessions, plugins and the
+// agent connection are closed afterwards by Run's deferred calls.
+func (r *Runner) drain(subs []athyr.Subscription, pluginMgr *plugin.Manager, dispatcher *dispatcher, gracePeriod time.Duration) {
+	r.logger.Info("shutting down, draining messages", "grace_period", gracePeriod.String())
+	start := time.Now()
+
+	// Stop taking new messages from Athyr topics and plugin sources
+	for _, sub := range subs {
+		if sub == nil {
+			continue
+		}
+		if err := sub.Unsubscribe(); err != nil {
+			r.logger.Warn("failed to unsubscribe", "error", err)
+		}
+	}
+	if pluginMgr != nil {
+		stopCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+		if err := pluginMgr.StopSubscriptions(stopCtx); err != nil {
+			r.logger.Warn("failed to stop plugin sources", "error", err)
+		}
+		cancel()
+	}
+
+	// Let queued and in-flight messages finish publishing
+	remaining := gracePeriod - time.Since(start)
+	if abandoned := dispatcher.drain(max(remaining, 0)); abandoned > 0 {
+		r.logger.Warn("grace period expired, abandoning messages",
+			"abandoned", abandoned,
+			"grace_period", gracePeriod.String(),
+		)
+	} else {
+		r.logger.Info("drain complete", "duration_ms", time.Since(start).Milliseconds())
+	}
+}
+
 // sdkLogger adapts slog.Logger to the athyr.Logger interface.
 type sdkLogger struct {
 	logger *slog.Logger
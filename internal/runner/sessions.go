@@ -0,0 +1,316 @@
+package runner
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SessionRecord maps a user session ID to the Athyr server session backing it.
+type SessionRecord struct {
+	UserSessionID   string    `json:"user_session_id"`
+	ServerSessionID string    `json:"server_session_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastUsed        time.Time `json:"last_used"`
+	Turns           int       `json:"turns"` // Messages processed in this session
+}
+
+// sessionStore tracks user → server session mappings. Sessions idle longer
+// than the TTL expire, and the least recently used session is evicted once
+// the store is full. When opened with a path, the sessions are written to
+// that file so a restarted agent resumes the same server sessions: at once
+// when a mapping is created, reset or removed, and by flush for turns.
+type sessionStore struct {
+	ttl    time.Duration // 0 = never expire
+	max    int
+	logger *slog.Logger
+	now    func() time.Time // Replaced in tests
+
+	mu      sync.Mutex
+	path    string                   // "" = in memory only
+	dirty   bool                     // Turns not yet written to path
+	entries map[string]*list.Element // user session ID → element in lru
+	lru     *list.List               // *SessionRecord, most recently used first
+}
+
+// newSessionStore creates an empty in-memory session store.
+func newSessionStore(ttl time.Duration, max int, logger *slog.Logger) *sessionStore {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &sessionStore{
+		ttl:     ttl,
+		max:     max,
+		logger:  logger,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// open loads the sessions persisted at path and saves later changes there.
+// A missing file starts an empty store. Expired sessions and sessions beyond
+// the cap aren't loaded; they are returned so their histories can be deleted.
+func (s *sessionStore) open(path string) (expired, evicted []SessionRecord, err error) {
+	records, err := ReadSessionFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+	for _, rec := range records {
+		switch {
+		case s.expiredLocked(rec):
+			expired = append(expired, rec)
+		case s.lru.Len() >= s.max:
+			evicted = append(evicted, rec)
+		default:
+			s.entries[rec.UserSessionID] = s.lru.PushBack(&rec)
+		}
+	}
+	if len(expired) > 0 || len(evicted) > 0 {
+		s.saveLocked()
+	}
+	return expired, evicted, nil
+}
+
+// ReadSessionFile reads the session records persisted by a running agent,
+// most recently used first.
+func ReadSessionFile(path string) ([]SessionRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []SessionRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid session file %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// WriteSessionFile replaces the session records persisted at path. It is used
+// to edit the sessions of an agent that isn't running.
+func WriteSessionFile(path string, records []SessionRecord) error {
+	return writeFileAtomic(path, records)
+}
+
+// touch returns the server session ID for userID and records another turn.
+// It reports false if the session isn't tracked. The turn is written to the
+// store file by the next save or flush, so workers don't wait on the disk.
+func (s *sessionStore) touch(userID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[userID]
+	if !ok {
+		return "", false
+	}
+	rec := el.Value.(*SessionRecord)
+	rec.LastUsed = s.now()
+	rec.Turns++
+	s.lru.MoveToFront(el)
+	s.dirty = true
+	return rec.ServerSessionID, true
+}
+
+// add tracks a new session and returns the sessions evicted to make room.
+func (s *sessionStore) add(userID, serverID string) []SessionRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rec := &SessionRecord{
+		UserSessionID:   userID,
+		ServerSessionID: serverID,
+		CreatedAt:       now,
+		LastUsed:        now,
+		Turns:           1,
+	}
+	if el, ok := s.entries[userID]; ok {
+		s.lru.Remove(el)
+	}
+	s.entries[userID] = s.lru.PushFront(rec)
+
+	var evicted []SessionRecord
+	for s.lru.Len() > s.max {
+		evicted = append(evicted, s.removeLocked(s.lru.Back()))
+	}
+	s.saveLocked()
+	return evicted
+}
+
+// expire removes and returns the sessions idle longer than the TTL.
+func (s *sessionStore) expire() []SessionRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []SessionRecord
+	// The least recently used sessions are at the back
+	for el := s.lru.Back(); el != nil && s.expiredLocked(*el.Value.(*SessionRecord)); el = s.lru.Back() {
+		expired = append(expired, s.removeLocked(el))
+	}
+	if len(expired) > 0 {
+		s.saveLocked()
+	}
+	return expired
+}
+
+// list returns the tracked sessions, most recently used first.
+func (s *sessionStore) list() []SessionRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]SessionRecord, 0, s.lru.Len())
+	for el := s.lru.Front(); el != nil; el = el.Next() {
+		records = append(records, *el.Value.(*SessionRecord))
+	}
+	return records
+}
+
+// get returns the session tracked for userID without counting a turn.
+func (s *sessionStore) get(userID string) (SessionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[userID]
+	if !ok {
+		return SessionRecord{}, false
+	}
+	return *el.Value.(*SessionRecord), true
+}
+
+// remove stops tracking userID and returns its session.
+func (s *sessionStore) remove(userID string) (SessionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[userID]
+	if !ok {
+		return SessionRecord{}, false
+	}
+	rec := s.removeLocked(el)
+	s.saveLocked()
+	return rec, true
+}
+
+// reset points userID at a fresh server session and clears its turn count.
+// It returns the session that was replaced.
+func (s *sessionStore) reset(userID, serverID string) (SessionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[userID]
+	if !ok {
+		return SessionRecord{}, false
+	}
+	rec := el.Value.(*SessionRecord)
+	old := *rec
+	now := s.now()
+	rec.ServerSessionID = serverID
+	rec.CreatedAt = now
+	rec.LastUsed = now
+	rec.Turns = 0
+	s.lru.MoveToFront(el)
+	s.saveLocked()
+	return old, true
+}
+
+// flush writes turns recorded since the last save to the store file.
+func (s *sessionStore) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dirty {
+		s.saveLocked()
+	}
+}
+
+// sessionFlushInterval is how often turns are written to the store file.
+const sessionFlushInterval = 30 * time.Second
+
+// flushEvery flushes the store every interval until ctx is done.
+func (s *sessionStore) flushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+// len returns the number of tracked sessions.
+func (s *sessionStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// expiredLocked reports whether rec has been idle longer than the TTL.
+func (s *sessionStore) expiredLocked(rec SessionRecord) bool {
+	return s.ttl > 0 && s.now().Sub(rec.LastUsed) > s.ttl
+}
+
+// removeLocked stops tracking the session held by el.
+func (s *sessionStore) removeLocked(el *list.Element) SessionRecord {
+	rec := s.lru.Remove(el).(*SessionRecord)
+	delete(s.entries, rec.UserSessionID)
+	return *rec
+}
+
+// saveLocked writes the sessions to the store file, if any. The file is
+// replaced atomically so a crash never leaves it half-written. Failures are
+// logged; the in-memory mapping stays authoritative.
+func (s *sessionStore) saveLocked() {
+	if s.path == "" {
+		return
+	}
+	s.dirty = false
+
+	records := make([]SessionRecord, 0, s.lru.Len())
+	for el := s.lru.Front(); el != nil; el = el.Next() {
+		records = append(records, *el.Value.(*SessionRecord))
+	}
+
+	if err := writeFileAtomic(s.path, records); err != nil {
+		s.logger.Warn("failed to save sessions", "path", s.path, "error", err)
+	}
+}
+
+// writeFileAtomic writes v as JSON to path via a temporary file and rename.
+func writeFileAtomic(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,235 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+func TestSessionStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	s := newSessionStore(0, 2, nil)
+
+	s.add("a", "srv-a")
+	s.add("b", "srv-b")
+	s.touch("a") // b is now the least recently used
+
+	evicted := s.add("c", "srv-c")
+	if len(evicted) != 1 || evicted[0].UserSessionID != "b" {
+		t.Fatalf("evicted = %+v, want session b", evicted)
+	}
+	if _, ok := s.touch("b"); ok {
+		t.Error("touch(b) found an evicted session")
+	}
+	for _, id := range []string{"a", "c"} {
+		if _, ok := s.touch(id); !ok {
+			t.Errorf("touch(%s) = false, want session kept", id)
+		}
+	}
+}
+
+func TestSessionStore_ExpiresIdleSessions(t *testing.T) {
+	now := time.Now()
+	s := newSessionStore(time.Hour, 10, nil)
+	s.now = func() time.Time { return now }
+
+	s.add("old", "srv-old")
+	now = now.Add(30 * time.Minute)
+	s.add("recent", "srv-recent")
+
+	now = now.Add(45 * time.Minute) // old idle 75m, recent idle 45m
+	expired := s.expire()
+	if len(expired) != 1 || expired[0].UserSessionID != "old" {
+		t.Fatalf("expired = %+v, want session old", expired)
+	}
+	if got := s.len(); got != 1 {
+		t.Errorf("len() = %d, want 1", got)
+	}
+}
+
+func TestSessionStore_TouchCountsTurns(t *testing.T) {
+	s := newSessionStore(0, 10, nil)
+	s.add("a", "srv-a")
+
+	serverID, ok := s.touch("a")
+	if !ok || serverID != "srv-a" {
+		t.Fatalf("touch(a) = %q, %v, want srv-a", serverID, ok)
+	}
+	s.touch("a")
+
+	rec := s.entries["a"].Value.(*SessionRecord)
+	if rec.Turns != 3 {
+		t.Errorf("Turns = %d, want 3", rec.Turns)
+	}
+}
+
+func TestSessionStore_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions", "agent.json")
+
+	s := newSessionStore(time.Hour, 10, nil)
+	if _, _, err := s.open(path); err != nil {
+		t.Fatalf("open() on missing file error = %v", err)
+	}
+	s.add("a", "srv-a")
+	s.add("b", "srv-b")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("session file not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("session file mode = %o, want 600", perm)
+	}
+
+	restarted := newSessionStore(time.Hour, 10, nil)
+	if _, _, err := restarted.open(path); err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	if serverID, ok := restarted.touch("a"); !ok || serverID != "srv-a" {
+		t.Errorf("touch(a) after restart = %q, %v, want srv-a", serverID, ok)
+	}
+
+	// Turns are only written by a flush
+	records, err := ReadSessionFile(path)
+	if err != nil {
+		t.Fatalf("ReadSessionFile() error = %v", err)
+	}
+	if len(records) != 2 || records[0].UserSessionID != "b" {
+		t.Errorf("records before flush = %+v, want b then a", records)
+	}
+
+	restarted.flush()
+	records, err = ReadSessionFile(path)
+	if err != nil {
+		t.Fatalf("ReadSessionFile() error = %v", err)
+	}
+	if len(records) != 2 || records[0].UserSessionID != "a" || records[0].Turns != 2 {
+		t.Errorf("records = %+v, want a (most recently used, 2 turns) then b", records)
+	}
+}
+
+func TestSessionStore_OpenDropsExpiredSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	now := time.Now()
+
+	s := newSessionStore(time.Hour, 10, nil)
+	s.now = func() time.Time { return now }
+	s.open(path)
+	s.add("stale", "srv-stale")
+	now = now.Add(90 * time.Minute)
+	s.add("fresh", "srv-fresh")
+	now = now.Add(time.Minute)
+	s.add("newest", "srv-newest")
+
+	// Reopened with room for one session, after stale's TTL ran out
+	restarted := newSessionStore(time.Hour, 1, nil)
+	restarted.now = func() time.Time { return now }
+	expired, evicted, err := restarted.open(path)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	if len(expired) != 1 || expired[0].ServerSessionID != "srv-stale" {
+		t.Errorf("expired = %+v, want srv-stale returned for deletion", expired)
+	}
+	if len(evicted) != 1 || evicted[0].ServerSessionID != "srv-fresh" {
+		t.Errorf("evicted = %+v, want srv-fresh returned for deletion", evicted)
+	}
+	if _, ok := restarted.get("newest"); !ok || restarted.len() != 1 {
+		t.Errorf("len() = %d, want only the newest session loaded", restarted.len())
+	}
+}
+
+func TestHandler_RecreatesExpiredSession(t *testing.T) {
+	cfg := &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Memory: config.MemoryConfig{
+				Enabled: true,
+				TTL:     "1h",
+			},
+		},
+	}
+
+	created := 0
+	var deleted []string
+	agent := &sessionAgent{
+		create: func() string {
+			created++
+			return []string{"", "srv-1", "srv-2"}[created]
+		},
+		delete: func(id string) { deleted = append(deleted, id) },
+	}
+
+	bus := NewEventBus(100)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, bus)
+
+	now := time.Now()
+	handler.sessions.now = func() time.Time { return now }
+
+	msg := athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "u1", "content": "hi"}`)}
+	handler.Handle(msg)
+	now = now.Add(30 * time.Minute)
+	handler.Handle(msg) // Within TTL: reuses srv-1
+	now = now.Add(2 * time.Hour)
+	handler.Handle(msg) // Idle past TTL: expires srv-1 and creates srv-2
+
+	if created != 2 {
+		t.Errorf("created %d server sessions, want 2", created)
+	}
+	if len(deleted) != 1 || deleted[0] != "srv-1" {
+		t.Errorf("deleted = %v, want srv-1", deleted)
+	}
+	if got := agent.lastSessionID; got != "srv-2" {
+		t.Errorf("last request SessionID = %q, want srv-2", got)
+	}
+
+	var actions []SessionAction
+	for len(bus.Events()) > 0 {
+		if e, ok := (<-bus.Events()).(SessionEvent); ok {
+			actions = append(actions, e.Action)
+		}
+	}
+	want := []SessionAction{SessionCreated, SessionExpired, SessionCreated}
+	if len(actions) != len(want) {
+		t.Fatalf("session events = %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("session event %d = %v, want %v", i, actions[i], want[i])
+		}
+	}
+}
+
+// sessionAgent is a mockAgent that tracks server session lifecycle.
+type sessionAgent struct {
+	mockAgent
+	create        func() string
+	delete        func(id string)
+	lastSessionID string
+}
+
+func (a *sessionAgent) CreateSession(ctx context.Context, profile athyr.SessionProfile, systemPrompt string) (*athyr.Session, error) {
+	return &athyr.Session{ID: a.create()}, nil
+}
+
+func (a *sessionAgent) DeleteSession(ctx context.Context, sessionID string) error {
+	a.delete(sessionID)
+	return nil
+}
+
+func (a *sessionAgent) Complete(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+	a.lastSessionID = req.SessionID
+	return &athyr.CompletionResponse{Content: "ok"}, nil
+}
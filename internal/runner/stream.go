@@ -0,0 +1,239 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+var (
+	// errStreamUnsupported is returned when the server can't stream completions.
+	errStreamUnsupported = errors.New("streaming not supported")
+
+	// errStreamInterrupted is returned when a stream fails after chunks were
+	// sent. Retrying or falling back would repeat them, so it is permanent.
+	errStreamInterrupted = errors.New("stream interrupted")
+)
+
+// unsupportedPatterns identify a server or provider without streaming support.
+var unsupportedPatterns = []string{"code = unimplemented", "not implemented", "not supported"}
+
+// ResponseChunk is published to <topic>.stream while a response is generated.
+// Chunks of one response share a trace ID and arrive with increasing sequence
+// numbers; the last one has Done set and no content.
+type ResponseChunk struct {
+	TraceID      string `json:"trace_id"`
+	SessionID    string `json:"session_id,omitempty"` // From the incoming message, if any
+	SourceTopic  string `json:"source_topic"`
+	Seq          int    `json:"seq"` // 1-based
+	Content      string `json:"content,omitempty"`
+	Done         bool   `json:"done,omitempty"`
+	Model        string `json:"model,omitempty"`         // Set on the final chunk
+	FinishReason string `json:"finish_reason,omitempty"` // Set on the final chunk
+	Error        string `json:"error,omitempty"`         // Set on the final chunk if processing failed
+}
+
+// responseStream publishes the chunks of one response to the streams of the
+// topics the response is published to. With routes, those are only known once
+// the response is complete, so chunks are held until then.
+type responseStream struct {
+	h      *MessageHandler
+	ctx    context.Context
+	topics []string        // <topic>.stream subjects
+	held   []ResponseChunk // Chunks waiting for the route, if routed
+	routed bool
+	chunk  ResponseChunk
+}
+
+// newResponseStream returns a stream for a message's response, or nil if
+// streaming is disabled or there is no Athyr topic to stream to.
+func (h *MessageHandler) newResponseStream(ctx context.Context, traceID, sessionID string, msg athyr.SubscribeMessage) *responseStream {
+	if !h.cfg.Agent.Streaming.Enabled {
+		return nil
+	}
+	routed := h.cfg.Agent.Topics.HasRoutes()
+	topics := h.streamTopics(h.cfg.Agent.Topics.Publish)
+	if len(topics) == 0 && !routed {
+		return nil
+	}
+	return &responseStream{
+		h:      h,
+		ctx:    ctx,
+		topics: topics,
+		routed: routed,
+		chunk: ResponseChunk{
+			TraceID:     traceID,
+			SessionID:   sessionID,
+			SourceTopic: msg.Subject,
+		},
+	}
+}
+
+// streamTopics returns the stream subjects of the Athyr topics among targets.
+func (h *MessageHandler) streamTopics(targets []string) []string {
+	var topics []string
+	for _, topic := range targets {
+		if h.plugins == nil || !h.plugins.IsPlugin(topic) {
+			topics = append(topics, topic+".stream")
+		}
+	}
+	return topics
+}
+
+// send publishes the next piece of the response. Failures are logged; the
+// full response is still published when the message completes.
+func (s *responseStream) send(content string) {
+	s.chunk.Content = content
+	s.publish()
+}
+
+// done publishes the final chunk, carrying the error if processing failed,
+// to the streams of targets, the topics the response goes to. Chunks held
+// for the route are sent there first.
+func (s *responseStream) done(targets []string, resp *athyr.CompletionResponse, err error) {
+	// The message's budget may have run out, but the marker must still go out
+	s.ctx = context.WithoutCancel(s.ctx)
+	if s.routed {
+		s.routed = false
+		s.topics = s.h.streamTopics(targets)
+		for _, chunk := range s.held {
+			s.sendChunk(chunk)
+		}
+		s.held = nil
+	}
+
+	s.chunk.Content = ""
+	s.chunk.Done = true
+	if resp != nil {
+		s.chunk.Model = resp.Model
+		s.chunk.FinishReason = resp.FinishReason
+	}
+	if err != nil {
+		s.chunk.Error = err.Error()
+	}
+	s.publish()
+}
+
+func (s *responseStream) publish() {
+	s.chunk.Seq++
+	if s.routed {
+		s.held = append(s.held, s.chunk)
+		return
+	}
+	s.sendChunk(s.chunk)
+}
+
+// sendChunk publishes a chunk to the stream topics.
+func (s *responseStream) sendChunk(chunk ResponseChunk) {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		s.h.logger.Error("failed to marshal stream chunk", "trace_id", chunk.TraceID, "error", err)
+		return
+	}
+	for _, topic := range s.topics {
+		if err := s.h.publish(s.ctx, topic, data); err != nil {
+			s.h.logger.Warn("stream chunk failed",
+				"trace_id", chunk.TraceID,
+				"topic", topic,
+				"seq", chunk.Seq,
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
+// completeStream executes a completion, passing content to emit as it is
+// generated. Requests with tools, and servers without streaming support,
+// are completed in one call and the content is emitted in chunks of
+// streaming.chunk_size afterwards.
+func (h *MessageHandler) completeStream(ctx context.Context, req athyr.CompletionRequest, emit func(string)) (*athyr.CompletionResponse, error) {
+	if len(req.Tools) == 0 {
+		resp, err := h.streamWith(ctx, req, emit)
+		if !errors.Is(err, errStreamUnsupported) {
+			return resp, err
+		}
+		h.logger.Debug("streaming unavailable, simulating", "model", req.Model, "error", err.Error())
+	}
+
+	resp, err := h.completeWith(ctx, req, nil)
+	if err == nil && len(resp.ToolCalls) == 0 {
+		simulateStream(resp.Content, h.cfg.Agent.Streaming.GetChunkSize(), emit)
+	}
+	return resp, err
+}
+
+// streamWith executes a streaming completion, allowing processing.llm_timeout
+// per attempt. Attempts are retried only until the first chunk is emitted.
+func (h *MessageHandler) streamWith(ctx context.Context, req athyr.CompletionRequest, emit func(string)) (*athyr.CompletionResponse, error) {
+	var resp *athyr.CompletionResponse
+	streamed := false
+	err := h.retry(ctx, "llm", func() error {
+		llmCtx, cancel := withBudget(ctx, h.processing.LLMTimeout)
+		defer cancel()
+
+		var content strings.Builder
+		resp = &athyr.CompletionResponse{Model: req.Model}
+		err := h.agent.CompleteStream(llmCtx, req, func(chunk athyr.StreamChunk) error {
+			if chunk.Content != "" {
+				streamed = true
+				content.WriteString(chunk.Content)
+				emit(chunk.Content)
+			}
+			if chunk.Model != "" {
+				resp.Model = chunk.Model
+			}
+			if chunk.FinishReason != "" {
+				resp.FinishReason = chunk.FinishReason
+			}
+			if chunk.Usage != nil {
+				resp.Usage = *chunk.Usage
+			}
+			return nil
+		})
+		resp.Content = content.String()
+
+		err = contextError(llmCtx, err)
+		switch {
+		case err == nil:
+			return nil
+		case streamed:
+			return fmt.Errorf("%w: %w", errStreamInterrupted, err)
+		case isStreamUnsupported(err):
+			return fmt.Errorf("%w: %w", errStreamUnsupported, err)
+		default:
+			return err
+		}
+	})
+	return resp, err
+}
+
+// isStreamUnsupported reports whether err says streaming isn't available.
+func isStreamUnsupported(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, p := range unsupportedPatterns {
+		if strings.Contains(msg, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// simulateStream emits content in chunks of size characters.
+func simulateStream(content string, size int, emit func(string)) {
+	runes := []rune(content)
+	for len(runes) > 0 {
+		n := min(size, len(runes))
+		emit(string(runes[:n]))
+		runes = runes[n:]
+	}
+}
+
+// chatStream emits Chat tab response content as ChatChunkEvents.
+func (h *MessageHandler) chatStream(exchange int, content string) {
+	h.emitEvent(ChatChunkEvent{Time: time.Now(), Exchange: exchange, Content: content})
+}
@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// streamAgent is a mockAgent whose CompleteStream is scripted.
+type streamAgent struct {
+	mockAgent
+	stream      func(handler athyr.StreamHandler) error
+	streamCalls int
+}
+
+func (a *streamAgent) CompleteStream(ctx context.Context, req athyr.CompletionRequest, handler athyr.StreamHandler) error {
+	a.streamCalls++
+	return a.stream(handler)
+}
+
+func streamConfig(chunkSize int) *config.Config {
+	cfg := retryConfig(config.ErrorsConfig{MaxRetries: 2, BaseBackoff: "1ms"})
+	cfg.Agent.Streaming = config.StreamingConfig{Enabled: true, ChunkSize: chunkSize}
+	return cfg
+}
+
+// streamChunks decodes the chunks published to subject.
+func streamChunks(t *testing.T, published []publishCall, subject string) []ResponseChunk {
+	t.Helper()
+	var chunks []ResponseChunk
+	for _, p := range published {
+		if p.Subject != subject {
+			continue
+		}
+		var chunk ResponseChunk
+		if err := json.Unmarshal(p.Data, &chunk); err != nil {
+			t.Fatalf("chunk is not JSON: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func TestHandler_StreamsChunks(t *testing.T) {
+	agent := &streamAgent{
+		stream: func(handler athyr.StreamHandler) error {
+			handler(athyr.StreamChunk{Content: "Hel"})
+			handler(athyr.StreamChunk{Content: "lo"})
+			handler(athyr.StreamChunk{Done: true, Model: "gpt-4", FinishReason: "stop", Usage: &athyr.Usage{TotalTokens: 7}})
+			return nil
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(streamConfig(0), agent, logger, nil, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte(`{"session_id": "s1", "content": "hi"}`)})
+
+	chunks := streamChunks(t, agent.published, "output.stream")
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	for i, want := range []string{"Hel", "lo", ""} {
+		if chunks[i].Seq != i+1 || chunks[i].Content != want {
+			t.Errorf("chunk %d = %+v, want seq %d content %q", i, chunks[i], i+1, want)
+		}
+		if chunks[i].TraceID != chunks[0].TraceID || chunks[i].SessionID != "s1" || chunks[i].SourceTopic != "input" {
+			t.Errorf("chunk %d = %+v, want shared trace ID, session s1 and source topic input", i, chunks[i])
+		}
+	}
+	if last := chunks[2]; !last.Done || last.Model != "gpt-4" || last.FinishReason != "stop" || last.Error != "" {
+		t.Errorf("final chunk = %+v, want done with model and finish reason", last)
+	}
+
+	// The full response is still published once the stream ends
+	var response Response
+	if err := json.Unmarshal(agent.published[len(agent.published)-1].Data, &response); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if response.Content != "Hello" || response.Tokens != 7 {
+		t.Errorf("response = %+v, want the streamed content and usage", response)
+	}
+}
+
+func TestHandler_StreamFollowsRoute(t *testing.T) {
+	agent := &streamAgent{
+		stream: func(handler athyr.StreamHandler) error {
+			handler(athyr.StreamChunk{Content: `{"route_to": "billing", `})
+			handler(athyr.StreamChunk{Content: `"answer": "refund issued"}`})
+			handler(athyr.StreamChunk{Done: true, Model: "gpt-4", FinishReason: "stop"})
+			return nil
+		},
+	}
+	cfg := streamConfig(0)
+	cfg.Agent.Topics.Routes = []config.RouteConfig{{Topic: "billing", Description: "Billing questions"}}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, nil, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("refund my order")})
+
+	if chunks := streamChunks(t, agent.published, "output.stream"); len(chunks) != 0 {
+		t.Errorf("got %d chunks on output.stream, want none for a routed response", len(chunks))
+	}
+	chunks := streamChunks(t, agent.published, "billing.stream")
+	if len(chunks) != 3 || chunks[0].Seq != 1 || chunks[0].Content != `{"route_to": "billing", ` || !chunks[2].Done {
+		t.Errorf("billing.stream chunks = %+v, want the whole stream in order", chunks)
+	}
+}
+
+func TestHandler_SimulatesStreamWhenUnsupported(t *testing.T) {
+	agent := &streamAgent{
+		mockAgent: mockAgent{
+			completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+				return &athyr.CompletionResponse{Content: "abcde"}, nil
+			},
+		},
+		stream: func(handler athyr.StreamHandler) error {
+			return errors.New("rpc error: code = Unimplemented desc = streaming disabled")
+		},
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(streamConfig(2), agent, logger, nil, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("hi")})
+
+	chunks := streamChunks(t, agent.published, "output.stream")
+	var got []string
+	for _, c := range chunks {
+		got = append(got, c.Content)
+	}
+	if len(chunks) != 4 || got[0] != "ab" || got[1] != "cd" || got[2] != "e" || !chunks[3].Done {
+		t.Errorf("chunks = %q, want ab, cd, e and a done marker", got)
+	}
+	if agent.streamCalls != 1 {
+		t.Errorf("CompleteStream called %d times, want 1 (unsupported is not retried)", agent.streamCalls)
+	}
+}
+
+func TestHandler_InterruptedStreamIsNotRetried(t *testing.T) {
+	agent := &streamAgent{
+		stream: func(handler athyr.StreamHandler) error {
+			handler(athyr.StreamChunk{Content: "partial"})
+			return errors.New("rpc error: code = Unavailable desc = connection lost")
+		},
+	}
+	bus := NewEventBus(10)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(streamConfig(0), agent, logger, nil, nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("hi")})
+
+	if agent.streamCalls != 1 {
+		t.Errorf("CompleteStream called %d times, want 1", agent.streamCalls)
+	}
+	chunks := streamChunks(t, agent.published, "output.stream")
+	if len(chunks) != 2 || chunks[0].Content != "partial" || !chunks[1].Done || chunks[1].Error == "" {
+		t.Errorf("chunks = %+v, want the partial chunk and a done marker with the error", chunks)
+	}
+	if event := lastProcessedEvent(t, bus); event.Outcome != OutcomeFailed {
+		t.Errorf("Outcome = %v, want %v", event.Outcome, OutcomeFailed)
+	}
+}
+
+func TestDirectChat_StreamsChunkEvents(t *testing.T) {
+	agent := &streamAgent{
+		stream: func(handler athyr.StreamHandler) error {
+			handler(athyr.StreamChunk{Content: "one "})
+			handler(athyr.StreamChunk{Content: "two"})
+			return nil
+		},
+	}
+	bus := NewEventBus(10)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(streamConfig(0), agent, logger, nil, nil, bus)
+
+	response, _, _, err := handler.DirectChat(1, "count")
+	if err != nil {
+		t.Fatalf("DirectChat failed: %v", err)
+	}
+	if response != "one two" {
+		t.Errorf("response = %q, want %q", response, "one two")
+	}
+
+	var got string
+	for len(bus.Events()) > 0 {
+		if e, ok := (<-bus.Events()).(ChatChunkEvent); ok {
+			if e.Exchange != 1 {
+				t.Errorf("chunk exchange = %d, want 1", e.Exchange)
+			}
+			got += e.Content
+		}
+	}
+	if got != "one two" {
+		t.Errorf("chunk events = %q, want %q", got, "one two")
+	}
+}
@@ -0,0 +1,254 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+)
+
+// MCPServerStatus is the state of a supervised MCP server.
+type MCPServerStatus int
+
+const (
+	MCPServerUp MCPServerStatus = iota
+	MCPServerDown
+	MCPServerRestarting
+)
+
+func (s MCPServerStatus) String() string {
+	switch s {
+	case MCPServerUp:
+		return "up"
+	case MCPServerDown:
+		return "down"
+	case MCPServerRestarting:
+		return "restarting"
+	default:
+		return "unknown"
+	}
+}
+
+// serverState tracks a supervised MCP server.
+type serverState struct {
+	status    MCPServerStatus
+	restarts  int // Restart attempts
+	restarted int // Successful restarts
+}
+
+// SetStatusHandler sets a function called whenever an MCP server's status
+// changes. Set it before Start to also hear about the initial connections.
+func (m *MCPManager) SetStatusHandler(fn func(MCPServerEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onStatus = fn
+}
+
+// setStatus records a server's status and reports it to the status handler.
+// A restart attempt counts towards the server's restarts, and coming up
+// after one towards its successful restarts.
+func (m *MCPManager) setStatus(server string, status MCPServerStatus, err error) {
+	m.mu.Lock()
+	state, ok := m.states[server]
+	if !ok {
+		state = &serverState{}
+		m.states[server] = state
+	}
+	if status == MCPServerRestarting {
+		state.restarts++
+	} else if status == MCPServerUp && state.status == MCPServerRestarting {
+		state.restarted++
+	}
+	state.status = status
+	event := MCPServerEvent{
+		Time:     time.Now(),
+		Server:   server,
+		Status:   status,
+		Restarts: state.restarts,
+		Error:    err,
+	}
+	onStatus := m.onStatus
+	m.mu.Unlock()
+
+	if onStatus != nil {
+		onStatus(event)
+	}
+}
+
+// restarted returns how many times a server was successfully restarted.
+func (m *MCPManager) restarted(server string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if state, ok := m.states[server]; ok {
+		return state.restarted
+	}
+	return 0
+}
+
+// supervise watches a server until ctx is done, restarting it whenever it
+// exits or fails a health check.
+func (m *MCPManager) supervise(ctx context.Context, srv config.MCPServerConfig) {
+	for {
+		err := m.watch(ctx, srv)
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.logger.Warn("MCP server down", "name", srv.Name, "error", err.Error())
+		m.dropServer(srv.Name)
+		m.setStatus(srv.Name, MCPServerDown, err)
+
+		if !m.restart(ctx, srv) {
+			return
+		}
+	}
+}
+
+// watch returns when the server's session ends or stops answering pings.
+func (m *MCPManager) watch(ctx context.Context, srv config.MCPServerConfig) error {
+	m.mu.RLock()
+	session := m.sessions[srv.Name]
+	m.mu.RUnlock()
+	if session == nil {
+		return errors.New("no session")
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- session.Wait() }()
+
+	// A zero interval disables pings; exits are still noticed
+	var tick <-chan time.Time
+	if interval := srv.HealthCheck.GetInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-exited:
+			if err == nil {
+				return errors.New("server exited")
+			}
+			return fmt.Errorf("server exited: %w", err)
+		case <-tick:
+			pingCtx, cancel := context.WithTimeout(ctx, srv.HealthCheck.GetTimeout())
+			err := session.Ping(pingCtx, nil)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				return fmt.Errorf("health check failed: %w", err)
+			}
+		}
+	}
+}
+
+// restart reconnects to a server with exponential backoff, rediscovering its
+// tools and resources. It reports false if ctx is done, the server's
+// restart.max_attempts attempts all failed, or it was already successfully
+// restarted restart.max_restarts times.
+func (m *MCPManager) restart(ctx context.Context, srv config.MCPServerConfig) bool {
+	if limit := srv.Restart.MaxRestarts; limit > 0 && m.restarted(srv.Name) >= limit {
+		m.logger.Error("giving up on MCP server", "name", srv.Name, "restarts", limit)
+		m.setStatus(srv.Name, MCPServerDown, fmt.Errorf("gave up after %d restarts in total", limit))
+		return false
+	}
+
+	backoff := srv.Restart.GetBaseBackoff()
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+
+		m.setStatus(srv.Name, MCPServerRestarting, nil)
+		err := m.connectServer(ctx, srv)
+		if err == nil {
+			err = m.registerResourceTools()
+		}
+		if err == nil {
+			m.logger.Info("MCP server restarted", "name", srv.Name, "attempt", attempt)
+			m.setStatus(srv.Name, MCPServerUp, nil)
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		m.dropServer(srv.Name)
+		if limit := srv.Restart.MaxAttempts; limit > 0 && attempt >= limit {
+			m.logger.Error("giving up on MCP server", "name", srv.Name, "attempts", attempt, "error", err.Error())
+			m.setStatus(srv.Name, MCPServerDown, fmt.Errorf("gave up after %d restart attempts: %w", attempt, err))
+			return false
+		}
+		m.logger.Warn("MCP server restart failed", "name", srv.Name, "attempt", attempt, "error", err.Error())
+		backoff = min(backoff*2, srv.Restart.GetMaxBackoff())
+	}
+}
+
+// dropServer closes a server's session and forgets its tools and resources,
+// so the LLM isn't offered tools that can't be called.
+func (m *MCPManager) dropServer(server string) {
+	m.mu.Lock()
+	session := m.sessions[server]
+	delete(m.sessions, server)
+	for name, src := range m.toolSrc {
+		if src == server {
+			delete(m.tools, name)
+			delete(m.toolSrc, name)
+			delete(m.toolNames, name)
+		}
+	}
+	m.resources = slices.DeleteFunc(m.resources, func(r ResourceInfo) bool { return r.Server == server })
+	m.mu.Unlock()
+
+	if session != nil {
+		_ = session.Close()
+	}
+}
+
+// stderrLogger logs each line an MCP server subprocess writes to stderr.
+type stderrLogger struct {
+	logger *slog.Logger
+	server string
+	mu     sync.Mutex
+	buf    []byte
+}
+
+// maxStderrLine caps a line kept while waiting for its newline.
+const maxStderrLine = 64 * 1024
+
+func (w *stderrLogger) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxStderrLine {
+		w.log(w.buf)
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+func (w *stderrLogger) log(line []byte) {
+	if s := strings.TrimRight(string(line), "\r"); s != "" {
+		w.logger.Info("MCP server stderr", "server", w.server, "line", s)
+	}
+}
@@ -0,0 +1,238 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// crashableServers hands out in-memory MCP servers offering a "ping_tool"
+// and can crash the latest one. Once broken, new connections fail.
+type crashableServers struct {
+	t        *testing.T
+	mu       sync.Mutex
+	sessions []*mcp.ServerSession
+	broken   bool
+}
+
+func (c *crashableServers) transport(srv config.MCPServerConfig) mcp.Transport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken {
+		return failingTransport{}
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{Name: srv.Name, Version: "1.0.0"}, nil)
+	server.AddTool(&mcp.Tool{Name: "ping_tool", InputSchema: map[string]any{"type": "object"}},
+		func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "pong"}}}, nil
+		})
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	session, err := server.Connect(context.Background(), serverTransport, nil)
+	if err != nil {
+		c.t.Fatalf("server connect failed: %v", err)
+	}
+	c.sessions = append(c.sessions, session)
+	return clientTransport
+}
+
+// crash closes the latest server's end of the connection.
+func (c *crashableServers) crash(breakRestarts bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.broken = breakRestarts
+	c.sessions[len(c.sessions)-1].Close()
+}
+
+// repair lets the next restart succeed again.
+func (c *crashableServers) repair() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.broken = false
+}
+
+// failingTransport is a transport to a server that won't start.
+type failingTransport struct{}
+
+func (failingTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	return nil, errors.New("exec: no such file")
+}
+
+// statusRecorder collects MCP server events.
+type statusRecorder struct {
+	mu     sync.Mutex
+	events []MCPServerEvent
+}
+
+func (r *statusRecorder) record(e MCPServerEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// waitFor waits until an event matches, failing the test after a second.
+func (r *statusRecorder) waitFor(t *testing.T, match func(MCPServerEvent) bool) MCPServerEvent {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		for _, e := range r.events {
+			if match(e) {
+				r.mu.Unlock()
+				return e
+			}
+		}
+		r.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no matching status event in %+v", r.events)
+	return MCPServerEvent{}
+}
+
+func TestMCPManager_RestartsCrashedServer(t *testing.T) {
+	servers := &crashableServers{t: t}
+	var status statusRecorder
+	mgr := NewMCPManager(nil)
+	mgr.transportFor = servers.transport
+	mgr.SetStatusHandler(status.record)
+	defer mgr.Close()
+
+	srv := config.MCPServerConfig{Name: "flaky", Command: []string{"flaky"}, Restart: config.MCPRestartConfig{BaseBackoff: "1ms"}}
+	if err := mgr.Start(context.Background(), []config.MCPServerConfig{srv}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	status.waitFor(t, func(e MCPServerEvent) bool { return e.Status == MCPServerUp && e.Restarts == 0 })
+
+	servers.crash(false)
+
+	status.waitFor(t, func(e MCPServerEvent) bool { return e.Status == MCPServerDown && e.Error != nil })
+	status.waitFor(t, func(e MCPServerEvent) bool { return e.Status == MCPServerUp && e.Restarts == 1 })
+
+	// The tools were rediscovered on the new session
+	result, err := mgr.CallTool(context.Background(), "ping_tool", nil)
+	if err != nil {
+		t.Fatalf("CallTool after restart failed: %v", err)
+	}
+	if result.Text != "pong" {
+		t.Errorf("CallTool() = %q, want pong", result.Text)
+	}
+}
+
+func TestMCPManager_GivesUpAfterMaxAttempts(t *testing.T) {
+	servers := &crashableServers{t: t}
+	var status statusRecorder
+	mgr := NewMCPManager(nil)
+	mgr.transportFor = servers.transport
+	mgr.SetStatusHandler(status.record)
+	defer mgr.Close()
+
+	srv := config.MCPServerConfig{
+		Name:    "flaky",
+		Command: []string{"flaky"},
+		Restart: config.MCPRestartConfig{MaxAttempts: 2, BaseBackoff: "1ms", MaxBackoff: "2ms"},
+	}
+	if err := mgr.Start(context.Background(), []config.MCPServerConfig{srv}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	servers.crash(true)
+
+	e := status.waitFor(t, func(e MCPServerEvent) bool {
+		return e.Status == MCPServerDown && e.Error != nil && strings.Contains(e.Error.Error(), "gave up after 2 restart attempts")
+	})
+	if e.Restarts != 2 {
+		t.Errorf("Restarts = %d, want 2", e.Restarts)
+	}
+	if tools := mgr.GetAthyrTools(); len(tools) != 0 {
+		t.Errorf("GetAthyrTools() = %+v, want the down server's tools gone", tools)
+	}
+}
+
+func TestStderrLogger(t *testing.T) {
+	var buf bytes.Buffer
+	w := &stderrLogger{logger: slog.New(slog.NewTextHandler(&buf, nil)), server: "files"}
+
+	w.Write([]byte("starting up\nlisten"))
+	w.Write([]byte("ing on stdio\r\n\n"))
+
+	out := buf.String()
+	for _, want := range []string{`server=files line="starting up"`, `server=files line="listening on stdio"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log = %q, want to contain %q", out, want)
+		}
+	}
+	if n := strings.Count(out, "MCP server stderr"); n != 2 {
+		t.Errorf("logged %d lines, want 2", n)
+	}
+}
+
+func TestMCPManager_GivesUpAfterMaxRestarts(t *testing.T) {
+	servers := &crashableServers{t: t}
+	var status statusRecorder
+	mgr := NewMCPManager(nil)
+	mgr.transportFor = servers.transport
+	mgr.SetStatusHandler(status.record)
+	defer mgr.Close()
+
+	srv := config.MCPServerConfig{
+		Name:    "flaky",
+		Command: []string{"flaky"},
+		Restart: config.MCPRestartConfig{MaxRestarts: 1, BaseBackoff: "1ms"},
+	}
+	if err := mgr.Start(context.Background(), []config.MCPServerConfig{srv}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	servers.crash(false)
+	status.waitFor(t, func(e MCPServerEvent) bool { return e.Status == MCPServerUp && e.Restarts == 1 })
+
+	// The restart succeeded, but the server may not be restarted again
+	servers.crash(false)
+	status.waitFor(t, func(e MCPServerEvent) bool {
+		return e.Status == MCPServerDown && e.Error != nil && strings.Contains(e.Error.Error(), "gave up after 1 restarts in total")
+	})
+	if tools := mgr.GetAthyrTools(); len(tools) != 0 {
+		t.Errorf("GetAthyrTools() = %+v, want the down server's tools gone", tools)
+	}
+}
+
+func TestMCPManager_MaxRestartsIgnoresFailedAttempts(t *testing.T) {
+	servers := &crashableServers{t: t}
+	var status statusRecorder
+	mgr := NewMCPManager(nil)
+	mgr.transportFor = servers.transport
+	mgr.SetStatusHandler(status.record)
+	defer mgr.Close()
+
+	srv := config.MCPServerConfig{
+		Name:    "flaky",
+		Command: []string{"flaky"},
+		Restart: config.MCPRestartConfig{MaxRestarts: 1, BaseBackoff: "1ms", MaxBackoff: "2ms"},
+	}
+	if err := mgr.Start(context.Background(), []config.MCPServerConfig{srv}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// Failed attempts don't use up the single restart allowed
+	servers.crash(true)
+	status.waitFor(t, func(e MCPServerEvent) bool { return e.Status == MCPServerRestarting && e.Restarts == 3 })
+	servers.repair()
+	status.waitFor(t, func(e MCPServerEvent) bool { return e.Status == MCPServerUp && e.Restarts > 0 })
+
+	result, err := mgr.CallTool(context.Background(), "ping_tool", nil)
+	if err != nil {
+		t.Fatalf("CallTool after restart failed: %v", err)
+	}
+	if result.Text != "pong" {
+		t.Errorf("CallTool() = %q, want pong", result.Text)
+	}
+}
@@ -0,0 +1,297 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// Finish reasons reported in Response.FinishReason when the tool loop is cut short.
+const (
+	FinishReasonMaxIterations = "max_iterations" // tools.max_iterations rounds of tool calls were made
+	FinishReasonToolLoop      = "tool_loop"      // The LLM repeated a tool call with the same arguments
+)
+
+// finalAnswerPrompt asks for an answer once the tools have been withdrawn.
+const finalAnswerPrompt = "Tool calls are no longer available for this request. " +
+	"Answer now using the information you already have."
+
+// executeToolCalls executes the tool calls of one LLM response, up to
+// tools.max_parallel at a time, and returns their results in call order.
+// Calls not yet started when ctx is done are skipped and have no result.
+func (h *MessageHandler) executeToolCalls(ctx context.Context, traceID string, calls []athyr.ToolCall) []string {
+	results := make([]string, len(calls))
+	sem := make(chan struct{}, h.cfg.Agent.Tools.GetMaxParallel())
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = h.runToolCall(ctx, traceID, call)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runToolCall executes one tool call, logging it and emitting ToolEvents.
+// Calls to tools in tools.require_approval wait for approval first.
+// Failures and denials are returned as a JSON error result for the LLM to react to.
+func (h *MessageHandler) runToolCall(ctx context.Context, traceID string, call athyr.ToolCall) string {
+	argsStr := string(call.Arguments)
+
+	var decision string
+	if h.cfg.Agent.Tools.RequiresApproval(call.Name) {
+		approvalStart := time.Now()
+		d := h.awaitApproval(ctx, traceID, call)
+		decision = d.String()
+		h.logger.Info("tool approval decided",
+			"trace_id", traceID,
+			"tool", call.Name,
+			"args", argsStr,
+			"approved", d.Approved,
+			"by", d.By,
+			"reason", d.Reason,
+			"wait_ms", time.Since(approvalStart).Milliseconds(),
+		)
+
+		if !d.Approved {
+			err := fmt.Errorf("tool call %s", decision)
+			h.emitEvent(ToolEvent{
+				Time:     time.Now(),
+				Status:   ToolDenied,
+				ID:       call.ID,
+				Name:     call.Name,
+				Args:     argsStr,
+				Error:    err,
+				Decision: decision,
+			})
+			return toolError(err)
+		}
+	}
+
+	// Emit tool started event
+	toolStart := time.Now()
+	h.emitEvent(ToolEvent{
+		Time:     toolStart,
+		Status:   ToolStarted,
+		ID:       call.ID,
+		Name:     call.Name,
+		Args:     argsStr,
+		Decision: decision,
+	})
+
+	result, err := h.executeToolCall(ctx, call)
+	toolDuration := time.Since(toolStart)
+
+	if err != nil {
+		h.logger.Error("tool failed",
+			"trace_id", traceID,
+			"tool", call.Name,
+			"error", err.Error(),
+			"latency_ms", toolDuration.Milliseconds(),
+		)
+		h.emitEvent(ToolEvent{
+			Time:     time.Now(),
+			Status:   ToolFailed,
+			ID:       call.ID,
+			Name:     call.Name,
+			Args:     argsStr,
+			Error:    err,
+			Duration: toolDuration,
+			Decision: decision,
+		})
+		return toolError(err)
+	}
+
+	h.logger.Info("tool executed",
+		"trace_id", traceID,
+		"tool", call.Name,
+		"server", h.mcp.GetServerForTool(call.Name),
+		"latency_ms", toolDuration.Milliseconds(),
+		"success", true,
+	)
+
+	sent, shortened := h.shortenResult(ctx, traceID, call, result.Text)
+	h.emitEvent(ToolEvent{
+		Time:      time.Now(),
+		Status:    ToolCompleted,
+		ID:        call.ID,
+		Name:      call.Name,
+		Args:      argsStr,
+		Result:    result.Text,
+		Content:   result.Content,
+		Duration:  toolDuration,
+		Decision:  decision,
+		Shortened: shortened,
+	})
+	return sent
+}
+
+// shortenResult fits a tool result into the tool's tools.max_result_bytes,
+// summarizing it with tools.summarize.model if set, else keeping its head and
+// tail. It returns the result for the LLM and how it was shortened, if it was.
+func (h *MessageHandler) shortenResult(ctx context.Context, traceID string, call athyr.ToolCall, result string) (string, string) {
+	limit := h.cfg.Agent.Tools.GetMaxResultBytes(call.Name)
+	if limit == 0 || len(result) <= limit {
+		return result, ""
+	}
+
+	if model := h.cfg.Agent.Tools.Summarize.Model; model != "" {
+		summary, err := h.summarizeResult(ctx, model, call, result, limit)
+		if err == nil {
+			h.logger.Info("tool result summarized",
+				"trace_id", traceID,
+				"tool", call.Name,
+				"model", model,
+				"bytes", len(result),
+				"summary_bytes", len(summary),
+			)
+			return summary, "summarized"
+		}
+		h.logger.Warn("tool result summarization failed, truncating",
+			"trace_id", traceID,
+			"tool", call.Name,
+			"model", model,
+			"error", err.Error(),
+		)
+	}
+
+	h.logger.Info("tool result truncated",
+		"trace_id", traceID,
+		"tool", call.Name,
+		"bytes", len(result),
+		"limit", limit,
+	)
+	return truncateResult(result, limit), "truncated"
+}
+
+// summarizePrompt instructs the model that summarizes large tool results.
+const summarizePrompt = "You summarize the output of a tool call for another assistant. " +
+	"Keep every detail it may need to act on the output, such as names, numbers, " +
+	"identifiers and errors, and leave out the rest. Reply with the summary only."
+
+// summarizeResult asks model for a summary of result that fits in limit bytes.
+// Results over tools.summarize.max_input_bytes are truncated first.
+func (h *MessageHandler) summarizeResult(ctx context.Context, model string, call athyr.ToolCall, result string, limit int) (string, error) {
+	input := truncateResult(result, h.cfg.Agent.Tools.Summarize.GetMaxInputBytes())
+	header := fmt.Sprintf("[Summary of a %d-byte result]\n", len(result))
+
+	req := h.newCompletionRequest([]athyr.Message{
+		{Role: "system", Content: summarizePrompt},
+		{Role: "user", Content: fmt.Sprintf("Tool: %s\nArguments: %s\n\nOutput:\n%s", call.Name, call.Arguments, input)},
+	}, nil)
+	req.Model = model
+	// About four bytes per token
+	req.Config.MaxTokens = max((limit-len(header))/4, 1)
+
+	resp, err := h.completeWith(ctx, req, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp.Content == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return truncateResult(header+resp.Content, limit), nil
+}
+
+// truncateResult cuts result down to at most limit bytes, keeping its head and
+// tail around a marker saying how much was left out. Cuts fall on UTF-8
+// character boundaries.
+func truncateResult(result string, limit int) string {
+	if len(result) <= limit {
+		return result
+	}
+	marker := fmt.Sprintf("\n[... %d bytes truncated ...]\n", len(result))
+	keep := limit - len(marker)
+	if keep <= 0 {
+		return validPrefix(result, limit)
+	}
+
+	head := validPrefix(result, keep-keep/2)
+	tail := validSuffix(result, keep/2)
+	// The marker counts the bytes actually left out
+	marker = fmt.Sprintf("\n[... %d bytes truncated ...]\n", len(result)-len(head)-len(tail))
+	return head + marker + tail
+}
+
+// validPrefix returns the longest prefix of s of at most n bytes that doesn't
+// split a UTF-8 character.
+func validPrefix(s string, n int) string {
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// validSuffix returns the longest suffix of s of at most n bytes that doesn't
+// split a UTF-8 character.
+func validSuffix(s string, n int) string {
+	i := len(s) - n
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
+}
+
+// toolError formats err as a tool result for the LLM.
+func toolError(err error) string {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(data)
+}
+
+// repeatsToolCall reports whether any of calls was already made with the same
+// arguments, then records them in seen.
+func repeatsToolCall(seen map[string]bool, calls []athyr.ToolCall) bool {
+	repeated := false
+	for _, call := range calls {
+		if seen[toolCallKey(call)] {
+			repeated = true
+		}
+	}
+	for _, call := range calls {
+		seen[toolCallKey(call)] = true
+	}
+	return repeated
+}
+
+// toolCallKey identifies a call by tool name and arguments. Arguments are
+// re-encoded so that formatting and key order don't matter.
+func toolCallKey(call athyr.ToolCall) string {
+	args := string(call.Arguments)
+	var v any
+	if err := json.Unmarshal(call.Arguments, &v); err == nil {
+		if data, err := json.Marshal(v); err == nil {
+			args = string(data)
+		}
+	}
+	return call.Name + "\x00" + args
+}
+
+// toolTimeout returns the time allowed for a call to the named tool: the
+// server's tool_timeouts entry or timeout, else processing.tool_timeout.
+// tool_timeouts is keyed by the name on the server, without the prefix.
+func (h *MessageHandler) toolTimeout(name string) time.Duration {
+	server := h.mcp.GetServerForTool(name)
+	for _, srv := range h.cfg.Agent.MCP.Servers {
+		if srv.Name == server {
+			return srv.GetToolTimeout(h.mcp.GetOriginalToolName(name), h.processing.ToolTimeout)
+		}
+	}
+	return h.processing.ToolTimeout
+}
@@ -0,0 +1,373 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/athyr-tech/athyr-agent/internal/config"
+
+	"github.com/athyr-tech/athyr-sdk-go/pkg/athyr"
+)
+
+// parallelToolAgent asks for three lookups in one response and records the
+// tool results it gets back.
+func parallelToolAgent(results *[]athyr.Message) *mockAgent {
+	return &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role != "tool" {
+				return &athyr.CompletionResponse{
+					ToolCalls: []athyr.ToolCall{
+						{ID: "call_1", Name: "lookup", Arguments: json.RawMessage(`{"delay": 30}`)},
+						{ID: "call_2", Name: "lookup", Arguments: json.RawMessage(`{"delay": 20}`)},
+						{ID: "call_3", Name: "lookup", Arguments: json.RawMessage(`{"delay": 10}`)},
+					},
+				}, nil
+			}
+			for _, m := range req.Messages {
+				if m.Role == "tool" {
+					*results = append(*results, m)
+				}
+			}
+			return &athyr.CompletionResponse{Content: "done"}, nil
+		},
+	}
+}
+
+// lookupManager returns an MCP manager whose lookup tool sleeps for the
+// requested delay and records the peak number of concurrent calls.
+func lookupManager(peak *int) *MCPManager {
+	var mu sync.Mutex
+	active := 0
+
+	mgr := NewMCPManager(nil)
+	mgr.RegisterTool("test-server", athyr.Tool{Name: "lookup"})
+	mgr.SetToolExecutor(func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		mu.Lock()
+		active++
+		*peak = max(*peak, active)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+
+		var in struct{ Delay int }
+		if err := json.Unmarshal(args, &in); err != nil {
+			return "", err
+		}
+		time.Sleep(time.Duration(in.Delay) * time.Millisecond)
+		return fmt.Sprintf("slept %d", in.Delay), nil
+	})
+	return mgr
+}
+
+func toolConfig(maxParallel int) *config.Config {
+	return &config.Config{
+		Agent: config.AgentConfig{
+			Name:  "test",
+			Model: "gpt-4",
+			Topics: config.TopicsConfig{
+				Subscribe: []string{"input"},
+				Publish:   []string{"output"},
+			},
+			Tools: config.ToolsConfig{MaxParallel: maxParallel},
+		},
+	}
+}
+
+func TestHandler_ExecutesToolCallsInParallel(t *testing.T) {
+	var results []athyr.Message
+	var peak int
+	bus := NewEventBus(20)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(toolConfig(0), parallelToolAgent(&results), logger, lookupManager(&peak), nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("look it up")})
+
+	if peak != 3 {
+		t.Errorf("peak concurrent calls = %d, want 3", peak)
+	}
+
+	// Results keep the call order even though call_3 finished first
+	want := []struct{ id, content string }{{"call_1", "slept 30"}, {"call_2", "slept 20"}, {"call_3", "slept 10"}}
+	if len(results) != len(want) {
+		t.Fatalf("got %d tool results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		if results[i].ToolCallID != w.id || results[i].Content != w.content {
+			t.Errorf("result %d = %s %q, want %s %q", i, results[i].ToolCallID, results[i].Content, w.id, w.content)
+		}
+	}
+
+	// Each call has a started and a completed event with its own ID and args
+	args := make(map[string]string)
+	completed := 0
+	for len(bus.Events()) > 0 {
+		e, ok := (<-bus.Events()).(ToolEvent)
+		if !ok {
+			continue
+		}
+		switch e.Status {
+		case ToolStarted:
+			args[e.ID] = e.Args
+		case ToolCompleted:
+			completed++
+			if args[e.ID] != e.Args {
+				t.Errorf("completed %s with args %s, started with %s", e.ID, e.Args, args[e.ID])
+			}
+		}
+	}
+	if len(args) != 3 || completed != 3 {
+		t.Errorf("got %d started and %d completed calls, want 3 of each", len(args), completed)
+	}
+}
+
+func TestHandler_MaxParallelLimitsToolCalls(t *testing.T) {
+	var results []athyr.Message
+	var peak int
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(toolConfig(1), parallelToolAgent(&results), logger, lookupManager(&peak), nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("look it up")})
+
+	if peak != 1 {
+		t.Errorf("peak concurrent calls = %d, want 1", peak)
+	}
+	if len(results) != 3 {
+		t.Errorf("got %d tool results, want 3", len(results))
+	}
+}
+
+// toolLoopAgent requests a tool call for as long as tools are offered, using
+// args to build each call's arguments, and records every request.
+func toolLoopAgent(args func(n int) string, requests *[]athyr.CompletionRequest) *mockAgent {
+	return &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			*requests = append(*requests, req)
+			if len(req.Tools) == 0 {
+				return &athyr.CompletionResponse{Content: "best effort", FinishReason: "stop"}, nil
+			}
+			n := len(*requests)
+			return &athyr.CompletionResponse{
+				FinishReason: "tool_calls",
+				ToolCalls: []athyr.ToolCall{
+					{ID: fmt.Sprintf("call_%d", n), Name: "search", Arguments: json.RawMessage(args(n))},
+				},
+			}, nil
+		},
+	}
+}
+
+func searchManager(calls *int) *MCPManager {
+	mgr := NewMCPManager(nil)
+	mgr.RegisterTool("test-server", athyr.Tool{Name: "search"})
+	mgr.SetToolExecutor(func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		*calls++
+		return "nothing found", nil
+	})
+	return mgr
+}
+
+func TestHandler_ForcesAnswerAtMaxIterations(t *testing.T) {
+	cfg := toolConfig(0)
+	cfg.Agent.Tools.MaxIterations = 2
+
+	var requests []athyr.CompletionRequest
+	var calls int
+	agent := toolLoopAgent(func(n int) string { return fmt.Sprintf(`{"page": %d}`, n) }, &requests)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, searchManager(&calls), nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("find it")})
+
+	if calls != 2 {
+		t.Errorf("tool executed %d times, want 2", calls)
+	}
+	if len(requests) != 4 {
+		t.Fatalf("Complete called %d times, want 4", len(requests))
+	}
+	final := requests[3]
+	if len(final.Tools) != 0 || final.Messages[len(final.Messages)-1].Content != finalAnswerPrompt {
+		t.Errorf("final request = %+v, want no tools and the final answer prompt", final)
+	}
+
+	var response Response
+	if err := json.Unmarshal(agent.published[0].Data, &response); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if response.Content != "best effort" || response.FinishReason != FinishReasonMaxIterations {
+		t.Errorf("response = %+v, want the forced answer with finish_reason %q", response, FinishReasonMaxIterations)
+	}
+}
+
+func TestHandler_DetectsRepeatedToolCalls(t *testing.T) {
+	var requests []athyr.CompletionRequest
+	var calls int
+	// Same arguments each time, formatted differently
+	agent := toolLoopAgent(func(n int) string {
+		if n%2 == 0 {
+			return `{"q": "billing", "limit": 5}`
+		}
+		return `{"limit":5,"q":"billing"}`
+	}, &requests)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(toolConfig(0), agent, logger, searchManager(&calls), nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("find it")})
+
+	if calls != 1 {
+		t.Errorf("tool executed %d times, want 1", calls)
+	}
+	if len(requests) != 3 {
+		t.Errorf("Complete called %d times, want 3", len(requests))
+	}
+
+	var response Response
+	if err := json.Unmarshal(agent.published[0].Data, &response); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if response.FinishReason != FinishReasonToolLoop {
+		t.Errorf("FinishReason = %q, want %q", response.FinishReason, FinishReasonToolLoop)
+	}
+}
+
+func TestHandler_PerToolTimeout(t *testing.T) {
+	cfg := toolConfig(0)
+	cfg.Agent.Processing.ToolTimeout = "1m"
+	cfg.Agent.MCP.Servers = []config.MCPServerConfig{{
+		Name:         "test-server",
+		Command:      []string{"server"},
+		Timeout:      "30s",
+		ToolTimeouts: map[string]string{"slow_tool": "20ms"},
+	}}
+
+	var result string
+	callCount := 0
+	agent := &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			callCount++
+			if callCount == 1 {
+				return &athyr.CompletionResponse{
+					ToolCalls: []athyr.ToolCall{{ID: "call_1", Name: "slow_tool"}},
+				}, nil
+			}
+			result = req.Messages[len(req.Messages)-1].Content
+			return &athyr.CompletionResponse{Content: "done"}, nil
+		},
+	}
+
+	mcpMgr := NewMCPManager(nil)
+	mcpMgr.RegisterTool("test-server", athyr.Tool{Name: "slow_tool"})
+	mcpMgr.SetToolExecutor(func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, agent, logger, mcpMgr, nil, nil)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("use the slow tool")})
+
+	if want := "timed out after 20ms"; !strings.Contains(result, want) {
+		t.Errorf("tool result = %q, want to contain %q", result, want)
+	}
+}
+
+func TestTruncateResult(t *testing.T) {
+	long := strings.Repeat("a", 100) + strings.Repeat("é", 100) + strings.Repeat("z", 100)
+	got := truncateResult(long, 120)
+	if len(got) > 120 {
+		t.Errorf("len = %d, want at most 120", len(got))
+	}
+	if !strings.HasPrefix(got, "aaaa") || !strings.HasSuffix(got, "zzzz") || !strings.Contains(got, "bytes truncated") {
+		t.Errorf("truncateResult = %q, want head, marker and tail", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("truncateResult = %q, want valid UTF-8", got)
+	}
+	if got := truncateResult("short", 120); got != "short" {
+		t.Errorf("truncateResult(short) = %q, want it unchanged", got)
+	}
+}
+
+// bigResultAgent calls read_file once and records the tool result it gets
+// back. Requests for summaryModel are answered with "summary".
+func bigResultAgent(result *string, summaryModel string) *mockAgent {
+	return &mockAgent{
+		completeFunc: func(ctx context.Context, req athyr.CompletionRequest) (*athyr.CompletionResponse, error) {
+			if req.Model == summaryModel {
+				return &athyr.CompletionResponse{Content: "summary"}, nil
+			}
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role == "tool" {
+				*result = last.Content
+				return &athyr.CompletionResponse{Content: "done"}, nil
+			}
+			return &athyr.CompletionResponse{
+				ToolCalls: []athyr.ToolCall{{ID: "call_1", Name: "read_file"}},
+			}, nil
+		},
+	}
+}
+
+func readFileManager(content string) *MCPManager {
+	mgr := NewMCPManager(nil)
+	mgr.RegisterTool("files", athyr.Tool{Name: "read_file"})
+	mgr.SetToolExecutor(func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		return content, nil
+	})
+	return mgr
+}
+
+func TestHandler_TruncatesLargeToolResults(t *testing.T) {
+	cfg := toolConfig(0)
+	cfg.Agent.Tools.MaxResultBytes = 16
+	cfg.Agent.Tools.ToolMaxResultBytes = map[string]int{"read_file": 200}
+
+	content := strings.Repeat("x", 1000)
+	var result string
+	bus := NewEventBus(20)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, bigResultAgent(&result, ""), logger, readFileManager(content), nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("read it")})
+
+	if len(result) > 200 || !strings.Contains(result, "bytes truncated") {
+		t.Errorf("tool result = %q, want at most 200 bytes with a truncation marker", result)
+	}
+	events := toolEvents(bus)
+	if last := events[len(events)-1]; last.Result != content || last.Shortened != "truncated" {
+		t.Errorf("completed event has %d-byte result, shortened %q, want the full result, truncated", len(last.Result), last.Shortened)
+	}
+}
+
+func TestHandler_SummarizesLargeToolResults(t *testing.T) {
+	cfg := toolConfig(0)
+	cfg.Agent.Tools.MaxResultBytes = 200
+	cfg.Agent.Tools.Summarize.Model = "gpt-4o-mini"
+
+	var result string
+	bus := NewEventBus(20)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	handler := newMessageHandler(cfg, bigResultAgent(&result, "gpt-4o-mini"), logger, readFileManager(strings.Repeat("x", 1000)), nil, bus)
+
+	handler.Handle(athyr.SubscribeMessage{Subject: "input", Data: []byte("read it")})
+
+	if want := "[Summary of a 1000-byte result]\nsummary"; result != want {
+		t.Errorf("tool result = %q, want %q", result, want)
+	}
+	events := toolEvents(bus)
+	if last := events[len(events)-1]; last.Shortened != "summarized" {
+		t.Errorf("Shortened = %q, want summarized", last.Shortened)
+	}
+}
@@ -15,26 +15,29 @@ import (
 // ChatMessage represents a message in the chat history.
 type ChatMessage struct {
 	Time    time.Time
-	Role    string // "user", "assistant", "error"
+	Role    string // "user", "assistant", "system", "preview", "error"
 	Content string
 }
 
 // Chat provides an interactive chat interface.
 type Chat struct {
-	messages []ChatMessage
-	textarea textarea.Model
-	viewport viewport.Model
-	width    int
-	height   int
-	ready    bool
-	focused  bool
-	sending  bool
+	messages  []ChatMessage
+	textarea  textarea.Model
+	viewport  viewport.Model
+	width     int
+	height    int
+	ready     bool
+	focused   bool
+	sending   bool
+	preview   bool // Messages run the pipeline preview
+	streaming bool // The last message is a response still being streamed
+	ended     int  // Latest exchange whose final response was shown
 }
 
 // NewChat creates a new Chat component.
 func NewChat() Chat {
 	ta := textarea.New()
-	ta.Placeholder = "Type a message..."
+	ta.Placeholder = "Type a message or /reset, /system, /model, /save, /preview..."
 	ta.ShowLineNumbers = false
 	ta.CharLimit = 2000
 	ta.SetHeight(3)
@@ -127,8 +130,16 @@ func (c *Chat) AddUserMessage(content string) {
 	c.viewport.GotoBottom()
 }
 
-// AddAssistantMessage adds an assistant message to the history.
+// AddAssistantMessage adds an assistant message to the history. A response
+// that was streamed is replaced by its final content.
 func (c *Chat) AddAssistantMessage(content string) {
+	if c.streaming {
+		c.streaming = false
+		c.messages[len(c.messages)-1].Content = content
+		c.updateContent()
+		c.viewport.GotoBottom()
+		return
+	}
 	c.messages = append(c.messages, ChatMessage{
 		Time:    time.Now(),
 		Role:    "assistant",
@@ -138,8 +149,34 @@ func (c *Chat) AddAssistantMessage(content string) {
 	c.viewport.GotoBottom()
 }
 
+// EndExchange marks an exchange as answered before its final response is
+// added, so chunks of it that arrive later are ignored.
+func (c *Chat) EndExchange(exchange int) {
+	c.ended = max(c.ended, exchange)
+}
+
+// AppendChunk adds streamed content to the response in progress, starting
+// a new assistant message for the first chunk. Chunks of an exchange that
+// has already ended are ignored.
+func (c *Chat) AppendChunk(exchange int, content string) {
+	if exchange <= c.ended {
+		return
+	}
+	if !c.streaming {
+		c.streaming = true
+		c.messages = append(c.messages, ChatMessage{
+			Time: time.Now(),
+			Role: "assistant",
+		})
+	}
+	c.messages[len(c.messages)-1].Content += content
+	c.updateContent()
+	c.viewport.GotoBottom()
+}
+
 // AddErrorMessage adds an error message to the history.
 func (c *Chat) AddErrorMessage(content string) {
+	c.streaming = false // Keep any partial response
 	c.messages = append(c.messages, ChatMessage{
 		Time:    time.Now(),
 		Role:    "error",
@@ -149,6 +186,40 @@ func (c *Chat) AddErrorMessage(content string) {
 	c.viewport.GotoBottom()
 }
 
+// AddSystemMessage adds a notice, such as the result of a chat command, to the history.
+func (c *Chat) AddSystemMessage(content string) {
+	c.messages = append(c.messages, ChatMessage{
+		Time:    time.Now(),
+		Role:    "system",
+		Content: content,
+	})
+	c.updateContent()
+	c.viewport.GotoBottom()
+}
+
+// AddPreviewMessage adds a pipeline preview result to the history.
+func (c *Chat) AddPreviewMessage(content string) {
+	c.messages = append(c.messages, ChatMessage{
+		Time:    time.Now(),
+		Role:    "preview",
+		Content: content,
+	})
+	c.updateContent()
+	c.viewport.GotoBottom()
+}
+
+// SetPreview shows whether messages run the pipeline preview.
+func (c *Chat) SetPreview(preview bool) {
+	c.preview = preview
+}
+
+// Clear removes all messages from the history.
+func (c *Chat) Clear() {
+	c.streaming = false
+	c.messages = c.messages[:0]
+	c.updateContent()
+}
+
 // Update handles input.
 func (c Chat) Update(msg tea.Msg) (Chat, tea.Cmd) {
 	var cmds []tea.Cmd
@@ -224,6 +295,12 @@ func (c Chat) formatMessage(msg ChatMessage) string {
 	case "assistant":
 		roleStyle = styles.ChatAssistant
 		roleLabel = "Assistant"
+	case "system":
+		roleStyle = styles.Muted
+		roleLabel = "System"
+	case "preview":
+		roleStyle = styles.ChatAssistant
+		roleLabel = "Pipeline preview"
 	case "error":
 		roleStyle = styles.LogError
 		roleLabel = "Error"
@@ -232,10 +309,10 @@ func (c Chat) formatMessage(msg ChatMessage) string {
 	timestamp := styles.MessageTimestamp.Render(msg.Time.Format("15:04:05"))
 	header := timestamp + " " + roleStyle.Render(roleLabel+":")
 
-	// Wrap content to fit width
+	// Wrap content to fit width; previews keep their JSON indentation
 	content := msg.Content
 	maxWidth := c.width - 8
-	if maxWidth > 0 {
+	if maxWidth > 0 && msg.Role != "preview" {
 		content = wordWrap(content, maxWidth)
 	}
 
@@ -258,6 +335,9 @@ func (c Chat) View() string {
 
 	// Build content: title + viewport + input
 	title := styles.PanelTitle.Render("Chat")
+	if c.preview {
+		title = styles.PanelTitle.Render("Chat (pipeline preview)")
+	}
 	content := title + "\n" + historyView + "\n" + inputView
 
 	// Use Height() to ensure consistent footer positioning across all tabs
@@ -81,6 +81,21 @@ func (d *Dashboard) AddTokens(count int) {
 	d.status.AddTokens(count)
 }
 
+// SetQueue updates the message queue depth.
+func (d *Dashboard) SetQueue(queue QueueInfo) {
+	d.status.SetQueue(queue)
+}
+
+// RecordOutcome counts a processed message by outcome.
+func (d *Dashboard) RecordOutcome(outcome MessageOutcome) {
+	d.status.RecordOutcome(outcome)
+}
+
+// RecordSession counts a session change and updates the active session count.
+func (d *Dashboard) RecordSession(action SessionAction, active int) {
+	d.status.RecordSession(action, active)
+}
+
 // TotalTokens returns the total token count.
 func (d Dashboard) TotalTokens() int {
 	return d.status.TotalTokens()
@@ -77,6 +77,11 @@ func (h Help) View() string {
 	b.WriteString(keyStyle.Render("i") + descStyle.Render("Focus input") + "\n")
 	b.WriteString(keyStyle.Render("Esc") + descStyle.Render("Unfocus input") + "\n")
 	b.WriteString(keyStyle.Render("Enter") + descStyle.Render("Send message") + "\n")
+	b.WriteString(keyStyle.Render("/reset") + descStyle.Render("Start the conversation over") + "\n")
+	b.WriteString(keyStyle.Render("/system [text]") + descStyle.Render("Set or restore the system prompt") + "\n")
+	b.WriteString(keyStyle.Render("/model [name]") + descStyle.Render("Set or restore the model") + "\n")
+	b.WriteString(keyStyle.Render("/save <file>") + descStyle.Render("Save the conversation as JSON") + "\n")
+	b.WriteString(keyStyle.Render("/preview") + descStyle.Render("Toggle the pipeline preview") + "\n")
 
 	// Messaging
 	b.WriteString(sectionStyle.Render("Messaging Tab"))
@@ -95,6 +100,7 @@ func (h Help) View() string {
 	b.WriteString("\n")
 	b.WriteString(keyStyle.Render("←/h") + descStyle.Render("Focus left panel") + "\n")
 	b.WriteString(keyStyle.Render("→/l") + descStyle.Render("Focus right panel") + "\n")
+	b.WriteString(keyStyle.Render("y/n") + descStyle.Render("Approve/deny tool call") + "\n")
 
 	// Footer
 	b.WriteString("\n")
@@ -28,6 +28,27 @@ type WatchMessage struct {
 	Content   string
 }
 
+// ReplyError is the error object of a reply from an agent that failed to
+// process a request (mirrors runner.ResponseError).
+type ReplyError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	TraceID   string `json:"trace_id"`
+	Retryable bool   `json:"retryable"`
+}
+
+// parseReplyError returns the error object of an agent reply, or nil if the
+// response isn't an error reply.
+func parseReplyError(response string) *ReplyError {
+	var reply struct {
+		Error *ReplyError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(response), &reply); err != nil {
+		return nil
+	}
+	return reply.Error
+}
+
 // maxWatchMessages is the maximum number of messages to buffer.
 const maxWatchMessages = 50
 
@@ -48,6 +69,7 @@ type Messaging struct {
 	// Response (for request mode)
 	response    string
 	responseErr error
+	replyError  *ReplyError // set when the reply reports a processing failure
 	sending     bool
 
 	// Success feedback (for publish mode)
@@ -195,6 +217,7 @@ func (m *Messaging) SetSending(sending bool) {
 func (m *Messaging) SetResponse(response string, err error) {
 	m.response = response
 	m.responseErr = err
+	m.replyError = parseReplyError(response)
 	// Also track success for publish mode feedback
 	if err == nil {
 		m.lastSendSuccess = true
@@ -210,6 +233,7 @@ func (m *Messaging) ClearInputs() {
 	m.messageInput.SetValue("")
 	m.response = ""
 	m.responseErr = nil
+	m.replyError = nil
 	m.lastSendSuccess = false
 	// Note: Watch state is cleared separately via ClearWatch()
 	// This allows the model to also stop the subscription
@@ -716,6 +740,8 @@ func (m Messaging) renderSendPanelContent(contentWidth int) string {
 		b.WriteString("\n")
 		if m.responseErr != nil {
 			b.WriteString(styles.LogError.Render("Error: " + m.responseErr.Error()))
+		} else if m.replyError != nil {
+			b.WriteString(m.renderReplyError())
 		} else if m.response != "" {
 			respLines := strings.Split(m.response, "\n")
 			for i, line := range respLines {
@@ -733,6 +759,21 @@ func (m Messaging) renderSendPanelContent(contentWidth int) string {
 	return b.String()
 }
 
+// renderReplyError renders an error reply from the agent.
+func (m Messaging) renderReplyError() string {
+	var b strings.Builder
+	b.WriteString(styles.LogError.Render(fmt.Sprintf("Agent error [%s]", m.replyError.Code)))
+	b.WriteString("\n")
+	b.WriteString(styles.LogError.Render(m.replyError.Message))
+	b.WriteString("\n")
+	retry := "not retryable"
+	if m.replyError.Retryable {
+		retry = "retryable"
+	}
+	b.WriteString(styles.Muted.Render(fmt.Sprintf("trace %s, %s", m.replyError.TraceID, retry)))
+	return b.String()
+}
+
 // renderWatchPanel renders the right panel for watching topics (with title).
 func (m Messaging) renderWatchPanel(contentWidth int) string {
 	var b strings.Builder
@@ -23,6 +23,46 @@ type MemoryInfo struct {
 	TTL           string
 	ProfileType   string
 	MaxTokens     int
+	MaxSessions   int
+}
+
+// CompletionInfo holds LLM completion parameters.
+type CompletionInfo struct {
+	Temperature float64
+	MaxTokens   int
+	TopP        *float64
+	Stop        []string
+	Seed        *int
+	ToolChoice  string
+}
+
+// MessageOutcome indicates how processing of an incoming message ended.
+type MessageOutcome int
+
+const (
+	OutcomeCompleted MessageOutcome = iota
+	OutcomeFailed
+	OutcomeTimedOut
+	OutcomeCancelled
+)
+
+// SessionAction indicates what happened to a memory session.
+type SessionAction int
+
+const (
+	SessionCreated SessionAction = iota
+	SessionExpired
+	SessionEvicted
+	SessionDeleted
+	SessionReset
+)
+
+// QueueInfo holds the depth of the message processing queue.
+type QueueInfo struct {
+	Queued   int
+	Active   int
+	Capacity int
+	Workers  int
 }
 
 // AgentInfo holds configuration details about the agent.
@@ -35,6 +75,7 @@ type AgentInfo struct {
 	Routes     []RouteInfo
 	MCPServers []string
 	Memory     MemoryInfo
+	Completion CompletionInfo
 }
 
 // Status displays the connection status panel.
@@ -44,6 +85,10 @@ type Status struct {
 	connected   bool
 	errorMsg    string
 	totalTokens int
+	outcomes    map[MessageOutcome]int
+	queue       QueueInfo
+	sessions    map[SessionAction]int
+	active      int // tracked memory sessions
 	width       int
 	height      int
 	viewport    viewport.Model
@@ -53,7 +98,9 @@ type Status struct {
 // NewStatus creates a new Status component.
 func NewStatus(info AgentInfo) Status {
 	return Status{
-		info: info,
+		info:     info,
+		outcomes: make(map[MessageOutcome]int),
+		sessions: make(map[SessionAction]int),
 	}
 }
 
@@ -110,6 +157,25 @@ func (s *Status) AddTokens(count int) {
 	s.updateContent()
 }
 
+// SetQueue updates the message queue depth.
+func (s *Status) SetQueue(queue QueueInfo) {
+	s.queue = queue
+	s.updateContent()
+}
+
+// RecordOutcome counts a processed message by outcome.
+func (s *Status) RecordOutcome(outcome MessageOutcome) {
+	s.outcomes[outcome]++
+	s.updateContent()
+}
+
+// RecordSession counts a session change and updates the active session count.
+func (s *Status) RecordSession(action SessionAction, active int) {
+	s.sessions[action]++
+	s.active = active
+	s.updateContent()
+}
+
 // TotalTokens returns the total token count.
 func (s Status) TotalTokens() int {
 	return s.totalTokens
@@ -165,6 +231,23 @@ func (s *Status) updateContent() {
 	b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Athyr:"), s.info.Server))
 	b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Model:"), s.info.Model))
 
+	// Completion parameters
+	completion := s.info.Completion
+	b.WriteString(fmt.Sprintf("%s %g\n", labelStyle.Render("Temperature:"), completion.Temperature))
+	b.WriteString(fmt.Sprintf("%s %d\n", labelStyle.Render("Max Tokens:"), completion.MaxTokens))
+	if completion.TopP != nil {
+		b.WriteString(fmt.Sprintf("%s %g\n", labelStyle.Render("Top P:"), *completion.TopP))
+	}
+	if len(completion.Stop) > 0 {
+		b.WriteString(fmt.Sprintf("%s %q\n", labelStyle.Render("Stop:"), completion.Stop))
+	}
+	if completion.Seed != nil {
+		b.WriteString(fmt.Sprintf("%s %d\n", labelStyle.Render("Seed:"), *completion.Seed))
+	}
+	if completion.ToolChoice != "" {
+		b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Tool Choice:"), completion.ToolChoice))
+	}
+
 	// Topics
 	b.WriteString("\n")
 	b.WriteString(labelStyle.Render("Subscribe:") + "\n")
@@ -216,6 +299,22 @@ func (s *Status) updateContent() {
 		if s.info.Memory.MaxTokens > 0 {
 			b.WriteString(fmt.Sprintf("  %s %d\n", labelStyle.Render("Max Tokens:"), s.info.Memory.MaxTokens))
 		}
+		b.WriteString(fmt.Sprintf("  %s %d/%d\n", labelStyle.Render("Sessions:"), s.active, s.info.Memory.MaxSessions))
+		if n := s.sessions[SessionCreated]; n > 0 {
+			b.WriteString(fmt.Sprintf("  %s %d\n", labelStyle.Render("Created:"), n))
+		}
+		if n := s.sessions[SessionExpired]; n > 0 {
+			b.WriteString(fmt.Sprintf("  %s %d\n", labelStyle.Render("Expired:"), n))
+		}
+		if n := s.sessions[SessionEvicted]; n > 0 {
+			b.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render("Evicted:"), styles.LogWarn.Render(fmt.Sprint(n))))
+		}
+		if n := s.sessions[SessionDeleted]; n > 0 {
+			b.WriteString(fmt.Sprintf("  %s %d\n", labelStyle.Render("Deleted:"), n))
+		}
+		if n := s.sessions[SessionReset]; n > 0 {
+			b.WriteString(fmt.Sprintf("  %s %d\n", labelStyle.Render("Reset:"), n))
+		}
 	} else {
 		b.WriteString(fmt.Sprintf("%s %s\n", styles.Muted.Render("○"), labelStyle.Render("Memory Disabled")))
 	}
@@ -224,6 +323,28 @@ func (s *Status) updateContent() {
 	b.WriteString("\n")
 	b.WriteString(fmt.Sprintf("%s %d\n", labelStyle.Render("Total Tokens:"), s.totalTokens))
 
+	// Message queue
+	if s.queue.Workers > 0 {
+		queued := fmt.Sprintf("%d/%d", s.queue.Queued, s.queue.Capacity)
+		if s.queue.Queued >= s.queue.Capacity {
+			queued = styles.LogWarn.Render(queued + " (full)")
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Queue:"), queued))
+		b.WriteString(fmt.Sprintf("%s %d/%d\n", labelStyle.Render("Active:"), s.queue.Active, s.queue.Workers))
+	}
+
+	// Message outcomes
+	b.WriteString(fmt.Sprintf("%s %d\n", labelStyle.Render("Processed:"), s.outcomes[OutcomeCompleted]))
+	if n := s.outcomes[OutcomeFailed]; n > 0 {
+		b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Failed:"), styles.LogError.Render(fmt.Sprint(n))))
+	}
+	if n := s.outcomes[OutcomeTimedOut]; n > 0 {
+		b.WriteString(fmt.Sprintf("%s %s\n", labelStyle.Render("Timed Out:"), styles.LogWarn.Render(fmt.Sprint(n))))
+	}
+	if n := s.outcomes[OutcomeCancelled]; n > 0 {
+		b.WriteString(fmt.Sprintf("%s %d\n", labelStyle.Render("Cancelled:"), n))
+	}
+
 	// Error message
 	if s.errorMsg != "" {
 		// Truncate long errors
@@ -19,30 +19,82 @@ const (
 	ToolStarted ToolStatus = iota
 	ToolCompleted
 	ToolFailed
+	ToolAwaitingApproval
+	ToolDenied
 )
 
 // ToolExecution represents a tool call and its result.
 type ToolExecution struct {
 	Time     time.Time
 	Status   ToolStatus
+	ID       string
 	Name     string
 	Args     string
 	Result   string
+	Content  []ToolContent
 	Error    error
 	Duration time.Duration
+
+	ApprovalID string // Set while awaiting approval
+	Decision   string // Approval decision, for tools that need one
+	Shortened  string // "truncated" or "summarized" when the LLM got less than Result
+}
+
+// ToolContent is one block of a tool result, such as text, an image or a resource.
+type ToolContent struct {
+	Type string // "text", "image", "audio", "resource_link", "resource" or "structured"
+	Text string // The text, or a description of non-text content
+}
+
+// contentIcons marks the non-text blocks of a result.
+var contentIcons = map[string]string{
+	"image":         "▣",
+	"audio":         "♪",
+	"resource_link": "↗",
+	"resource":      "◆",
+	"structured":    "{}",
 }
 
 // AvailableTool represents an available MCP tool.
 type AvailableTool struct {
 	Name        string
+	Original    string // Name on the MCP server, if different
 	Description string
 	Server      string
 }
 
+// MCPServerStatus mirrors the runner's MCP server status.
+type MCPServerStatus int
+
+const (
+	MCPServerUp MCPServerStatus = iota
+	MCPServerDown
+	MCPServerRestarting
+)
+
+// MCPServer represents the state of a supervised MCP server.
+type MCPServer struct {
+	Name     string
+	Status   MCPServerStatus
+	Restarts int
+	Error    error
+}
+
+// AvailableResource represents a resource offered by an MCP server.
+type AvailableResource struct {
+	URI     string
+	Name    string
+	Server  string
+	Exposed bool // The LLM may read it via read_resource
+}
+
 // Tools displays available tools and execution history in a split view.
 type Tools struct {
-	available  []AvailableTool
-	executions []ToolExecution
+	servers          []MCPServer
+	available        []AvailableTool
+	resources        []AvailableResource
+	instructionsFrom string // MCP prompt the instructions come from, as server/prompt
+	executions       []ToolExecution
 
 	// Left panel: available tools
 	leftViewport viewport.Model
@@ -111,12 +163,37 @@ func (t *Tools) SetAvailableTools(tools []AvailableTool) {
 	t.updateLeftContent()
 }
 
+// SetServerStatus records the status of an MCP server.
+func (t *Tools) SetServerStatus(server MCPServer) {
+	for i := range t.servers {
+		if t.servers[i].Name == server.Name {
+			t.servers[i] = server
+			t.updateLeftContent()
+			return
+		}
+	}
+	t.servers = append(t.servers, server)
+	t.updateLeftContent()
+}
+
+// SetResources sets the list of MCP resources and the MCP prompt the agent's
+// instructions come from, if any.
+func (t *Tools) SetResources(resources []AvailableResource, instructionsFrom string) {
+	t.resources = resources
+	t.instructionsFrom = instructionsFrom
+	t.updateLeftContent()
+}
+
 // AddEvent adds a new tool execution event.
 func (t *Tools) AddEvent(exec ToolExecution) {
-	// If this is an update (completed/failed), find and update the existing entry
-	if exec.Status != ToolStarted {
+	// If this is an update (started after approval, completed, failed or
+	// denied), find and update the existing entry
+	if exec.Status != ToolAwaitingApproval {
 		for i := len(t.executions) - 1; i >= 0; i-- {
-			if t.executions[i].Name == exec.Name && t.executions[i].Status == ToolStarted {
+			// Calls may run in parallel, so match on the call ID too
+			prev := t.executions[i]
+			if prev.ID == exec.ID && prev.Name == exec.Name &&
+				(prev.Status == ToolAwaitingApproval || prev.Status == ToolStarted && exec.Status != ToolStarted) {
 				t.executions[i] = exec
 				t.updateRightContent()
 				return
@@ -133,6 +210,16 @@ func (t *Tools) AddEvent(exec ToolExecution) {
 	t.rightViewport.GotoBottom()
 }
 
+// PendingApproval returns the oldest tool call waiting for approval.
+func (t Tools) PendingApproval() (ToolExecution, bool) {
+	for _, exec := range t.executions {
+		if exec.Status == ToolAwaitingApproval {
+			return exec, true
+		}
+	}
+	return ToolExecution{}, false
+}
+
 // Update handles key messages for scrolling.
 func (t Tools) Update(msg tea.Msg) (Tools, tea.Cmd) {
 	var cmd tea.Cmd
@@ -167,6 +254,20 @@ func (t *Tools) updateLeftContent() {
 
 	var lines []string
 
+	if len(t.servers) > 0 {
+		lines = append(lines, styles.Muted.Render("MCP servers"))
+		for _, srv := range t.servers {
+			lines = append(lines, t.formatServer(srv))
+		}
+		lines = append(lines, "")
+	}
+
+	if t.instructionsFrom != "" {
+		lines = append(lines, styles.Muted.Render("Instructions from MCP prompt"))
+		lines = append(lines, styles.ToolName.Render(t.instructionsFrom))
+		lines = append(lines, "")
+	}
+
 	if len(t.available) == 0 {
 		lines = append(lines, styles.Muted.Render("No MCP tools configured"))
 	} else {
@@ -177,8 +278,12 @@ func (t *Tools) updateLeftContent() {
 			// Tool name
 			lines = append(lines, styles.ToolName.Render(tool.Name))
 
-			// Server
-			lines = append(lines, styles.Muted.Render("  "+tool.Server))
+			// Server, and the tool's name there if it's exposed under another
+			source := tool.Server
+			if tool.Original != "" && tool.Original != tool.Name {
+				source += " (" + tool.Original + ")"
+			}
+			lines = append(lines, styles.Muted.Render("  "+source))
 
 			// Description (word wrapped)
 			if tool.Description != "" {
@@ -193,6 +298,36 @@ func (t *Tools) updateLeftContent() {
 		}
 	}
 
+	if len(t.resources) > 0 {
+		exposed := 0
+		for _, res := range t.resources {
+			if res.Exposed {
+				exposed++
+			}
+		}
+		lines = append(lines, styles.Muted.Render(fmt.Sprintf("%d resources, %d readable by the LLM", len(t.resources), exposed)))
+		lines = append(lines, "")
+
+		for _, res := range t.resources {
+			name := res.Name
+			if name == "" {
+				name = res.URI
+			}
+			icon := styles.Muted.Render("○ ")
+			if res.Exposed {
+				icon = styles.ToolSuccess.Render("● ")
+			}
+			lines = append(lines, icon+styles.ToolName.Render(name))
+
+			uri := res.URI
+			maxLen := t.leftWidth - 8
+			if maxLen > 0 && len(uri) > maxLen {
+				uri = uri[:maxLen-3] + "..."
+			}
+			lines = append(lines, styles.Muted.Render("  "+res.Server+" "+uri))
+		}
+	}
+
 	t.leftViewport.SetContent(strings.Join(lines, "\n"))
 }
 
@@ -210,8 +345,12 @@ func (t *Tools) updateRightContent() {
 		lines = append(lines, styles.Muted.Render("Tool calls will appear here"))
 		lines = append(lines, styles.Muted.Render("when the LLM uses tools."))
 	} else {
+		pending, _ := t.PendingApproval()
 		for _, exec := range t.executions {
 			lines = append(lines, t.formatExecution(exec)...)
+			if exec.Status == ToolAwaitingApproval && exec.ApprovalID == pending.ApprovalID {
+				lines = append(lines, styles.ToolRunning.Render("  ? Approve this call? y approve, n deny"))
+			}
 			lines = append(lines, "") // Blank line between executions
 		}
 	}
@@ -237,6 +376,12 @@ func (t Tools) formatExecution(exec ToolExecution) []string {
 	case ToolFailed:
 		statusStyle = styles.ToolFailed
 		statusIcon = "✗"
+	case ToolAwaitingApproval:
+		statusStyle = styles.ToolRunning
+		statusIcon = "?"
+	case ToolDenied:
+		statusStyle = styles.ToolFailed
+		statusIcon = "⊘"
 	}
 
 	toolName := styles.ToolName.Render(exec.Name)
@@ -259,6 +404,11 @@ func (t Tools) formatExecution(exec ToolExecution) []string {
 		lines = append(lines, styles.Muted.Render("  "+args))
 	}
 
+	// Approval decision
+	if exec.Decision != "" && exec.Status != ToolDenied {
+		lines = append(lines, styles.Muted.Render("  "+exec.Decision))
+	}
+
 	// Result or error (truncated)
 	if exec.Status == ToolCompleted && exec.Result != "" {
 		result := strings.ReplaceAll(exec.Result, "\n", " ")
@@ -267,7 +417,11 @@ func (t Tools) formatExecution(exec ToolExecution) []string {
 			result = result[:maxLen-3] + "..."
 		}
 		lines = append(lines, styles.ToolSuccess.Render("  ✓ ")+result)
-	} else if exec.Status == ToolFailed && exec.Error != nil {
+		lines = append(lines, t.formatContent(exec.Content)...)
+		if exec.Shortened != "" {
+			lines = append(lines, styles.Muted.Render(fmt.Sprintf("  %d bytes, %s for the LLM", len(exec.Result), exec.Shortened)))
+		}
+	} else if (exec.Status == ToolFailed || exec.Status == ToolDenied) && exec.Error != nil {
 		errMsg := exec.Error.Error()
 		maxLen := t.rightWidth - 10
 		if maxLen > 0 && len(errMsg) > maxLen {
@@ -279,6 +433,50 @@ func (t Tools) formatExecution(exec ToolExecution) []string {
 	return lines
 }
 
+// formatServer formats an MCP server's status on one line.
+func (t Tools) formatServer(srv MCPServer) string {
+	var line string
+	switch srv.Status {
+	case MCPServerUp:
+		line = styles.ToolSuccess.Render("● ") + srv.Name + styles.Muted.Render(" up")
+	case MCPServerRestarting:
+		line = styles.ToolRunning.Render("↻ ") + srv.Name + styles.Muted.Render(" restarting")
+	case MCPServerDown:
+		line = styles.ToolFailed.Render("✗ ") + srv.Name + styles.Muted.Render(" down")
+	}
+	if srv.Restarts > 0 {
+		line += styles.Muted.Render(fmt.Sprintf(", %d restarts", srv.Restarts))
+	}
+	if srv.Status == MCPServerDown && srv.Error != nil {
+		errMsg := srv.Error.Error()
+		maxLen := t.leftWidth - 8 - len(srv.Name) - 20
+		if maxLen > 3 && len(errMsg) > maxLen {
+			errMsg = errMsg[:maxLen-3] + "..."
+		}
+		line += styles.Muted.Render(": " + errMsg)
+	}
+	return line
+}
+
+// formatContent lists the non-text blocks of a result, one line each, so that
+// images, resources and structured output stand out from the text.
+func (t Tools) formatContent(content []ToolContent) []string {
+	var lines []string
+	for _, c := range content {
+		icon, ok := contentIcons[c.Type]
+		if !ok {
+			continue
+		}
+		text, _, _ := strings.Cut(c.Text, "\n")
+		maxLen := t.rightWidth - 10
+		if maxLen > 0 && len(text) > maxLen {
+			text = text[:maxLen-3] + "..."
+		}
+		lines = append(lines, styles.Muted.Render(fmt.Sprintf("  %s %s", icon, text)))
+	}
+	return lines
+}
+
 // View renders the tools panel filling exactly width × height.
 func (t Tools) View() string {
 	// Left panel: Available Tools
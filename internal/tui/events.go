@@ -13,9 +13,25 @@ type EventMsg struct {
 
 // ChatResponseMsg is sent when a direct chat response is received.
 type ChatResponseMsg struct {
-	Content string
-	Model   string
-	Tokens  int
+	Exchange int // Number of the message answered
+	Content  string
+	Model    string
+	Tokens   int
+	Error    error
+}
+
+// ChatCommandMsg is sent when a chat slash command has run.
+type ChatCommandMsg struct {
+	Info  string // Confirmation shown in the chat
+	Reset bool   // Clear the displayed conversation
+	Error error
+
+	TogglePreview bool // Switch the pipeline preview mode
+}
+
+// ChatPreviewMsg is sent when a pipeline preview has run.
+type ChatPreviewMsg struct {
+	Preview *runner.PipelinePreview
 	Error   error
 }
 
@@ -35,6 +51,11 @@ type SetMessagingHandlerMsg struct {
 	Handler MessagingHandler
 }
 
+// SetApprovalHandlerMsg is sent to set the approval handler from outside the event loop.
+type SetApprovalHandlerMsg struct {
+	Handler ApprovalHandler
+}
+
 // MessagingResponseMsg is sent when a messaging request response is received.
 type MessagingResponseMsg struct {
 	Response []byte
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -37,7 +38,10 @@ type Model struct {
 	quitting         bool
 	showHelp         bool
 	chatHandler      ChatHandler
+	chatPreview      bool // Chat messages run the pipeline preview
+	chatExchange     int  // Number of the latest message sent from the Chat tab
 	messagingHandler MessagingHandler
+	approvalHandler  ApprovalHandler
 
 	// Help overlay
 	help components.Help
@@ -49,7 +53,12 @@ type Model struct {
 // ChatHandler is the interface for sending chat messages.
 // This is implemented by the Runner/MessageHandler.
 type ChatHandler interface {
-	DirectChat(content string) (response string, model string, tokens int, err error)
+	DirectChat(exchange int, content string) (response string, model string, tokens int, err error)
+	ResetChat() error
+	SetChatSystemPrompt(prompt string)
+	SetChatModel(model string)
+	SaveChat(path string) error
+	PreviewPipeline(content string) (*runner.PipelinePreview, error)
 }
 
 // WatchCallback is the function signature for receiving watch messages.
@@ -65,28 +74,35 @@ type MessagingHandler interface {
 	WatchingTopic() string
 }
 
+// ApprovalHandler is the interface for deciding tool calls that need approval.
+// This is implemented by the Runner/MessageHandler.
+type ApprovalHandler interface {
+	DecideApproval(id string, approved bool) error
+}
+
 // NewModel creates a new root Model.
 func NewModel(cfg *config.Config, eventBus runner.EventBus, serverAddr string) Model {
 	// Build agent info from config
+	// Values are redacted so secrets loaded via ${file:...} never reach the screen.
 	agentInfo := components.AgentInfo{
-		Name:      cfg.Agent.Name,
-		Model:     cfg.Agent.Model,
+		Name:      cfg.Redact(cfg.Agent.Name),
+		Model:     cfg.Redact(cfg.Agent.Model),
 		Server:    serverAddr,
-		Subscribe: cfg.Agent.Topics.Subscribe,
-		Publish:   cfg.Agent.Topics.Publish,
+		Subscribe: redactAll(cfg, cfg.Agent.Topics.Subscribe),
+		Publish:   redactAll(cfg, cfg.Agent.Topics.Publish),
 	}
 
 	// Add routes if configured
 	for _, route := range cfg.Agent.Topics.Routes {
 		agentInfo.Routes = append(agentInfo.Routes, components.RouteInfo{
-			Topic:       route.Topic,
-			Description: route.Description,
+			Topic:       cfg.Redact(route.Topic),
+			Description: cfg.Redact(route.Description),
 		})
 	}
 
 	// Add MCP server names if configured
 	for _, srv := range cfg.Agent.MCP.Servers {
-		agentInfo.MCPServers = append(agentInfo.MCPServers, srv.Name)
+		agentInfo.MCPServers = append(agentInfo.MCPServers, cfg.Redact(srv.Name))
 	}
 
 	// Add memory configuration
@@ -94,10 +110,22 @@ func NewModel(cfg *config.Config, eventBus runner.EventBus, serverAddr string) M
 		Enabled:       cfg.Agent.Memory.Enabled,
 		SessionPrefix: cfg.Agent.Memory.SessionPrefix,
 		TTL:           cfg.Agent.Memory.TTL,
+		MaxSessions:   cfg.Agent.Memory.GetMaxSessions(),
 		ProfileType:   cfg.Agent.Memory.GetProfile().Type,
 		MaxTokens:     cfg.Agent.Memory.GetProfile().MaxTokens,
 	}
 
+	// Add completion parameters
+	completion := cfg.Agent.GetCompletion()
+	agentInfo.Completion = components.CompletionInfo{
+		Temperature: *completion.Temperature,
+		MaxTokens:   completion.MaxTokens,
+		TopP:        completion.TopP,
+		Stop:        completion.Stop,
+		Seed:        completion.Seed,
+		ToolChoice:  completion.ToolChoice,
+	}
+
 	// Collect all topics for messaging component
 	allTopics := make([]string, 0)
 	allTopics = append(allTopics, cfg.Agent.Topics.Subscribe...)
@@ -119,6 +147,15 @@ func NewModel(cfg *config.Config, eventBus runner.EventBus, serverAddr string) M
 	}
 }
 
+// redactAll returns a copy of values with secrets masked.
+func redactAll(cfg *config.Config, values []string) []string {
+	redacted := make([]string, len(values))
+	for i, v := range values {
+		redacted[i] = cfg.Redact(v)
+	}
+	return redacted
+}
+
 // SetChatHandler sets the handler for sending chat messages.
 func (m *Model) SetChatHandler(h ChatHandler) {
 	m.chatHandler = h
@@ -129,6 +166,11 @@ func (m *Model) SetMessagingHandler(h MessagingHandler) {
 	m.messagingHandler = h
 }
 
+// SetApprovalHandler sets the handler for approving tool calls.
+func (m *Model) SetApprovalHandler(h ApprovalHandler) {
+	m.approvalHandler = h
+}
+
 // SetProgram sets the tea.Program reference for sending async messages.
 func (m *Model) SetProgram(p *tea.Program) {
 	m.program = p
@@ -214,10 +256,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Check if user pressed Enter to send
 			if key == "enter" && m.chat.Focused() {
 				content := m.chat.Value()
-				if content != "" {
+				if strings.HasPrefix(content, "/") {
+					m.chat.ClearInput()
+					cmds = append(cmds, m.runChatCommand(content))
+				} else if content != "" {
 					m.chat.ClearInput()
 					m.chat.AddUserMessage(content)
-					cmds = append(cmds, m.sendChatMessage(content))
+					if m.chatPreview {
+						cmds = append(cmds, m.previewChatMessage(content))
+					} else {
+						m.chatExchange++
+						cmds = append(cmds, m.sendChatMessage(m.chatExchange, content))
+					}
 				}
 			}
 
@@ -227,6 +277,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 
 		case components.TabTools:
+			// y/n decide the oldest tool call waiting for approval
+			if pending, ok := m.tools.PendingApproval(); ok && m.approvalHandler != nil && (key == "y" || key == "n") {
+				cmds = append(cmds, m.decideApproval(pending.ApprovalID, key == "y"))
+				break
+			}
 			var cmd tea.Cmd
 			m.tools, cmd = m.tools.Update(msg)
 			cmds = append(cmds, cmd)
@@ -269,7 +324,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, listenForEvents(m.eventBus))
 
 	case ChatResponseMsg:
-		// Handle chat response
+		// Handle chat response; chunks of it still queued are dropped
+		m.chat.EndExchange(msg.Exchange)
 		if msg.Error != nil {
 			m.chat.AddErrorMessage(msg.Error.Error())
 		} else {
@@ -277,6 +333,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.chat.SetSending(false)
 
+	case ChatPreviewMsg:
+		// Handle pipeline preview result
+		if msg.Error != nil {
+			m.chat.AddErrorMessage(msg.Error.Error())
+		} else {
+			m.chat.AddPreviewMessage(formatPipelinePreview(msg.Preview))
+		}
+		m.chat.SetSending(false)
+
+	case ChatCommandMsg:
+		// Handle the result of a chat command
+		if msg.Error != nil {
+			m.chat.AddErrorMessage(msg.Error.Error())
+		} else {
+			if msg.Reset {
+				m.chat.Clear()
+			}
+			if msg.TogglePreview {
+				m.chatPreview = !m.chatPreview
+				m.chat.SetPreview(m.chatPreview)
+				msg.Info = "Pipeline preview off"
+				if m.chatPreview {
+					msg.Info = "Pipeline preview on: messages run the full pipeline without publishing or executing tools"
+				}
+			}
+			m.chat.AddSystemMessage(msg.Info)
+		}
+
 	case SetChatHandlerMsg:
 		// Set the chat handler from external source
 		m.chatHandler = msg.Handler
@@ -285,6 +369,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Set the messaging handler from external source
 		m.messagingHandler = msg.Handler
 
+	case SetApprovalHandlerMsg:
+		// Set the approval handler from external source
+		m.approvalHandler = msg.Handler
+
 	case MessagingResponseMsg:
 		// Handle messaging response
 		m.messaging.SetSending(false)
@@ -329,15 +417,42 @@ func (m *Model) handleEvent(event runner.Event) tea.Cmd {
 			m.dashboard.AddTokens(e.Tokens)
 		}
 
+	case runner.QueueEvent:
+		m.dashboard.SetQueue(components.QueueInfo{
+			Queued:   e.Queued,
+			Active:   e.Active,
+			Capacity: e.Capacity,
+			Workers:  e.Workers,
+		})
+
+	case runner.MessageProcessedEvent:
+		m.dashboard.RecordOutcome(components.MessageOutcome(e.Outcome))
+
+	case runner.ChatChunkEvent:
+		m.chat.AppendChunk(e.Exchange, e.Content)
+
+	case runner.SessionEvent:
+		m.dashboard.RecordSession(components.SessionAction(e.Action), e.Active)
+
 	case runner.ToolEvent:
+		content := make([]components.ToolContent, len(e.Content))
+		for i, c := range e.Content {
+			content[i] = components.ToolContent{Type: c.Type, Text: c.Text}
+		}
 		m.tools.AddEvent(components.ToolExecution{
 			Time:     e.Time,
 			Status:   components.ToolStatus(e.Status),
+			ID:       e.ID,
 			Name:     e.Name,
 			Args:     e.Args,
 			Result:   e.Result,
+			Content:  content,
 			Error:    e.Error,
 			Duration: e.Duration,
+
+			ApprovalID: e.ApprovalID,
+			Decision:   e.Decision,
+			Shortened:  e.Shortened,
 		})
 
 	case runner.ToolsAvailableEvent:
@@ -346,12 +461,32 @@ func (m *Model) handleEvent(event runner.Event) tea.Cmd {
 		for i, t := range e.Tools {
 			available[i] = components.AvailableTool{
 				Name:        t.Name,
+				Original:    t.Original,
 				Description: t.Description,
 				Server:      t.Server,
 			}
 		}
 		m.tools.SetAvailableTools(available)
 
+		resources := make([]components.AvailableResource, len(e.Resources))
+		for i, r := range e.Resources {
+			resources[i] = components.AvailableResource{
+				URI:     r.URI,
+				Name:    r.Name,
+				Server:  r.Server,
+				Exposed: r.Exposed,
+			}
+		}
+		m.tools.SetResources(resources, e.InstructionsFrom)
+
+	case runner.MCPServerEvent:
+		m.tools.SetServerStatus(components.MCPServer{
+			Name:     e.Server,
+			Status:   components.MCPServerStatus(e.Status),
+			Restarts: e.Restarts,
+			Error:    e.Error,
+		})
+
 	case runner.LogEvent:
 		m.logs.AddLog(components.LogEntry{
 			Time:    e.Time,
@@ -364,18 +499,126 @@ func (m *Model) handleEvent(event runner.Event) tea.Cmd {
 }
 
 // sendChatMessage sends a message via the chat handler asynchronously.
-func (m Model) sendChatMessage(content string) tea.Cmd {
+func (m Model) sendChatMessage(exchange int, content string) tea.Cmd {
 	return func() tea.Msg {
 		if m.chatHandler == nil {
-			return ChatResponseMsg{Error: fmt.Errorf("chat not available")}
+			return ChatResponseMsg{Exchange: exchange, Error: fmt.Errorf("chat not available")}
 		}
 		m.chat.SetSending(true)
-		response, model, tokens, err := m.chatHandler.DirectChat(content)
+		response, model, tokens, err := m.chatHandler.DirectChat(exchange, content)
 		return ChatResponseMsg{
-			Content: response,
-			Model:   model,
-			Tokens:  tokens,
-			Error:   err,
+			Exchange: exchange,
+			Content:  response,
+			Model:    model,
+			Tokens:   tokens,
+			Error:    err,
+		}
+	}
+}
+
+// previewChatMessage runs a message through the pipeline preview asynchronously.
+func (m Model) previewChatMessage(content string) tea.Cmd {
+	return func() tea.Msg {
+		if m.chatHandler == nil {
+			return ChatPreviewMsg{Error: fmt.Errorf("chat not available")}
+		}
+		m.chat.SetSending(true)
+		preview, err := m.chatHandler.PreviewPipeline(content)
+		return ChatPreviewMsg{Preview: preview, Error: err}
+	}
+}
+
+// decideApproval approves or denies a tool call asynchronously. The outcome
+// arrives as a ToolEvent, so nothing is returned; a call that has already
+// been decided elsewhere or timed out is ignored.
+func (m Model) decideApproval(id string, approved bool) tea.Cmd {
+	return func() tea.Msg {
+		_ = m.approvalHandler.DecideApproval(id, approved)
+		return nil
+	}
+}
+
+// formatPipelinePreview describes what the agent would do with a message:
+// the route it chose, where the response would go, and the response itself.
+func formatPipelinePreview(p *runner.PipelinePreview) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Received on: %s\n", p.Topic)
+	switch {
+	case p.Route == "":
+		b.WriteString("Route: none (default publish topics)\n")
+	case p.RouteValid:
+		fmt.Fprintf(&b, "Route: %s\n", p.Route)
+	default:
+		fmt.Fprintf(&b, "Route: %s is not a configured route (default publish topics)\n", p.Route)
+	}
+
+	for _, call := range p.ToolCalls {
+		approval := ""
+		if call.RequiresApproval {
+			approval = ", requires approval"
+		}
+		fmt.Fprintf(&b, "Would call: %s %s (not executed%s)\n", call.Name, call.Arguments, approval)
+	}
+
+	if len(p.Publications) == 0 {
+		b.WriteString("Would publish: nothing (no publish topics)\n")
+	}
+	for _, pub := range p.Publications {
+		destination := "Athyr topic"
+		if pub.Plugin {
+			destination = "plugin"
+		}
+		fmt.Fprintf(&b, "Would publish: %s (%s)\n", pub.Topic, destination)
+	}
+
+	response, err := json.MarshalIndent(p.Response, "", "  ")
+	if err != nil {
+		response = []byte(err.Error())
+	}
+	b.WriteString("Response:\n")
+	b.Write(response)
+	return b.String()
+}
+
+// runChatCommand runs a Chat tab slash command asynchronously.
+func (m Model) runChatCommand(input string) tea.Cmd {
+	return func() tea.Msg {
+		if m.chatHandler == nil {
+			return ChatCommandMsg{Error: fmt.Errorf("chat not available")}
+		}
+
+		name, arg, _ := strings.Cut(input, " ")
+		arg = strings.TrimSpace(arg)
+		switch name {
+		case "/reset":
+			if err := m.chatHandler.ResetChat(); err != nil {
+				return ChatCommandMsg{Error: fmt.Errorf("reset failed: %w", err)}
+			}
+			return ChatCommandMsg{Info: "Conversation reset", Reset: true}
+		case "/system":
+			m.chatHandler.SetChatSystemPrompt(arg)
+			if arg == "" {
+				return ChatCommandMsg{Info: "Using the configured instructions"}
+			}
+			return ChatCommandMsg{Info: "System prompt set"}
+		case "/model":
+			m.chatHandler.SetChatModel(arg)
+			if arg == "" {
+				return ChatCommandMsg{Info: "Using the configured models"}
+			}
+			return ChatCommandMsg{Info: "Using model " + arg}
+		case "/preview":
+			return ChatCommandMsg{TogglePreview: true}
+		case "/save":
+			if arg == "" {
+				return ChatCommandMsg{Error: fmt.Errorf("usage: /save <file>")}
+			}
+			if err := m.chatHandler.SaveChat(arg); err != nil {
+				return ChatCommandMsg{Error: fmt.Errorf("save failed: %w", err)}
+			}
+			return ChatCommandMsg{Info: "Conversation saved to " + arg}
+		default:
+			return ChatCommandMsg{Error: fmt.Errorf("unknown command %s (try /reset, /system, /model, /save or /preview)", name)}
 		}
 	}
 }
@@ -528,7 +771,7 @@ func (m Model) View() string {
 
 // renderHeader renders the top header bar.
 func (m Model) renderHeader() string {
-	title := styles.HeaderTitle.Render("athyr-agent: " + m.cfg.Agent.Name)
+	title := styles.HeaderTitle.Render("athyr-agent: " + m.cfg.Redact(m.cfg.Agent.Name))
 
 	statusIcon := "●"
 	var statusStyle lipgloss.Style
@@ -22,6 +22,7 @@ type Options struct {
 	EventBus         runner.EventBus
 	ChatHandler      ChatHandler
 	MessagingHandler MessagingHandler
+	ApprovalHandler  ApprovalHandler
 	ServerAddr       string // Athyr server address
 }
 
@@ -41,6 +42,9 @@ func New(opts Options) (*TUI, error) {
 	if opts.MessagingHandler != nil {
 		model.SetMessagingHandler(opts.MessagingHandler)
 	}
+	if opts.ApprovalHandler != nil {
+		model.SetApprovalHandler(opts.ApprovalHandler)
+	}
 
 	program := tea.NewProgram(
 		model,
@@ -88,3 +92,10 @@ func (t *TUI) SetChatHandler(h ChatHandler) {
 func (t *TUI) SetMessagingHandler(h MessagingHandler) {
 	t.program.Send(SetMessagingHandlerMsg{Handler: h})
 }
+
+// SetApprovalHandler sets the approval handler after creation.
+// This sends a message through Bubble Tea's event loop to ensure
+// the handler is set on the actual model instance being used.
+func (t *TUI) SetApprovalHandler(h ApprovalHandler) {
+	t.program.Send(SetApprovalHandlerMsg{Handler: h})
+}